@@ -1,35 +1,92 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
+	"google.golang.org/grpc"
 	"orderbook/internal/api" // adjust this import path
+	"orderbook/internal/api/orderbookpb"
+	"orderbook/internal/exchange"
+	"orderbook/internal/fix"
+	"orderbook/internal/journal"
 	"orderbook/internal/orderbook"
 )
 
 const (
-	defaultPort = ":8080"
+	defaultPort         = ":8080"
+	expirySweepInterval = time.Second
 )
 
 func main() {
-	// Initialize orderbook
-	book := orderbook.NewOrderBook("MAIN")
+	dataDir := flag.String("data", "data", "Directory for the market registry, journals and snapshots")
+	fsync := flag.String("fsync", "always", "When to fsync the journals: always, interval or never")
+	snapshotInterval := flag.Duration("snapshot-interval", time.Minute, "How often to snapshot the books and compact the journals")
+	markets := flag.String("markets", "MAIN", "Comma separated markets to create on startup if missing")
+	history := flag.Int("event-history", 10000, "Events each market keeps for resuming event streams")
+	balances := flag.Bool("balances", false, "Reserve and settle account balances, so orders need funded accounts")
+	fixAddr := flag.String("fix", "", "Address to accept FIX 4.4 sessions on, e.g. :9878; disabled if empty")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	policy, err := journal.ParseSyncPolicy(*fsync)
+	if err != nil {
+		log.Fatalf("Invalid -fsync: %v", err)
+	}
+
+	// Open the exchange, recovering every market before serving
+	options := []exchange.Option{exchange.WithDataDir(*dataDir, policy), exchange.WithEventHistory(*history)}
+	if *balances {
+		options = append(options, exchange.WithBalances())
+	}
+	ex, err := exchange.New(options...)
+	if err != nil {
+		log.Fatalf("Failed to open exchange: %v", err)
+	}
+	defer ex.Close()
+
+	for _, symbol := range strings.Split(*markets, ",") {
+		if symbol = strings.TrimSpace(symbol); symbol == "" {
+			continue
+		}
+		if _, err := ex.CreateMarket(symbol, orderbook.DefaultInstrumentSpec()); err != nil && !errors.Is(err, exchange.ErrMarketExists) {
+			log.Fatalf("Failed to create market %s: %v", symbol, err)
+		}
+	}
+	log.Printf("Serving %d markets", len(ex.Markets()))
+
+	go ex.RunSnapshots(ctx, *snapshotInterval)
+
+	// Remove GTD orders once they expire
+	go ex.RunExpirySweeper(ctx, expirySweepInterval)
 
 	// Initialize handler
-	handler := api.NewHandler(book)
+	handler := api.NewHandler(ex)
 
 	// Initialize router
 	router := api.NewRouter(handler)
 	mux := router.SetupRoutes()
 
+	// Serve the gRPC API on the same port
+	grpcServer := grpc.NewServer()
+	orderbookpb.RegisterOrderBookServer(grpcServer, api.NewGRPCServer(ex))
+	defer grpcServer.Stop()
+
 	// Create server
 	server := &http.Server{
 		Addr:    defaultPort,
-		Handler: mux,
+		Handler: api.WithGRPC(grpcServer, mux),
 	}
 
 	// Start server in a goroutine
@@ -40,6 +97,18 @@ func main() {
 		}
 	}()
 
+	// Accept FIX sessions, kept under the data directory
+	if *fixAddr != "" {
+		acceptor := fix.NewAcceptor(ex, filepath.Join(*dataDir, "fix"))
+		defer acceptor.Close()
+		go func() {
+			log.Printf("Accepting FIX sessions on %s", *fixAddr)
+			if err := acceptor.ListenAndServe(*fixAddr); err != nil && !errors.Is(err, fix.ErrAcceptorClosed) {
+				log.Fatalf("FIX acceptor failed: %v", err)
+			}
+		}()
+	}
+
 	// Graceful shutdown
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
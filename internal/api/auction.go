@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"orderbook/internal/orderbook"
+	"time"
+)
+
+// Handler for GetIndicative function
+func (h *Handler) GetIndicative(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	indicative, err := book.Indicative()
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(indicative); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Handler for StartAuction function
+func (h *Handler) StartAuction(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	// Without a duration the auction runs until it is uncrossed
+	var request struct {
+		Type     orderbook.AuctionType `json:"type"`
+		Duration string                `json:"duration"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid Request Body", http.StatusBadRequest)
+		return
+	}
+	var duration time.Duration
+	if request.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(request.Duration); err != nil {
+			http.Error(w, "Invalid Duration", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := book.StartAuction(request.Type, duration); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handler for Uncross function
+func (h *Handler) Uncross(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	trades, err := book.Uncross()
+	if err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(trades); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"orderbook/internal/orderbook"
+)
+
+// Body of deposit and withdrawal requests
+type transferRequest struct {
+	Asset  orderbook.Asset   `json:"asset"`
+	Amount orderbook.Decimal `json:"amount"`
+}
+
+// Handler for GetBalances function
+func (h *Handler) GetBalances(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(book.Balances(r.PathValue("account"))); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Handler for Deposit function
+func (h *Handler) Deposit(w http.ResponseWriter, r *http.Request) {
+	h.transfer(w, r, (*orderbook.OrderBook).Deposit)
+}
+
+// Handler for Withdraw function
+func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
+	h.transfer(w, r, (*orderbook.OrderBook).Withdraw)
+}
+
+// Move funds in or out of the account named by the {account} path segment
+func (h *Handler) transfer(w http.ResponseWriter, r *http.Request, apply func(*orderbook.OrderBook, string, orderbook.Asset, orderbook.Decimal) error) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	var request transferRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid Request Body", http.StatusBadRequest)
+		return
+	}
+
+	if err := apply(book, r.PathValue("account"), request.Asset, request.Amount); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
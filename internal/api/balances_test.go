@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"strings"
+	"testing"
+)
+
+func TestBalanceRoutes(t *testing.T) {
+	ex, _ := exchange.New(exchange.WithBalances())
+	ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	mux := NewRouter(NewHandler(ex)).SetupRoutes()
+
+	tests := []struct {
+		name         string
+		method       string
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{"Unfunded Order", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "100", "amount": "1", "account": "alice"}`, http.StatusBadRequest},
+		{"Deposit", "POST", "/markets/BTC-USD/accounts/alice/deposit", `{"asset": "QUOTE", "amount": "150"}`, http.StatusOK},
+		{"Deposit Unknown Asset", "POST", "/markets/BTC-USD/accounts/alice/deposit", `{"asset": "EUR", "amount": "1"}`, http.StatusBadRequest},
+		{"Funded Order", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "100", "amount": "1", "account": "alice"}`, http.StatusCreated},
+		{"Order Without Account", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "100", "amount": "1"}`, http.StatusBadRequest},
+		{"Withdraw Reserved", "POST", "/markets/BTC-USD/accounts/alice/withdraw", `{"asset": "QUOTE", "amount": "100"}`, http.StatusBadRequest},
+		{"Withdraw", "POST", "/markets/BTC-USD/accounts/alice/withdraw", `{"asset": "QUOTE", "amount": "10"}`, http.StatusOK},
+		{"Deposit Wrong Method", "GET", "/markets/BTC-USD/accounts/alice/deposit", "", http.StatusMethodNotAllowed},
+		{"Unknown Market", "GET", "/markets/ETH-USD/accounts/alice/balances", "", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, w.Code, w.Body)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/markets/BTC-USD/accounts/alice/balances", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var balances orderbook.AccountBalances
+	if err := json.Unmarshal(w.Body.Bytes(), &balances); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	expected := orderbook.Balance{Available: dec(40.0), Reserved: dec(100.0)}
+	if balances.Quote != expected {
+		t.Errorf("Expected quote balance %+v, got %+v", expected, balances.Quote)
+	}
+}
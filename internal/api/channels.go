@@ -0,0 +1,296 @@
+package api
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"orderbook/internal/orderbook"
+)
+
+const (
+	defaultFeedDepth = 10
+	maxFeedDepth     = 1000
+)
+
+// channelState keeps what a feed channel has told its client, to turn book
+// events into the updates of the channel.
+type channelState interface {
+	snapshot() any
+	// apply returns the update for an event, false if the channel has none
+	apply(event orderbook.Event) (any, bool)
+}
+
+func validChannel(channel string, depth int) bool {
+	switch channel {
+	case "trades", "orders", "ticker":
+		return depth == 0
+	case "depth":
+		return depth >= 0 && depth <= maxFeedDepth
+	}
+	return false
+}
+
+// newChannelState starts a channel from the view of the book. The channel
+// must be valid.
+func newChannelState(channel string, depth int, view orderbook.BookView) channelState {
+	switch channel {
+	case "trades":
+		return &tradesState{lastPrice: view.LastPrice}
+	case "orders":
+		return &ordersState{bids: view.Bids, asks: view.Asks}
+	case "ticker":
+		t := &tickerState{bids: newDepthSide(orderbook.Buy, 1, view.Bids), asks: newDepthSide(orderbook.Sell, 1, view.Asks)}
+		t.current = feedTicker{LastPrice: view.LastPrice, Status: view.Status}
+		t.current.BestBid, t.current.BestBidAmount = t.bids.best()
+		t.current.BestAsk, t.current.BestAskAmount = t.asks.best()
+		return t
+	}
+	if depth == 0 {
+		depth = defaultFeedDepth
+	}
+	return &depthState{bids: newDepthSide(orderbook.Buy, depth, view.Bids), asks: newDepthSide(orderbook.Sell, depth, view.Asks)}
+}
+
+// feedTrade is what the trades channel publishes of a trade, nothing about
+// the orders or accounts behind it.
+type feedTrade struct {
+	ID            string            `json:"id"`
+	Price         orderbook.Decimal `json:"price"`
+	Amount        orderbook.Decimal `json:"amount"`
+	AggressorSide orderbook.Side    `json:"aggressor_side"`
+	Timestamp     time.Time         `json:"timestamp"`
+}
+
+type tradesState struct {
+	lastPrice orderbook.Decimal
+}
+
+func (s *tradesState) snapshot() any {
+	return map[string]orderbook.Decimal{"last_price": s.lastPrice}
+}
+
+func (s *tradesState) apply(event orderbook.Event) (any, bool) {
+	if event.Type != orderbook.EventTrade {
+		return nil, false
+	}
+	return publicTrade(event.Trade), true
+}
+
+func publicTrade(t *orderbook.Trade) feedTrade {
+	return feedTrade{ID: t.ID, Price: t.Price, Amount: t.Amount, AggressorSide: t.AggressorSide, Timestamp: t.Timestamp}
+}
+
+// ordersState serves the order-by-order book: the snapshot lists the
+// resting orders, and every change to one is an update, with a zero amount
+// once it left the book.
+type ordersState struct {
+	bids, asks []orderbook.BookOrder
+}
+
+func (s *ordersState) snapshot() any {
+	return map[string][]orderbook.BookOrder{"bids": nonNil(s.bids), "asks": nonNil(s.asks)}
+}
+
+func (s *ordersState) apply(event orderbook.Event) (any, bool) {
+	if event.Type != orderbook.EventBookOrderChanged {
+		return nil, false
+	}
+	return *event.BookOrder, true
+}
+
+// feedLevel is a price level of the depth channel, with a zero amount in an
+// update once the level left the top of the book.
+type feedLevel struct {
+	Price  orderbook.Decimal `json:"price"`
+	Amount orderbook.Decimal `json:"amount"`
+	Orders int               `json:"orders"`
+}
+
+// feedDepth is a message of the depth channel. Checksum is that of the top
+// levels once the message is applied, see orderbook.Checksum.
+type feedDepth struct {
+	Bids     []feedLevel `json:"bids"`
+	Asks     []feedLevel `json:"asks"`
+	Checksum uint32      `json:"checksum"`
+}
+
+// depthState serves the top levels of each side of the book. Updates carry
+// only the levels that changed.
+type depthState struct {
+	bids, asks *depthSide
+}
+
+func (s *depthState) snapshot() any {
+	return feedDepth{Bids: s.bids.publish(), Asks: s.asks.publish(), Checksum: s.checksum()}
+}
+
+func (s *depthState) checksum() uint32 {
+	return orderbook.Checksum(s.bids.aggregates(), s.asks.aggregates(), s.bids.depth)
+}
+
+func (s *depthState) apply(event orderbook.Event) (any, bool) {
+	if event.Type != orderbook.EventBookLevelChanged {
+		return nil, false
+	}
+	update := feedDepth{Bids: []feedLevel{}, Asks: []feedLevel{}}
+	if event.Side == orderbook.Buy {
+		s.bids.set(*event.Level)
+		update.Bids = s.bids.diff()
+	} else {
+		s.asks.set(*event.Level)
+		update.Asks = s.asks.diff()
+	}
+	if len(update.Bids)+len(update.Asks) == 0 {
+		return nil, false
+	}
+	update.Checksum = s.checksum()
+	return update, true
+}
+
+// depthSide keeps every level of a side, best price first, and the top
+// levels last published.
+type depthSide struct {
+	side   orderbook.Side
+	depth  int
+	prices []orderbook.Decimal
+	levels map[orderbook.Decimal]feedLevel
+	sent   map[orderbook.Decimal]feedLevel
+}
+
+func newDepthSide(side orderbook.Side, depth int, orders []orderbook.BookOrder) *depthSide {
+	d := &depthSide{side: side, depth: depth, levels: make(map[orderbook.Decimal]feedLevel)}
+	for _, order := range orders {
+		level, ok := d.levels[order.Price]
+		if !ok {
+			d.prices = append(d.prices, order.Price)
+		}
+		d.levels[order.Price] = feedLevel{Price: order.Price, Amount: level.Amount + order.Amount, Orders: level.Orders + 1}
+	}
+	return d
+}
+
+func (d *depthSide) compare(a, b orderbook.Decimal) int {
+	if d.side == orderbook.Buy {
+		return cmp.Compare(b, a)
+	}
+	return cmp.Compare(a, b)
+}
+
+// set records the new amount of a level, removing it once empty.
+func (d *depthSide) set(level orderbook.OrderBookLevel) {
+	i, found := slices.BinarySearchFunc(d.prices, level.Price, d.compare)
+	switch {
+	case level.TotalAmount == 0 && found:
+		d.prices = slices.Delete(d.prices, i, i+1)
+		delete(d.levels, level.Price)
+	case level.TotalAmount > 0 && !found:
+		d.prices = slices.Insert(d.prices, i, level.Price)
+		fallthrough
+	case level.TotalAmount > 0:
+		d.levels[level.Price] = feedLevel{Price: level.Price, Amount: level.TotalAmount, Orders: level.OrderCount}
+	}
+}
+
+func (d *depthSide) top() []feedLevel {
+	levels := make([]feedLevel, 0, min(d.depth, len(d.prices)))
+	for _, price := range d.prices[:cap(levels)] {
+		levels = append(levels, d.levels[price])
+	}
+	return levels
+}
+
+// aggregates returns the top levels as the book aggregates them.
+func (d *depthSide) aggregates() []orderbook.OrderBookLevel {
+	levels := make([]orderbook.OrderBookLevel, 0, min(d.depth, len(d.prices)))
+	for _, level := range d.top() {
+		levels = append(levels, orderbook.OrderBookLevel{Price: level.Price, TotalAmount: level.Amount, OrderCount: level.Orders})
+	}
+	return levels
+}
+
+func (d *depthSide) best() (price, amount orderbook.Decimal) {
+	if len(d.prices) == 0 {
+		return 0, 0
+	}
+	return d.prices[0], d.levels[d.prices[0]].Amount
+}
+
+// publish returns the top levels and remembers them as sent.
+func (d *depthSide) publish() []feedLevel {
+	levels := d.top()
+	d.sent = make(map[orderbook.Decimal]feedLevel, len(levels))
+	for _, level := range levels {
+		d.sent[level.Price] = level
+	}
+	return levels
+}
+
+// diff returns how the top levels changed since last sent and remembers
+// them as sent.
+func (d *depthSide) diff() []feedLevel {
+	before := d.sent
+	changes := []feedLevel{}
+	for _, level := range d.publish() {
+		if sent, ok := before[level.Price]; !ok || sent != level {
+			changes = append(changes, level)
+		}
+		delete(before, level.Price)
+	}
+	for price := range before {
+		changes = append(changes, feedLevel{Price: price})
+	}
+	slices.SortFunc(changes, func(a, b feedLevel) int { return d.compare(a.Price, b.Price) })
+	return changes
+}
+
+// feedTicker sums up a market: its last trade, best prices and status.
+type feedTicker struct {
+	LastPrice     orderbook.Decimal    `json:"last_price"`
+	LastAmount    orderbook.Decimal    `json:"last_amount"`
+	BestBid       orderbook.Decimal    `json:"best_bid"`
+	BestBidAmount orderbook.Decimal    `json:"best_bid_amount"`
+	BestAsk       orderbook.Decimal    `json:"best_ask"`
+	BestAskAmount orderbook.Decimal    `json:"best_ask_amount"`
+	Status        orderbook.BookStatus `json:"status"`
+}
+
+// tickerState publishes the ticker whenever any of it changes.
+type tickerState struct {
+	bids, asks *depthSide
+	current    feedTicker
+}
+
+func (s *tickerState) snapshot() any {
+	return s.current
+}
+
+func (s *tickerState) apply(event orderbook.Event) (any, bool) {
+	next := s.current
+	switch event.Type {
+	case orderbook.EventTrade:
+		next.LastPrice, next.LastAmount = event.Trade.Price, event.Trade.Amount
+	case orderbook.EventBookLevelChanged:
+		if event.Side == orderbook.Buy {
+			s.bids.set(*event.Level)
+			next.BestBid, next.BestBidAmount = s.bids.best()
+		} else {
+			s.asks.set(*event.Level)
+			next.BestAsk, next.BestAskAmount = s.asks.best()
+		}
+	case orderbook.EventStatusChanged:
+		next.Status = event.Status
+	}
+	if next == s.current {
+		return nil, false
+	}
+	s.current = next
+	return next, true
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
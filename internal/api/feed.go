@@ -0,0 +1,242 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"orderbook/internal/orderbook"
+)
+
+const (
+	feedSendBuffer = 256 // Messages queued per connection before updates are dropped
+	feedReadLimit  = 4096
+	feedWriteWait  = 10 * time.Second
+	feedPongWait   = 60 * time.Second
+	feedPingPeriod = feedPongWait * 9 / 10
+)
+
+// The feed only publishes market data, so any origin may connect
+var upgrader = websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+
+// feedRequest is a message from a feed client.
+type feedRequest struct {
+	Op      string `json:"op"` // "subscribe" or "unsubscribe"
+	Market  string `json:"market"`
+	Channel string `json:"channel"`         // "trades", "depth", "orders" or "ticker"
+	Depth   int    `json:"depth,omitempty"` // Levels per side on the depth channel
+}
+
+// feedMessage is a message to a feed client. Seq numbers the messages of a
+// subscription from 1 for its snapshot, so a client that sees a gap missed
+// updates and must subscribe again to resync. BookSeq is the last book event
+// the message reflects.
+type feedMessage struct {
+	Type    string `json:"type"` // "snapshot", "update", "unsubscribed" or "error"
+	Market  string `json:"market,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	Seq     uint64 `json:"seq,omitempty"`
+	BookSeq uint64 `json:"book_seq,omitempty"`
+	Data    any    `json:"data,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Handler for MarketData function
+func (h *Handler) MarketData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The upgrader answers failed handshakes itself
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &feedConn{
+		ws:   ws,
+		send: make(chan feedMessage, feedSendBuffer),
+		done: make(chan struct{}),
+		subs: make(map[feedKey]*feedSubscription),
+	}
+	go c.write()
+	c.read(h)
+	c.close()
+}
+
+// feedConn is a client connected to the feed.
+type feedConn struct {
+	ws   *websocket.Conn
+	send chan feedMessage
+	done chan struct{}
+	once sync.Once
+
+	mu   sync.Mutex
+	subs map[feedKey]*feedSubscription
+}
+
+type feedKey struct {
+	market  string
+	channel string
+}
+
+// feedSubscription turns the events of one book into the messages of one
+// channel.
+type feedSubscription struct {
+	feedKey
+	book     *orderbook.Subscription
+	state    channelState
+	seq      uint64
+	finished chan struct{}
+}
+
+// read handles the requests of the client until it goes away.
+func (c *feedConn) read(h *Handler) {
+	c.ws.SetReadLimit(feedReadLimit)
+	c.ws.SetReadDeadline(time.Now().Add(feedPongWait))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(feedPongWait))
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var request feedRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			c.push(feedMessage{Type: "error", Error: "Invalid Request"})
+			continue
+		}
+		key := feedKey{market: request.Market, channel: request.Channel}
+		switch request.Op {
+		case "subscribe":
+			c.subscribe(h, key, request.Depth)
+		case "unsubscribe":
+			c.unsubscribe(key)
+		default:
+			c.push(feedMessage{Type: "error", Market: key.market, Channel: key.channel, Error: "Unknown Operation"})
+		}
+	}
+}
+
+// subscribe sends the snapshot of a channel and starts its updates. A
+// subscription to the same channel is replaced, which is how clients resync.
+func (c *feedConn) subscribe(h *Handler, key feedKey, depth int) {
+	failed := feedMessage{Type: "error", Market: key.market, Channel: key.channel}
+	if !validChannel(key.channel, depth) {
+		failed.Error = "Invalid Channel"
+		c.push(failed)
+		return
+	}
+	book, err := h.exchange.Market(key.market)
+	if err != nil {
+		failed.Error = "Market Not Found"
+		c.push(failed)
+		return
+	}
+	c.unsubscribe(key)
+
+	events, view := book.SubscribeView()
+	s := &feedSubscription{
+		feedKey:  key,
+		book:     events,
+		state:    newChannelState(key.channel, depth, view),
+		finished: make(chan struct{}),
+	}
+	c.mu.Lock()
+	c.subs[key] = s
+	c.mu.Unlock()
+
+	c.publish(s, "snapshot", view.Seq, s.state.snapshot())
+	go func() {
+		defer close(s.finished)
+		for event := range events.C {
+			if data, ok := s.state.apply(event); ok {
+				c.publish(s, "update", event.Seq, data)
+			}
+		}
+		if err := events.Err(); err != nil {
+			// Dropped by the book, so the client has to subscribe again
+			c.mu.Lock()
+			if c.subs[key] == s {
+				delete(c.subs, key)
+			}
+			c.mu.Unlock()
+			failed.Error = "Subscription Dropped"
+			c.push(failed)
+		}
+	}()
+}
+
+// unsubscribe stops the updates of a channel, once the last one is queued.
+func (c *feedConn) unsubscribe(key feedKey) {
+	c.mu.Lock()
+	s, ok := c.subs[key]
+	delete(c.subs, key)
+	c.mu.Unlock()
+
+	if ok {
+		s.book.Close()
+		<-s.finished
+		c.push(feedMessage{Type: "unsubscribed", Market: key.market, Channel: key.channel})
+	}
+}
+
+// publish queues the next message of a subscription. Messages that do not
+// fit the queue of a slow client are dropped; the gap in Seq tells it.
+func (c *feedConn) publish(s *feedSubscription, typ string, bookSeq uint64, data any) {
+	s.seq++
+	c.push(feedMessage{Type: typ, Market: s.market, Channel: s.channel, Seq: s.seq, BookSeq: bookSeq, Data: data})
+}
+
+func (c *feedConn) push(message feedMessage) {
+	select {
+	case c.send <- message:
+	default:
+	}
+}
+
+// write sends queued messages and keeps the connection alive with pings.
+func (c *feedConn) write() {
+	ticker := time.NewTicker(feedPingPeriod)
+	defer ticker.Stop()
+	defer c.ws.Close()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case message := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(feedWriteWait))
+			if err := c.ws.WriteJSON(message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.ws.SetWriteDeadline(time.Now().Add(feedWriteWait))
+			if err := c.ws.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// close ends every subscription and the connection.
+func (c *feedConn) close() {
+	c.once.Do(func() {
+		c.mu.Lock()
+		subs := c.subs
+		c.subs = nil
+		c.mu.Unlock()
+
+		for _, s := range subs {
+			s.book.Close()
+		}
+		close(c.done)
+		c.ws.Close()
+	})
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type testFeedMessage struct {
+	Type    string          `json:"type"`
+	Market  string          `json:"market"`
+	Channel string          `json:"channel"`
+	Seq     uint64          `json:"seq"`
+	Data    json.RawMessage `json:"data"`
+	Error   string          `json:"error"`
+}
+
+func readFeed(t *testing.T, ws *websocket.Conn) testFeedMessage {
+	t.Helper()
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	var message testFeedMessage
+	if err := ws.ReadJSON(&message); err != nil {
+		t.Fatalf("Failed to read from the feed: %v", err)
+	}
+	return message
+}
+
+func TestMarketDataFeed(t *testing.T) {
+	ex, _ := exchange.New()
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	for i, price := range []float64{100, 99, 98} {
+		book.PlaceOrder(orderbook.Order{ID: "b" + string(rune('1'+i)), Side: orderbook.Buy, Price: dec(price), Amount: dec(float64(i + 1))})
+	}
+	book.PlaceOrder(orderbook.Order{ID: "a1", Side: orderbook.Sell, Price: dec(101.0), Amount: dec(1.0)})
+
+	server := httptest.NewServer(NewRouter(NewHandler(ex)).SetupRoutes())
+	defer server.Close()
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	errorTests := []struct {
+		name    string
+		request string
+		err     string
+	}{
+		{"Unknown market", `{"op": "subscribe", "market": "ETH-USD", "channel": "trades"}`, "Market Not Found"},
+		{"Unknown channel", `{"op": "subscribe", "market": "BTC-USD", "channel": "candles"}`, "Invalid Channel"},
+		{"Depth too large", `{"op": "subscribe", "market": "BTC-USD", "channel": "depth", "depth": 5000}`, "Invalid Channel"},
+		{"Unknown operation", `{"op": "list"}`, "Unknown Operation"},
+		{"Invalid request", `{"op":`, "Invalid Request"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			ws.WriteMessage(websocket.TextMessage, []byte(tt.request))
+			if message := readFeed(t, ws); message.Type != "error" || message.Error != tt.err {
+				t.Errorf("Expected error %q, got %+v", tt.err, message)
+			}
+		})
+	}
+
+	levels := func(data json.RawMessage) (depth feedDepth) {
+		json.Unmarshal(data, &depth)
+		return depth
+	}
+
+	// The depth snapshot holds the top levels, updates what changed in them
+	ws.WriteJSON(feedRequest{Op: "subscribe", Market: "BTC-USD", Channel: "depth", Depth: 2})
+	snapshot := readFeed(t, ws)
+	depth := levels(snapshot.Data)
+	if snapshot.Type != "snapshot" || snapshot.Seq != 1 || len(depth.Bids) != 2 || depth.Bids[1] != (feedLevel{dec(99.0), dec(2.0), 1}) || len(depth.Asks) != 1 {
+		t.Errorf("Expected a snapshot of two bids and an ask, got %+v", snapshot)
+	}
+
+	book.PlaceOrder(orderbook.Order{ID: "b4", Side: orderbook.Buy, Price: dec(99.5), Amount: dec(1.0)})
+	update := readFeed(t, ws)
+	expected := []feedLevel{{dec(99.5), dec(1.0), 1}, {dec(99.0), 0, 0}}
+	if depth := levels(update.Data); update.Type != "update" || update.Seq != 2 || !reflect.DeepEqual(depth.Bids, expected) || len(depth.Asks) != 0 {
+		t.Errorf("Expected 99.5 to push 99 out of the top, got %s", update.Data)
+	}
+	bids := []orderbook.OrderBookLevel{{Price: dec(100.0), TotalAmount: dec(1.0)}, {Price: dec(99.5), TotalAmount: dec(1.0)}}
+	asks := []orderbook.OrderBookLevel{{Price: dec(101.0), TotalAmount: dec(1.0)}}
+	if sum := levels(update.Data).Checksum; sum != orderbook.Checksum(bids, asks, 2) {
+		t.Errorf("Expected the checksum of the top two levels, got %d", sum)
+	}
+
+	// Each channel numbers its own messages
+	ws.WriteJSON(feedRequest{Op: "subscribe", Market: "BTC-USD", Channel: "trades"})
+	if snapshot := readFeed(t, ws); snapshot.Channel != "trades" || snapshot.Seq != 1 {
+		t.Errorf("Expected the trades snapshot, got %+v", snapshot)
+	}
+	book.ProcessOrder(orderbook.Order{Side: orderbook.Sell, Price: dec(100.0), Amount: dec(1.0)})
+	messages := map[string]testFeedMessage{}
+	for range 2 {
+		message := readFeed(t, ws)
+		messages[message.Channel] = message
+	}
+	var trade feedTrade
+	json.Unmarshal(messages["trades"].Data, &trade)
+	if messages["trades"].Seq != 2 || trade.Price != dec(100.0) || trade.AggressorSide != orderbook.Sell {
+		t.Errorf("Expected the trade at 100, got %+v", messages["trades"])
+	}
+	if messages["depth"].Seq != 3 {
+		t.Errorf("Expected the third depth message, got %+v", messages["depth"])
+	}
+
+	// Subscribing again resyncs from a new snapshot
+	ws.WriteJSON(feedRequest{Op: "unsubscribe", Market: "BTC-USD", Channel: "trades"})
+	if message := readFeed(t, ws); message.Type != "unsubscribed" {
+		t.Errorf("Expected the trades to stop, got %+v", message)
+	}
+	ws.WriteJSON(feedRequest{Op: "subscribe", Market: "BTC-USD", Channel: "depth"})
+	readFeed(t, ws) // Old subscription ends
+	snapshot = readFeed(t, ws)
+	if depth := levels(snapshot.Data); snapshot.Seq != 1 || len(depth.Bids) != 3 || depth.Bids[0].Price != dec(99.5) {
+		t.Errorf("Expected a fresh snapshot of three bids, got %s", snapshot.Data)
+	}
+
+	// The order channel lists the resting orders, the ticker sums them up
+	ws.WriteJSON(feedRequest{Op: "subscribe", Market: "BTC-USD", Channel: "orders"})
+	var orders struct{ Bids, Asks []orderbook.BookOrder }
+	json.Unmarshal(readFeed(t, ws).Data, &orders)
+	if len(orders.Bids) != 3 || orders.Bids[0].ID != "b4" || orders.Asks[0].ID != "a1" {
+		t.Errorf("Expected the resting orders, got %+v", orders)
+	}
+	ws.WriteJSON(feedRequest{Op: "subscribe", Market: "BTC-USD", Channel: "ticker"})
+	var ticker feedTicker
+	json.Unmarshal(readFeed(t, ws).Data, &ticker)
+	expectedTicker := feedTicker{LastPrice: dec(100.0), BestBid: dec(99.5), BestBidAmount: dec(1.0), BestAsk: dec(101.0), BestAskAmount: dec(1.0), Status: orderbook.BookOpen}
+	if ticker != expectedTicker {
+		t.Errorf("Expected ticker %+v, got %+v", expectedTicker, ticker)
+	}
+
+	book.CancelOrder("a1")
+	messages = map[string]testFeedMessage{}
+	for range 3 {
+		message := readFeed(t, ws)
+		messages[message.Channel] = message
+	}
+	var changed orderbook.BookOrder
+	json.Unmarshal(messages["orders"].Data, &changed)
+	if changed.ID != "a1" || changed.Amount != 0 {
+		t.Errorf("Expected a1 to leave the book, got %+v", changed)
+	}
+	json.Unmarshal(messages["ticker"].Data, &ticker)
+	if ticker.BestAsk != 0 || messages["ticker"].Seq != 2 {
+		t.Errorf("Expected the ticker without an ask, got %+v", messages["ticker"])
+	}
+	if depth := levels(messages["depth"].Data); !reflect.DeepEqual(depth.Asks, []feedLevel{{dec(101.0), 0, 0}}) {
+		t.Errorf("Expected the ask level to go, got %s", messages["depth"].Data)
+	}
+}
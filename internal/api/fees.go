@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"orderbook/internal/orderbook"
+)
+
+// Handler for GetFeeSchedule function
+func (h *Handler) GetFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(book.FeeSchedule()); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Handler for SetFeeSchedule function
+func (h *Handler) SetFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	var schedule orderbook.FeeSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "Invalid Request Body", http.StatusBadRequest)
+		return
+	}
+
+	if err := book.SetFeeSchedule(schedule); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handler for GetAccountFees function
+func (h *Handler) GetAccountFees(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(book.AccountFees()); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
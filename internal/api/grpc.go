@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"orderbook/internal/api/orderbookpb"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+// GRPCServer serves orderbook.proto, the gRPC counterpart of the order and
+// order book endpoints of Handler, for every market of an exchange.
+type GRPCServer struct {
+	orderbookpb.UnimplementedOrderBookServer
+	exchange *exchange.Exchange
+}
+
+// Create a new gRPC server for every market of an exchange
+func NewGRPCServer(ex *exchange.Exchange) *GRPCServer {
+	return &GRPCServer{exchange: ex}
+}
+
+// WithGRPC serves gRPC requests with gs and every other request with next,
+// accepting HTTP/2 without TLS so that one port serves both APIs.
+func WithGRPC(gs *grpc.Server, next http.Handler) http.Handler {
+	return h2c.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			gs.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}), &http2.Server{})
+}
+
+// Look up the book of the market a request names
+func (s *GRPCServer) market(symbol string) (*orderbook.OrderBook, error) {
+	book, err := s.exchange.Market(symbol)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "Market Not Found")
+	}
+	return book, nil
+}
+
+// The gRPC codes of errors, as errorStatus and writeRiskError pick HTTP
+// statuses
+func grpcError(err error) error {
+	var rejection *risk.Rejection
+	switch {
+	case errors.As(err, &rejection):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, exchange.ErrMarketNotFound), errors.Is(err, orderbook.ErrOrderNotFound), errors.Is(err, orderbook.ErrNoOrders):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, orderbook.ErrPostOnlyWouldCross):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	switch errorStatus(err) {
+	case http.StatusInternalServerError:
+		return status.Error(codes.Internal, err.Error())
+	case http.StatusLocked:
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// PlaceOrder adds a limit order to a book under a new ID
+func (s *GRPCServer) PlaceOrder(ctx context.Context, req *orderbookpb.PlaceOrderRequest) (*orderbookpb.PlaceOrderResponse, error) {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	order, err := orderFromProto(req.Order)
+	if err != nil {
+		return nil, err
+	}
+
+	order.ID = uuid.New().String() // Clients must not pick IDs that rewrite other orders
+
+	if err := book.PlaceOrder(order); err != nil {
+		return nil, grpcError(err)
+	}
+	return &orderbookpb.PlaceOrderResponse{OrderId: order.ID}, nil
+}
+
+// ProcessOrder matches an order against a book
+func (s *GRPCServer) ProcessOrder(ctx context.Context, req *orderbookpb.ProcessOrderRequest) (*orderbookpb.ProcessResult, error) {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	order, err := orderFromProto(req.Order)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPrices(order); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	result, err := book.ProcessOrder(order)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return processResultProto(result), nil
+}
+
+// CancelOrder removes a resting or stop order from a book
+func (s *GRPCServer) CancelOrder(ctx context.Context, req *orderbookpb.CancelOrderRequest) (*orderbookpb.CancelOrderResponse, error) {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Order ID is Required")
+	}
+
+	if err := book.CancelOrder(req.OrderId); err != nil {
+		return nil, grpcError(err)
+	}
+	return &orderbookpb.CancelOrderResponse{}, nil
+}
+
+// ModifyOrder changes the price and open amount of an order, and the stop
+// price of a stop order
+func (s *GRPCServer) ModifyOrder(ctx context.Context, req *orderbookpb.ModifyOrderRequest) (*orderbookpb.ModifyOrderResponse, error) {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if req.OrderId == "" {
+		return nil, status.Error(codes.InvalidArgument, "Order ID is Required")
+	}
+
+	// An empty price is zero, as stop market orders have no limit price
+	price, err := fromDecimal(req.Price, "Price")
+	if err != nil {
+		return nil, err
+	}
+	amount, err := fromDecimal(req.Amount, "Amount")
+	if err != nil {
+		return nil, err
+	}
+	stopPrice, err := fromDecimal(req.StopPrice, "Stop Price")
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StopPrice != "" {
+		err = book.ModifyStopOrder(req.OrderId, stopPrice, price, amount)
+	} else {
+		err = book.ModifyOrder(req.OrderId, price, amount)
+	}
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &orderbookpb.ModifyOrderResponse{}, nil
+}
+
+// GetBestBid returns the first bid in priority order
+func (s *GRPCServer) GetBestBid(ctx context.Context, req *orderbookpb.GetBestBidRequest) (*orderbookpb.Order, error) {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	order, err := book.GetBestBid()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return orderProto(order), nil
+}
+
+// GetBestAsk returns the first ask in priority order
+func (s *GRPCServer) GetBestAsk(ctx context.Context, req *orderbookpb.GetBestAskRequest) (*orderbookpb.Order, error) {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	order, err := book.GetBestAsk()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return orderProto(order), nil
+}
+
+// GetSnapshot returns the price levels of a book
+func (s *GRPCServer) GetSnapshot(ctx context.Context, req *orderbookpb.GetSnapshotRequest) (*orderbookpb.Snapshot, error) {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	return snapshotProto(book.GetOrderBookSnapshot()), nil
+}
+
+// StreamTrades sends the public trades of a market, as the trades channel of
+// the market data feed does. The headers are sent once subscribed, so that a
+// client can wait for them to know it misses no trade from then on
+func (s *GRPCServer) StreamTrades(req *orderbookpb.StreamTradesRequest, stream grpc.ServerStreamingServer[orderbookpb.PublicTrade]) error {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return err
+	}
+	sub, view := book.SubscribeView()
+	defer sub.Close()
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+
+	return relay(stream.Context(), sub, newChannelState("trades", 0, view), func(update any) error {
+		return stream.Send(publicTradeProto(update.(feedTrade)))
+	})
+}
+
+// StreamDepth sends the top levels of a market, as the depth channel of the
+// market data feed does
+func (s *GRPCServer) StreamDepth(req *orderbookpb.StreamDepthRequest, stream grpc.ServerStreamingServer[orderbookpb.DepthUpdate]) error {
+	book, err := s.market(req.Symbol)
+	if err != nil {
+		return err
+	}
+	if !validChannel("depth", int(req.Depth)) {
+		return status.Error(codes.InvalidArgument, "Invalid Depth")
+	}
+	sub, view := book.SubscribeView()
+	defer sub.Close()
+
+	state := newChannelState("depth", int(req.Depth), view)
+	if err := stream.Send(depthProto(state.snapshot().(feedDepth), true)); err != nil {
+		return err
+	}
+	return relay(stream.Context(), sub, state, func(update any) error {
+		return stream.Send(depthProto(update.(feedDepth), false))
+	})
+}
+
+// relay sends the updates of a channel until the client goes away. Unlike
+// the feed, it never skips updates: gRPC flow control holds them back in the
+// subscription while the client catches up. A client falling further behind
+// than the subscription holds is cut off, and calls again for a new snapshot.
+func relay(ctx context.Context, sub *orderbook.Subscription, state channelState, send func(any) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-sub.C:
+			if !ok {
+				if errors.Is(sub.Err(), orderbook.ErrSubscriberBehind) {
+					return status.Error(codes.ResourceExhausted, sub.Err().Error())
+				}
+				return status.Error(codes.Unavailable, "Market Closed")
+			}
+			update, ok := state.apply(event)
+			if !ok {
+				continue
+			}
+			if err := send(update); err != nil {
+				return err
+			}
+		}
+	}
+}
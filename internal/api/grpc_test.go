@@ -0,0 +1,175 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"orderbook/internal/api/orderbookpb"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCServer(t *testing.T) {
+	ex, _ := exchange.New()
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+
+	// gRPC and HTTP share the server
+	gs := grpc.NewServer()
+	orderbookpb.RegisterOrderBookServer(gs, NewGRPCServer(ex))
+	server := httptest.NewServer(WithGRPC(gs, NewRouter(NewHandler(ex)).SetupRoutes()))
+	defer server.Close()
+	defer gs.Stop()
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+	client := orderbookpb.NewOrderBookClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	placed, err := client.PlaceOrder(ctx, &orderbookpb.PlaceOrderRequest{Symbol: "BTC-USD", Order: &orderbookpb.Order{
+		Id: "mine", Side: orderbookpb.Side_SIDE_SELL, Price: "101", Amount: "2"}})
+	if err != nil {
+		t.Fatalf("Failed to place order: %v", err)
+	}
+	if placed.OrderId == "" || placed.OrderId == "mine" {
+		t.Errorf("Expected a new order ID, got %q", placed.OrderId)
+	}
+	client.ProcessOrder(ctx, &orderbookpb.ProcessOrderRequest{Symbol: "BTC-USD", Order: &orderbookpb.Order{
+		Id: "b1", Side: orderbookpb.Side_SIDE_BUY, Price: "99", Amount: "3"}})
+
+	trades, err := client.StreamTrades(ctx, &orderbookpb.StreamTradesRequest{Symbol: "BTC-USD"})
+	if err != nil {
+		t.Fatalf("Failed to stream trades: %v", err)
+	}
+	if _, err := trades.Header(); err != nil {
+		t.Fatalf("Failed to subscribe to trades: %v", err)
+	}
+	depth, err := client.StreamDepth(ctx, &orderbookpb.StreamDepthRequest{Symbol: "BTC-USD", Depth: 5})
+	if err != nil {
+		t.Fatalf("Failed to stream depth: %v", err)
+	}
+	snapshot, err := depth.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive the depth snapshot: %v", err)
+	}
+	if !snapshot.Snapshot || len(snapshot.Bids) != 1 || snapshot.Bids[0].Amount != "3" || len(snapshot.Asks) != 1 || snapshot.Asks[0].Price != "101" {
+		t.Errorf("Unexpected depth snapshot %v", snapshot)
+	}
+
+	result, err := client.ProcessOrder(ctx, &orderbookpb.ProcessOrderRequest{Symbol: "BTC-USD", Order: &orderbookpb.Order{
+		Id: "b2", Side: orderbookpb.Side_SIDE_BUY, Price: "101", Amount: "1", Account: "alice"}})
+	if err != nil {
+		t.Fatalf("Failed to process order: %v", err)
+	}
+	if result.Status != orderbookpb.OrderStatus_ORDER_STATUS_FILLED || len(result.Trades) != 1 || result.Trades[0].SellOrderId != placed.OrderId {
+		t.Errorf("Expected a fill against the placed order, got %v", result)
+	}
+
+	trade, err := trades.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive a trade: %v", err)
+	}
+	if trade.Id != result.Trades[0].Id || trade.Price != "101" || trade.Amount != "1" || trade.AggressorSide != orderbookpb.Side_SIDE_BUY {
+		t.Errorf("Unexpected trade %v", trade)
+	}
+	update, err := depth.Recv()
+	if err != nil {
+		t.Fatalf("Failed to receive a depth update: %v", err)
+	}
+	expected := orderbook.Checksum(book.GetOrderBookSnapshot().Bids, book.GetOrderBookSnapshot().Asks, 5)
+	if update.Snapshot || len(update.Asks) != 1 || update.Asks[0].Amount != "1" || update.Checksum != expected {
+		t.Errorf("Unexpected depth update %v, expected checksum %d", update, expected)
+	}
+
+	if _, err := client.ModifyOrder(ctx, &orderbookpb.ModifyOrderRequest{Symbol: "BTC-USD", OrderId: "b1", Price: "100", Amount: "4"}); err != nil {
+		t.Fatalf("Failed to modify order: %v", err)
+	}
+	bid, err := client.GetBestBid(ctx, &orderbookpb.GetBestBidRequest{Symbol: "BTC-USD"})
+	if err != nil || bid.Id != "b1" || bid.Price != "100" || bid.Amount != "4" {
+		t.Errorf("Expected the modified bid, got %v, %v", bid, err)
+	}
+	if _, err := client.CancelOrder(ctx, &orderbookpb.CancelOrderRequest{Symbol: "BTC-USD", OrderId: "b1"}); err != nil {
+		t.Fatalf("Failed to cancel order: %v", err)
+	}
+	snap, err := client.GetSnapshot(ctx, &orderbookpb.GetSnapshotRequest{Symbol: "BTC-USD"})
+	if err != nil || len(snap.Bids) != 0 || len(snap.Asks) != 1 || snap.Checksum != book.GetOrderBookSnapshot().Checksum {
+		t.Errorf("Unexpected snapshot %v, %v", snap, err)
+	}
+
+	errorTests := []struct {
+		name     string
+		err      error
+		expected codes.Code
+	}{
+		{"Unknown market", second(client.GetSnapshot(ctx, &orderbookpb.GetSnapshotRequest{Symbol: "ETH-USD"})), codes.NotFound},
+		{"No bids", second(client.GetBestBid(ctx, &orderbookpb.GetBestBidRequest{Symbol: "BTC-USD"})), codes.NotFound},
+		{"Unknown order", second(client.CancelOrder(ctx, &orderbookpb.CancelOrderRequest{Symbol: "BTC-USD", OrderId: "b1"})), codes.NotFound},
+		{"Missing order", second(client.ProcessOrder(ctx, &orderbookpb.ProcessOrderRequest{Symbol: "BTC-USD"})), codes.InvalidArgument},
+		{"Bad price", second(client.ProcessOrder(ctx, &orderbookpb.ProcessOrderRequest{Symbol: "BTC-USD", Order: &orderbookpb.Order{
+			Side: orderbookpb.Side_SIDE_BUY, Price: "abc", Amount: "1"}})), codes.InvalidArgument},
+		{"Limit without price", second(client.ProcessOrder(ctx, &orderbookpb.ProcessOrderRequest{Symbol: "BTC-USD", Order: &orderbookpb.Order{
+			Side: orderbookpb.Side_SIDE_BUY, Amount: "1"}})), codes.InvalidArgument},
+		{"Unknown side", second(client.PlaceOrder(ctx, &orderbookpb.PlaceOrderRequest{Symbol: "BTC-USD", Order: &orderbookpb.Order{
+			Side: 7, Price: "1", Amount: "1"}})), codes.InvalidArgument},
+		{"Post-only would cross", second(client.ProcessOrder(ctx, &orderbookpb.ProcessOrderRequest{Symbol: "BTC-USD", Order: &orderbookpb.Order{
+			Side: orderbookpb.Side_SIDE_BUY, Price: "101", Amount: "1", PostOnly: true}})), codes.FailedPrecondition},
+		{"Depth too large", streamError(client.StreamDepth(ctx, &orderbookpb.StreamDepthRequest{Symbol: "BTC-USD", Depth: 5000})), codes.InvalidArgument},
+	}
+	for _, tt := range errorTests {
+		if code := status.Code(tt.err); code != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.err)
+		}
+	}
+
+	// HTTP requests still reach the router
+	resp, err := http.Get(server.URL + "/markets")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the HTTP API alongside gRPC, got %v, %v", resp, err)
+	}
+	resp.Body.Close()
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
+
+// streamError returns the error a server stream ends with.
+func streamError[T any](stream grpc.ServerStreamingClient[T], err error) error {
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func TestRelay_SubscriberBehind(t *testing.T) {
+	book := orderbook.NewOrderBook("BTC-USD", orderbook.WithSubscriptionBuffer(1))
+	sub, view := book.SubscribeView()
+	defer sub.Close()
+	for i := 0; i < 5; i++ {
+		book.PlaceOrder(orderbook.Order{Side: orderbook.Buy, Price: dec(100.0), Amount: dec(1.0)})
+	}
+
+	err := relay(context.Background(), sub, newChannelState("trades", 0, view), func(any) error { return nil })
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected a stream behind the book to end with ResourceExhausted, got %v", err)
+	}
+}
@@ -2,20 +2,46 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"orderbook/internal/exchange"
 	"orderbook/internal/orderbook"
-	"strconv"
 
 	"github.com/google/uuid"
 )
 
 type Handler struct {
-	book *orderbook.OrderBook
+	exchange *exchange.Exchange
 }
 
-// Create a new book handler for OrderBook
-func NewHandler(book *orderbook.OrderBook) *Handler {
-	return &Handler{book: book}
+// Create a new handler serving every market of an exchange
+func NewHandler(ex *exchange.Exchange) *Handler {
+	return &Handler{exchange: ex}
+}
+
+// Look up the book of the market named by the {symbol} path segment
+func (h *Handler) market(w http.ResponseWriter, r *http.Request) (*orderbook.OrderBook, bool) {
+	book, err := h.exchange.Market(r.PathValue("symbol"))
+	if err != nil {
+		http.Error(w, "Market Not Found", http.StatusNotFound)
+		return nil, false
+	}
+	return book, true
+}
+
+// Journal failures are on our side, operations the trading status of the
+// market does not allow find it locked, any other book error is a bad
+// request. Locked rather than conflict keeps them apart from post-only
+// rejections, which makers simply requote
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, orderbook.ErrJournal):
+		return http.StatusInternalServerError
+	case errors.Is(err, orderbook.ErrBookHalted), errors.Is(err, orderbook.ErrBookClosed), errors.Is(err, orderbook.ErrAuctionOrder),
+		errors.Is(err, orderbook.ErrAuctionStatus), errors.Is(err, orderbook.ErrNoAuction):
+		return http.StatusLocked
+	}
+	return http.StatusBadRequest
 }
 
 // Handler for PlaceOrder function
@@ -25,6 +51,11 @@ func (h *Handler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
 	var order orderbook.Order
 
 	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
@@ -34,8 +65,8 @@ func (h *Handler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
 
   order.ID = uuid.New().String() // Without this uuid become arbitrary from the user and can rewrites ther orders
 
-	if err := h.book.PlaceOrder(order); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := book.PlaceOrder(order); err != nil {
+		writeRiskError(w, err)
 		return
 	}
 
@@ -49,14 +80,19 @@ func (h *Handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
 	orderID := r.URL.Query().Get("id")
 	if orderID == "" {
 		http.Error(w, "Order ID is Required", http.StatusBadRequest)
 		return
 	}
 
-	if err := h.book.CancelOrder(orderID); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := book.CancelOrder(orderID); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
 		return
 	}
 
@@ -70,31 +106,54 @@ func (h *Handler) ModifyOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var price, amount float64
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
 	orderID := r.URL.Query().Get("id")
 	priceString := r.URL.Query().Get("price")
 	amountString := r.URL.Query().Get("amount")
+	stopPriceString := r.URL.Query().Get("stop_price")
 
 	if orderID == "" {
 		http.Error(w, "Order ID is Required", http.StatusBadRequest)
 		return
 	}
 
-	if _, err := strconv.ParseFloat(priceString, 64); err != nil {
+	// Stop market orders have no limit price to modify
+	if stopPriceString != "" && priceString == "" {
+		priceString = "0"
+	}
+
+	price, err := orderbook.ParseDecimal(priceString)
+	if err != nil {
 		http.Error(w, "Price is Not a Number", http.StatusBadRequest)
 		return
 	}
 
-	if _, err := strconv.ParseFloat(amountString, 64); err != nil {
+	amount, err := orderbook.ParseDecimal(amountString)
+	if err != nil {
 		http.Error(w, "Amount is Not a Number", http.StatusBadRequest)
 		return
 	}
 
-	price, _ = strconv.ParseFloat(priceString, 64)
-	amount, _ = strconv.ParseFloat(amountString, 64)
+	if stopPriceString != "" {
+		stopPrice, err := orderbook.ParseDecimal(stopPriceString)
+		if err != nil {
+			http.Error(w, "Stop Price is Not a Number", http.StatusBadRequest)
+			return
+		}
+		if err := book.ModifyStopOrder(orderID, stopPrice, price, amount); err != nil {
+			writeRiskError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
 
-	if err := h.book.ModifyOrder(orderID, price, amount); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := book.ModifyOrder(orderID, price, amount); err != nil {
+		writeRiskError(w, err)
 		return
 	}
 
@@ -108,6 +167,11 @@ func (h *Handler) ProcessOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
 	// Decode the incoming order
 	var order orderbook.Order
 	if err := json.NewDecoder(r.Body).Decode(&order); err != nil {
@@ -115,28 +179,55 @@ func (h *Handler) ProcessOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checkPrices(order); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	// Process the order and get resulting trades
-	trades, err := h.book.ProcessOrder(order)
+	result, err := book.ProcessOrder(order)
+	if errors.Is(err, orderbook.ErrPostOnlyWouldCross) {
+		// Distinct from validation errors so makers can simply requote
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeRiskError(w, err)
 		return
 	}
 
 	// If there are no trades, return an empty array instead of null
-	if trades == nil {
-		trades = []*orderbook.Trade{}
+	if result.Trades == nil {
+		result.Trades = []*orderbook.Trade{}
 	}
 
 	// Set response header
 	w.Header().Set("Content-Type", "application/json")
 
-	// Encode and return the trades
-	if err := json.NewEncoder(w).Encode(trades); err != nil {
+	// Encode and return the execution result
+	if err := json.NewEncoder(w).Encode(result); err != nil {
 		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
 		return
 	}
 }
 
+// Market and stop market orders need no price, stop orders need a stop price
+func checkPrices(order orderbook.Order) error {
+	switch order.Type {
+	case "", orderbook.Limit, orderbook.StopLimit:
+		if order.Price <= 0 {
+			return errors.New("Price is Required for Limit Orders")
+		}
+	case orderbook.Market, orderbook.Stop:
+	default:
+		return errors.New("Invalid Order Type")
+	}
+	if order.IsStop() && order.StopPrice <= 0 {
+		return errors.New("Stop Price is Required for Stop Orders")
+	}
+	return nil
+}
+
 // Handler for GetBestBid function
 func (h *Handler) GetBestBid(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -144,7 +235,12 @@ func (h *Handler) GetBestBid(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bestBid, err := h.book.GetBestBid()
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	bestBid, err := book.GetBestBid()
 
 	if err == orderbook.ErrNoOrders {
 		http.Error(w, "No Orders Present", http.StatusNotFound)
@@ -171,7 +267,12 @@ func (h *Handler) GetBestAsk(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	bestBid, err := h.book.GetBestAsk()
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	bestBid, err := book.GetBestAsk()
 
 	if err == orderbook.ErrNoOrders {
 		http.Error(w, "No Orders Present", http.StatusNotFound)
@@ -198,7 +299,12 @@ func (h *Handler) GetOrderbookSnapshot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	snapshot := h.book.GetOrderBookSnapshot()
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+
+	snapshot := book.GetOrderBookSnapshot()
 
 	w.Header().Set("Content-Type", "application/json")
 
@@ -3,8 +3,10 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"orderbook/internal/exchange"
 	"orderbook/internal/orderbook"
 	"strings"
 	"testing"
@@ -12,8 +14,7 @@ import (
 )
 
 func TestPlaceOrder(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, _ := newTestHandler(t)
 
 	tests := []struct {
 		name         string
@@ -26,8 +27,8 @@ func TestPlaceOrder(t *testing.T) {
 			order: orderbook.Order{
 				ID:     "order1",
 				Side:   orderbook.Buy,
-				Price:  100.0,
-				Amount: 10.0,
+				Price:  dec(100.0),
+				Amount: dec(10.0),
 			},
 			method:       "POST",
 			expectedCode: http.StatusCreated,
@@ -37,8 +38,8 @@ func TestPlaceOrder(t *testing.T) {
 			order: orderbook.Order{
 				ID:     "order2",
 				Side:   orderbook.Buy,
-				Price:  -100.0,
-				Amount: 10.0,
+				Price:  dec(-100.0),
+				Amount: dec(10.0),
 			},
 			method:       "POST",
 			expectedCode: http.StatusBadRequest,
@@ -48,8 +49,8 @@ func TestPlaceOrder(t *testing.T) {
 			order: orderbook.Order{
 				ID:     "order3",
 				Side:   orderbook.Buy,
-				Price:  100.0,
-				Amount: 10.0,
+				Price:  dec(100.0),
+				Amount: dec(10.0),
 			},
 			method:       "GET",
 			expectedCode: http.StatusMethodNotAllowed,
@@ -59,7 +60,7 @@ func TestPlaceOrder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			orderJSON, _ := json.Marshal(tt.order)
-			req := httptest.NewRequest(tt.method, "/place-order", bytes.NewBuffer(orderJSON))
+			req := newTestRequest(tt.method, "/place-order", bytes.NewBuffer(orderJSON))
 			w := httptest.NewRecorder()
 
 			handler.PlaceOrder(w, req)
@@ -72,15 +73,14 @@ func TestPlaceOrder(t *testing.T) {
 }
 
 func TestCancelOrder(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, book := newTestHandler(t)
 
 	// Place an order first
 	order := orderbook.Order{
 		ID:     "order-to-cancel",
 		Side:   orderbook.Buy,
-		Price:  100.0,
-		Amount: 10.0,
+		Price:  dec(100.0),
+		Amount: dec(10.0),
 	}
 	book.PlaceOrder(order)
 
@@ -112,7 +112,7 @@ func TestCancelOrder(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest(tt.method, "/cancel-order?id="+tt.orderID, nil)
+			req := newTestRequest(tt.method, "/cancel-order?id="+tt.orderID, nil)
 			w := httptest.NewRecorder()
 
 			handler.CancelOrder(w, req)
@@ -125,15 +125,14 @@ func TestCancelOrder(t *testing.T) {
 }
 
 func TestModifyOrder(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, book := newTestHandler(t)
 
 	// Place an order first
 	order := orderbook.Order{
 		ID:     "order-to-modify",
 		Side:   orderbook.Buy,
-		Price:  100.0,
-		Amount: 10.0,
+		Price:  dec(100.0),
+		Amount: dec(10.0),
 	}
 	book.PlaceOrder(order)
 
@@ -174,7 +173,7 @@ func TestModifyOrder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			url := "/modify-order?id=" + tt.orderID + "&price=" + tt.price + "&amount=" + tt.amount
-			req := httptest.NewRequest(tt.method, url, nil)
+			req := newTestRequest(tt.method, url, nil)
 			w := httptest.NewRecorder()
 
 			handler.ModifyOrder(w, req)
@@ -187,15 +186,14 @@ func TestModifyOrder(t *testing.T) {
 }
 
 func TestProcessOrder(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, book := newTestHandler(t)
 
 	// Place a sell order first
 	sellOrder := orderbook.Order{
 		ID:     "sell-order",
 		Side:   orderbook.Sell,
-		Price:  100.0,
-		Amount: 10.0,
+		Price:  dec(100.0),
+		Amount: dec(10.0),
 	}
 	book.PlaceOrder(sellOrder)
 
@@ -211,8 +209,8 @@ func TestProcessOrder(t *testing.T) {
 			order: orderbook.Order{
 				ID:     "buy-order",
 				Side:   orderbook.Buy,
-				Price:  100.0,
-				Amount: 5.0,
+				Price:  dec(100.0),
+				Amount: dec(5.0),
 			},
 			method:       "POST",
 			expectedCode: http.StatusOK,
@@ -223,8 +221,8 @@ func TestProcessOrder(t *testing.T) {
 			order: orderbook.Order{
 				ID:     "non-matching-buy",
 				Side:   orderbook.Buy,
-				Price:  90.0,
-				Amount: 5.0,
+				Price:  dec(90.0),
+				Amount: dec(5.0),
 			},
 			method:       "POST",
 			expectedCode: http.StatusOK,
@@ -235,8 +233,8 @@ func TestProcessOrder(t *testing.T) {
 			order: orderbook.Order{
 				ID:     "invalid-order",
 				Side:   orderbook.Buy,
-				Price:  -100.0,
-				Amount: 5.0,
+				Price:  dec(-100.0),
+				Amount: dec(5.0),
 			},
 			method:       "POST",
 			expectedCode: http.StatusBadRequest,
@@ -247,8 +245,8 @@ func TestProcessOrder(t *testing.T) {
 			order: orderbook.Order{
 				ID:     "wrong-method",
 				Side:   orderbook.Buy,
-				Price:  100.0,
-				Amount: 5.0,
+				Price:  dec(100.0),
+				Amount: dec(5.0),
 			},
 			method:       "GET",
 			expectedCode: http.StatusMethodNotAllowed,
@@ -259,7 +257,7 @@ func TestProcessOrder(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			orderJSON, _ := json.Marshal(tt.order)
-			req := httptest.NewRequest(tt.method, "/process-order", bytes.NewBuffer(orderJSON))
+			req := newTestRequest(tt.method, "/process-order", bytes.NewBuffer(orderJSON))
 			w := httptest.NewRecorder()
 
 			handler.ProcessOrder(w, req)
@@ -269,10 +267,11 @@ func TestProcessOrder(t *testing.T) {
 			}
 
 			if tt.checkTrades && w.Code == http.StatusOK {
-				var trades []*orderbook.Trade
-				if err := json.NewDecoder(w.Body).Decode(&trades); err != nil {
+				var result orderbook.ProcessResult
+				if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
 					t.Errorf("Failed to decode response body: %v", err)
 				}
+				trades := result.Trades
 				if len(trades) == 0 {
 					t.Error("Expected trades but got none")
 				}
@@ -291,11 +290,11 @@ func TestProcessOrder(t *testing.T) {
 }
 
 func TestGetBestBid_Success(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
+	handler, ob := newTestHandler(t)
 	expectedOrder := orderbook.Order{
 		ID:     "bid1",
-		Price:  100.0,
-		Amount: 5.0,
+		Price:  dec(100.0),
+		Amount: dec(5.0),
 		Side:   orderbook.Buy,
 	}
 
@@ -303,8 +302,7 @@ func TestGetBestBid_Success(t *testing.T) {
 		t.Fatalf("Failed to place order: %v", err)
 	}
 
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodGet, "/best-bid", nil)
+	req := newTestRequest(http.MethodGet, "/best-bid", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestBid(rr, req)
@@ -334,9 +332,8 @@ func TestGetBestBid_Success(t *testing.T) {
 }
 
 func TestGetBestBid_NoBids(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodGet, "/best-bid", nil)
+	handler, _ := newTestHandler(t)
+	req := newTestRequest(http.MethodGet, "/best-bid", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestBid(rr, req)
@@ -351,9 +348,8 @@ func TestGetBestBid_NoBids(t *testing.T) {
 }
 
 func TestGetBestBid_WrongMethod(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodPost, "/best-bid", nil)
+	handler, _ := newTestHandler(t)
+	req := newTestRequest(http.MethodPost, "/best-bid", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestBid(rr, req)
@@ -368,18 +364,18 @@ func TestGetBestBid_WrongMethod(t *testing.T) {
 }
 
 func TestGetBestBid_MultipleBids(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
+	handler, ob := newTestHandler(t)
 	expectedBestBid := orderbook.Order{
 		ID:     "best",
-		Price:  200.0, // Highest price should be best bid
-		Amount: 3.0,
+		Price:  dec(200.0), // Highest price should be best bid
+		Amount: dec(3.0),
 		Side:   orderbook.Buy,
 	}
 
 	bids := []orderbook.Order{
 		expectedBestBid,
-		{ID: "bid2", Price: 150.0, Amount: 2.0, Side: orderbook.Buy},
-		{ID: "bid3", Price: 100.0, Amount: 5.0, Side: orderbook.Buy},
+		{ID: "bid2", Price: dec(150.0), Amount: dec(2.0), Side: orderbook.Buy},
+		{ID: "bid3", Price: dec(100.0), Amount: dec(5.0), Side: orderbook.Buy},
 	}
 
 	for _, bid := range bids {
@@ -388,8 +384,7 @@ func TestGetBestBid_MultipleBids(t *testing.T) {
 		}
 	}
 
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodGet, "/best-bid", nil)
+	req := newTestRequest(http.MethodGet, "/best-bid", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestBid(rr, req)
@@ -405,11 +400,11 @@ func TestGetBestBid_MultipleBids(t *testing.T) {
 }
 
 func TestGetBestAskHandler_Success(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
+	handler, ob := newTestHandler(t)
 	expectedOrder := orderbook.Order{
 		ID:     "ask1",
-		Price:  100.0,
-		Amount: 5.0,
+		Price:  dec(100.0),
+		Amount: dec(5.0),
 		Side:   orderbook.Sell,
 	}
 
@@ -418,8 +413,7 @@ func TestGetBestAskHandler_Success(t *testing.T) {
 		t.Fatalf("Failed to place order: %v", err)
 	}
 
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodGet, "/best-ask", nil)
+	req := newTestRequest(http.MethodGet, "/best-ask", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestAsk(rr, req)
@@ -449,9 +443,8 @@ func TestGetBestAskHandler_Success(t *testing.T) {
 }
 
 func TestGetBestAskHandler_NoAsks(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodGet, "/best-ask", nil)
+	handler, _ := newTestHandler(t)
+	req := newTestRequest(http.MethodGet, "/best-ask", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestAsk(rr, req)
@@ -466,9 +459,8 @@ func TestGetBestAskHandler_NoAsks(t *testing.T) {
 }
 
 func TestGetBestAskHandler_WrongMethod(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodPost, "/best-ask", nil)
+	handler, _ := newTestHandler(t)
+	req := newTestRequest(http.MethodPost, "/best-ask", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestAsk(rr, req)
@@ -483,19 +475,19 @@ func TestGetBestAskHandler_WrongMethod(t *testing.T) {
 }
 
 func TestGetBestAskHandler_MultipleAsks(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
+	handler, ob := newTestHandler(t)
 	expectedBestAsk := orderbook.Order{
 		ID:     "best",
-		Price:  100.0, // Lowest price should be best ask
-		Amount: 3.0,
+		Price:  dec(100.0), // Lowest price should be best ask
+		Amount: dec(3.0),
 		Side:   orderbook.Sell,
 	}
 
 	// Add asks in ascending order
 	asks := []orderbook.Order{
 		expectedBestAsk,
-		{ID: "ask2", Price: 150.0, Amount: 2.0, Side: orderbook.Sell},
-		{ID: "ask3", Price: 200.0, Amount: 5.0, Side: orderbook.Sell},
+		{ID: "ask2", Price: dec(150.0), Amount: dec(2.0), Side: orderbook.Sell},
+		{ID: "ask3", Price: dec(200.0), Amount: dec(5.0), Side: orderbook.Sell},
 	}
 
 	for _, ask := range asks {
@@ -504,8 +496,7 @@ func TestGetBestAskHandler_MultipleAsks(t *testing.T) {
 		}
 	}
 
-	handler := NewHandler(ob)
-	req := httptest.NewRequest(http.MethodGet, "/best-ask", nil)
+	req := newTestRequest(http.MethodGet, "/best-ask", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetBestAsk(rr, req)
@@ -521,15 +512,14 @@ func TestGetBestAskHandler_MultipleAsks(t *testing.T) {
 }
 
 func TestGetOrderbookSnapshot_Success(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
+	handler, ob := newTestHandler(t)
 
 	// Add some test orders
 	orders := []orderbook.Order{
-		{ID: "ask1", Price: 100.0, Amount: 5.0, Side: orderbook.Sell},
-		{ID: "ask2", Price: 101.0, Amount: 3.0, Side: orderbook.Sell},
-		{ID: "bid1", Price: 99.0, Amount: 4.0, Side: orderbook.Buy},
-		{ID: "bid2", Price: 98.0, Amount: 2.0, Side: orderbook.Buy},
+		{ID: "ask1", Price: dec(100.0), Amount: dec(5.0), Side: orderbook.Sell},
+		{ID: "ask2", Price: dec(101.0), Amount: dec(3.0), Side: orderbook.Sell},
+		{ID: "bid1", Price: dec(99.0), Amount: dec(4.0), Side: orderbook.Buy},
+		{ID: "bid2", Price: dec(98.0), Amount: dec(2.0), Side: orderbook.Buy},
 	}
 
 	for _, order := range orders {
@@ -538,7 +528,7 @@ func TestGetOrderbookSnapshot_Success(t *testing.T) {
 		}
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/orderbook-snapshot", nil)
+	req := newTestRequest(http.MethodGet, "/orderbook-snapshot", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetOrderbookSnapshot(rr, req)
@@ -563,8 +553,8 @@ func TestGetOrderbookSnapshot_Success(t *testing.T) {
 	if len(snapshot.Asks) != 2 {
 		t.Errorf("Expected 2 ask levels, got %d", len(snapshot.Asks))
 	}
-	if snapshot.Asks[0].Price != 100.0 || snapshot.Asks[0].TotalAmount != 5.0 {
-		t.Errorf("First ask level incorrect: got price %.2f amount %.2f, want price 100.00 amount 5.00",
+	if snapshot.Asks[0].Price != dec(100.0) || snapshot.Asks[0].TotalAmount != dec(5.0) {
+		t.Errorf("First ask level incorrect: got price %v amount %v, want price 100.00 amount 5.00",
 			snapshot.Asks[0].Price, snapshot.Asks[0].TotalAmount)
 	}
 
@@ -572,8 +562,8 @@ func TestGetOrderbookSnapshot_Success(t *testing.T) {
 	if len(snapshot.Bids) != 2 {
 		t.Errorf("Expected 2 bid levels, got %d", len(snapshot.Bids))
 	}
-	if snapshot.Bids[0].Price != 99.0 || snapshot.Bids[0].TotalAmount != 4.0 {
-		t.Errorf("First bid level incorrect: got price %.2f amount %.2f, want price 99.00 amount 4.00",
+	if snapshot.Bids[0].Price != dec(99.0) || snapshot.Bids[0].TotalAmount != dec(4.0) {
+		t.Errorf("First bid level incorrect: got price %v amount %v, want price 99.00 amount 4.00",
 			snapshot.Bids[0].Price, snapshot.Bids[0].TotalAmount)
 	}
 
@@ -584,10 +574,9 @@ func TestGetOrderbookSnapshot_Success(t *testing.T) {
 }
 
 func TestGetOrderbookSnapshot_EmptyOrderbook(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
+	handler, _ := newTestHandler(t)
 
-	req := httptest.NewRequest(http.MethodGet, "/orderbook-snapshot", nil)
+	req := newTestRequest(http.MethodGet, "/orderbook-snapshot", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetOrderbookSnapshot(rr, req)
@@ -610,10 +599,9 @@ func TestGetOrderbookSnapshot_EmptyOrderbook(t *testing.T) {
 }
 
 func TestGetOrderbookSnapshot_WrongMethod(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
+	handler, _ := newTestHandler(t)
 
-	req := httptest.NewRequest(http.MethodPost, "/orderbook-snapshot", nil)
+	req := newTestRequest(http.MethodPost, "/orderbook-snapshot", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetOrderbookSnapshot(rr, req)
@@ -624,15 +612,14 @@ func TestGetOrderbookSnapshot_WrongMethod(t *testing.T) {
 }
 
 func TestGetOrderbookSnapshot_MultipleOrdersSamePrice(t *testing.T) {
-	ob := orderbook.NewOrderBook("test")
-	handler := NewHandler(ob)
+	handler, ob := newTestHandler(t)
 
 	// Add multiple orders at the same price level
 	orders := []orderbook.Order{
-		{ID: "ask1", Price: 100.0, Amount: 5.0, Side: orderbook.Sell},
-		{ID: "ask2", Price: 100.0, Amount: 3.0, Side: orderbook.Sell},
-		{ID: "bid1", Price: 99.0, Amount: 4.0, Side: orderbook.Buy},
-		{ID: "bid2", Price: 99.0, Amount: 2.0, Side: orderbook.Buy},
+		{ID: "ask1", Price: dec(100.0), Amount: dec(5.0), Side: orderbook.Sell},
+		{ID: "ask2", Price: dec(100.0), Amount: dec(3.0), Side: orderbook.Sell},
+		{ID: "bid1", Price: dec(99.0), Amount: dec(4.0), Side: orderbook.Buy},
+		{ID: "bid2", Price: dec(99.0), Amount: dec(2.0), Side: orderbook.Buy},
 	}
 
 	for _, order := range orders {
@@ -641,7 +628,7 @@ func TestGetOrderbookSnapshot_MultipleOrdersSamePrice(t *testing.T) {
 		}
 	}
 
-	req := httptest.NewRequest(http.MethodGet, "/orderbook-snapshot", nil)
+	req := newTestRequest(http.MethodGet, "/orderbook-snapshot", nil)
 	rr := httptest.NewRecorder()
 
 	handler.GetOrderbookSnapshot(rr, req)
@@ -655,8 +642,8 @@ func TestGetOrderbookSnapshot_MultipleOrdersSamePrice(t *testing.T) {
 	if len(snapshot.Asks) != 1 {
 		t.Errorf("Expected 1 ask level, got %d", len(snapshot.Asks))
 	}
-	if snapshot.Asks[0].Price != 100.0 || snapshot.Asks[0].TotalAmount != 8.0 {
-		t.Errorf("Ask level incorrect: got price %.2f amount %.2f, want price 100.00 amount 8.00",
+	if snapshot.Asks[0].Price != dec(100.0) || snapshot.Asks[0].TotalAmount != dec(8.0) {
+		t.Errorf("Ask level incorrect: got price %v amount %v, want price 100.00 amount 8.00",
 			snapshot.Asks[0].Price, snapshot.Asks[0].TotalAmount)
 	}
 	if snapshot.Asks[0].OrderCount != 2 {
@@ -667,8 +654,8 @@ func TestGetOrderbookSnapshot_MultipleOrdersSamePrice(t *testing.T) {
 	if len(snapshot.Bids) != 1 {
 		t.Errorf("Expected 1 bid level, got %d", len(snapshot.Bids))
 	}
-	if snapshot.Bids[0].Price != 99.0 || snapshot.Bids[0].TotalAmount != 6.0 {
-		t.Errorf("Bid level incorrect: got price %.2f amount %.2f, want price 99.00 amount 6.00",
+	if snapshot.Bids[0].Price != dec(99.0) || snapshot.Bids[0].TotalAmount != dec(6.0) {
+		t.Errorf("Bid level incorrect: got price %v amount %v, want price 99.00 amount 6.00",
 			snapshot.Bids[0].Price, snapshot.Bids[0].TotalAmount)
 	}
 	if snapshot.Bids[0].OrderCount != 2 {
@@ -677,8 +664,7 @@ func TestGetOrderbookSnapshot_MultipleOrdersSamePrice(t *testing.T) {
 }
 
 func TestPlaceOrder_InvalidJSON(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, _ := newTestHandler(t)
 
 	tests := []struct {
 		name         string
@@ -704,7 +690,7 @@ func TestPlaceOrder_InvalidJSON(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req := httptest.NewRequest("POST", "/place-order", strings.NewReader(tt.body))
+			req := newTestRequest("POST", "/place-order", strings.NewReader(tt.body))
 			w := httptest.NewRecorder()
 			handler.PlaceOrder(w, req)
 			if w.Code != tt.expectedCode {
@@ -715,11 +701,10 @@ func TestPlaceOrder_InvalidJSON(t *testing.T) {
 }
 
 func TestCancelOrder_InvalidIDFormat(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, _ := newTestHandler(t)
 
 	// Test ID with special characters
-	req := httptest.NewRequest("DELETE", "/cancel-order?id=order@123", nil)
+	req := newTestRequest("DELETE", "/cancel-order?id=order@123", nil)
 	w := httptest.NewRecorder()
 	handler.CancelOrder(w, req)
 
@@ -730,15 +715,14 @@ func TestCancelOrder_InvalidIDFormat(t *testing.T) {
 }
 
 func TestProcessOrder_PartialFillAndRemaining(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, book := newTestHandler(t)
 
 	// Place a sell order
 	sellOrder := orderbook.Order{
 		ID:     "sell1",
 		Side:   orderbook.Sell,
-		Price:  100.0,
-		Amount: 5.0,
+		Price:  dec(100.0),
+		Amount: dec(5.0),
 	}
 	book.PlaceOrder(sellOrder)
 
@@ -746,11 +730,11 @@ func TestProcessOrder_PartialFillAndRemaining(t *testing.T) {
 	buyOrder := orderbook.Order{
 		ID:     "buy1",
 		Side:   orderbook.Buy,
-		Price:  100.0,
-		Amount: 8.0,
+		Price:  dec(100.0),
+		Amount: dec(8.0),
 	}
 	orderJSON, _ := json.Marshal(buyOrder)
-	req := httptest.NewRequest("POST", "/process-order", bytes.NewBuffer(orderJSON))
+	req := newTestRequest("POST", "/process-order", bytes.NewBuffer(orderJSON))
 	w := httptest.NewRecorder()
 	handler.ProcessOrder(w, req)
 
@@ -759,52 +743,175 @@ func TestProcessOrder_PartialFillAndRemaining(t *testing.T) {
 	}
 
 	// Verify trade and remaining order
-	var trades []*orderbook.Trade
-	json.NewDecoder(w.Body).Decode(&trades)
-	if len(trades) != 1 || trades[0].Amount != 5.0 {
-		t.Errorf("Expected 1 trade for 5.0, got %v", trades)
+	var result orderbook.ProcessResult
+	json.NewDecoder(w.Body).Decode(&result)
+	if len(result.Trades) != 1 || result.Trades[0].Amount != dec(5.0) {
+		t.Errorf("Expected 1 trade for 5.0, got %v", result.Trades)
+	}
+	if trade := result.Trades[0]; trade.ID == "" || trade.Seq != 1 || trade.Symbol != "TEST" ||
+		trade.TakerOrderID != "buy1" || trade.AggressorSide != orderbook.Buy || trade.Timestamp.IsZero() {
+		t.Errorf("Expected the trade identity to be returned, got %+v", trade)
+	}
+	if result.Status != orderbook.StatusPartiallyFilled || result.RestingAmount != dec(3.0) {
+		t.Errorf("Expected partially filled with 3.0 resting, got %s with %v", result.Status, result.RestingAmount)
 	}
 
 	// Check remaining buy order in bids
 	snapshot := book.GetOrderBookSnapshot()
-	if len(snapshot.Bids) != 1 || snapshot.Bids[0].TotalAmount != 3.0 {
+	if len(snapshot.Bids) != 1 || snapshot.Bids[0].TotalAmount != dec(3.0) {
 		t.Errorf("Expected remaining buy amount 3.0, got %v", snapshot.Bids)
 	}
 }
 
+func TestProcessOrder_Market(t *testing.T) {
+	handler, book := newTestHandler(t)
+	book.PlaceOrder(orderbook.Order{ID: "sell1", Side: orderbook.Sell, Price: dec(100.0), Amount: dec(2.0)})
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{"Market Buy", `{"type": "MARKET", "side": "BUY", "amount": "3"}`, http.StatusOK},
+		{"Limit Without Price", `{"type": "LIMIT", "side": "BUY", "amount": "3"}`, http.StatusBadRequest},
+		{"Unknown Type", `{"type": "TRAILING_STOP", "side": "BUY", "price": "100", "amount": "3"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := newTestRequest("POST", "/process-order", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			handler.ProcessOrder(w, req)
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var result orderbook.ProcessResult
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if result.Status != orderbook.StatusCancelled || result.FilledAmount != dec(2.0) || result.CancelledAmount != dec(1.0) {
+				t.Errorf("Expected 2 filled and 1 cancelled, got %+v", result)
+			}
+		})
+	}
+}
+
+func TestProcessOrder_TimeInForce(t *testing.T) {
+	handler, book := newTestHandler(t)
+	book.PlaceOrder(orderbook.Order{ID: "sell1", Side: orderbook.Sell, Price: dec(100.0), Amount: dec(1.0)})
+
+	body := `{"side": "BUY", "price": "100", "amount": "2", "time_in_force": "IOC"}`
+	req := newTestRequest("POST", "/process-order", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ProcessOrder(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 OK, got %d", w.Code)
+	}
+
+	var result orderbook.ProcessResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	if result.TimeInForce != orderbook.IOC || result.CancelledAmount != dec(1.0) {
+		t.Errorf("Expected IOC with 1.0 cancelled, got %+v", result)
+	}
+	if snapshot := book.GetOrderBookSnapshot(); len(snapshot.Bids) != 0 {
+		t.Errorf("IOC remainder must not rest, got %v", snapshot.Bids)
+	}
+
+	req = newTestRequest("POST", "/process-order", strings.NewReader(`{"side": "BUY", "price": "100", "amount": "2", "time_in_force": "DAY"}`))
+	w = httptest.NewRecorder()
+	handler.ProcessOrder(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for unknown time in force, got %d", w.Code)
+	}
+}
+
+func TestProcessOrder_PostOnlyRejected(t *testing.T) {
+	handler, book := newTestHandler(t)
+	book.PlaceOrder(orderbook.Order{ID: "sell1", Side: orderbook.Sell, Price: dec(100.0), Amount: dec(1.0)})
+
+	body := `{"side": "BUY", "price": "100", "amount": "1", "post_only": true}`
+	req := newTestRequest("POST", "/process-order", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ProcessOrder(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 Conflict for crossing post-only order, got %d", w.Code)
+	}
+	if _, err := book.GetBestBid(); err != orderbook.ErrNoOrders {
+		t.Error("Rejected post-only order must not rest")
+	}
+}
+
+func TestProcessOrder_StopOrder(t *testing.T) {
+	handler, _ := newTestHandler(t)
+
+	body := `{"type": "STOP", "side": "SELL", "stop_price": "95", "amount": "1"}`
+	req := newTestRequest("POST", "/process-order", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ProcessOrder(w, req)
+
+	var result orderbook.ProcessResult
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode response body: %v", err)
+	}
+	if w.Code != http.StatusOK || result.Status != orderbook.StatusUntriggered {
+		t.Fatalf("Expected untriggered stop, got %d %+v", w.Code, result)
+	}
+
+	// Move the stop price of the waiting stop market order
+	req = newTestRequest("PATCH", "/modify-order?id="+result.OrderID+"&stop_price=90&amount=2", nil)
+	w = httptest.NewRecorder()
+	handler.ModifyOrder(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 modifying the stop, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = newTestRequest("POST", "/process-order", strings.NewReader(`{"type": "STOP", "side": "SELL", "amount": "1"}`))
+	w = httptest.NewRecorder()
+	handler.ProcessOrder(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 without stop price, got %d", w.Code)
+	}
+}
+
 func TestGetBestAsk_AfterModify(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, book := newTestHandler(t)
 
 	// Place two asks
-	book.PlaceOrder(orderbook.Order{ID: "ask1", Side: orderbook.Sell, Price: 105.0, Amount: 2.0})
-	book.PlaceOrder(orderbook.Order{ID: "ask2", Side: orderbook.Sell, Price: 100.0, Amount: 3.0})
+	book.PlaceOrder(orderbook.Order{ID: "ask1", Side: orderbook.Sell, Price: dec(105.0), Amount: dec(2.0)})
+	book.PlaceOrder(orderbook.Order{ID: "ask2", Side: orderbook.Sell, Price: dec(100.0), Amount: dec(3.0)})
 
 	// Modify ask2 to have lower price
-	book.ModifyOrder("ask2", 95.0, 3.0)
+	book.ModifyOrder("ask2", dec(95.0), dec(3.0))
 
-	req := httptest.NewRequest("GET", "/best-ask", nil)
+	req := newTestRequest("GET", "/best-ask", nil)
 	w := httptest.NewRecorder()
 	handler.GetBestAsk(w, req)
 
 	var bestAsk orderbook.Order
 	json.NewDecoder(w.Body).Decode(&bestAsk)
-	if bestAsk.Price != 95.0 {
-		t.Errorf("Expected best ask 95.0, got %.2f", bestAsk.Price)
+	if bestAsk.Price != dec(95.0) {
+		t.Errorf("Expected best ask 95.0, got %v", bestAsk.Price)
 	}
 }
 
 func TestGetOrderbookSnapshot_AfterMultipleOperations(t *testing.T) {
-	book := orderbook.NewOrderBook("TEST")
-	handler := NewHandler(book)
+	handler, book := newTestHandler(t)
 
 	// Add and modify orders
-	book.PlaceOrder(orderbook.Order{ID: "bid1", Side: orderbook.Buy, Price: 99.0, Amount: 5.0})
-	book.PlaceOrder(orderbook.Order{ID: "bid2", Side: orderbook.Buy, Price: 100.0, Amount: 3.0})
+	book.PlaceOrder(orderbook.Order{ID: "bid1", Side: orderbook.Buy, Price: dec(99.0), Amount: dec(5.0)})
+	book.PlaceOrder(orderbook.Order{ID: "bid2", Side: orderbook.Buy, Price: dec(100.0), Amount: dec(3.0)})
 	book.CancelOrder("bid1")
-	book.ModifyOrder("bid2", 101.0, 4.0)
+	book.ModifyOrder("bid2", dec(101.0), dec(4.0))
 
-	req := httptest.NewRequest("GET", "/orderbook-snapshot", nil)
+	req := newTestRequest("GET", "/orderbook-snapshot", nil)
 	w := httptest.NewRecorder()
 	handler.GetOrderbookSnapshot(w, req)
 
@@ -812,7 +919,29 @@ func TestGetOrderbookSnapshot_AfterMultipleOperations(t *testing.T) {
 	json.NewDecoder(w.Body).Decode(&snapshot)
 
 	// Verify bids
-	if len(snapshot.Bids) != 1 || snapshot.Bids[0].Price != 101.0 || snapshot.Bids[0].TotalAmount != 4.0 {
+	if len(snapshot.Bids) != 1 || snapshot.Bids[0].Price != dec(101.0) || snapshot.Bids[0].TotalAmount != dec(4.0) {
 		t.Errorf("Snapshot bids incorrect: %v", snapshot.Bids)
 	}
 }
+
+// dec converts a float literal to a Decimal for readable test tables.
+// newTestHandler serves a single TEST market
+func newTestHandler(t *testing.T) (*Handler, *orderbook.OrderBook) {
+	ex, _ := exchange.New()
+	book, err := ex.CreateMarket("TEST", orderbook.DefaultInstrumentSpec())
+	if err != nil {
+		t.Fatalf("Failed to create market: %v", err)
+	}
+	return NewHandler(ex), book
+}
+
+// newTestRequest builds a request addressed to the TEST market
+func newTestRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.SetPathValue("symbol", "TEST")
+	return req
+}
+
+func dec(f float64) orderbook.Decimal {
+	return orderbook.DecimalFromFloat(f)
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+// Status code for an exchange error
+func marketErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, exchange.ErrMarketNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, exchange.ErrMarketExists), errors.Is(err, exchange.ErrMarketActive):
+		return http.StatusConflict
+	case errors.Is(err, exchange.ErrInvalidSymbol), errors.Is(err, orderbook.ErrInvalidInstrument),
+		errors.Is(err, orderbook.ErrInvalidMatching), errors.Is(err, risk.ErrInvalidLimits):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// Handler for ListMarkets function
+func (h *Handler) ListMarkets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(h.exchange.Markets()); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Handler for CreateMarket function
+func (h *Handler) CreateMarket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Omitted instrument fields keep their defaults
+	var request struct {
+		Symbol     string                   `json:"symbol"`
+		Instrument orderbook.InstrumentSpec `json:"instrument"`
+	}
+	request.Instrument = orderbook.DefaultInstrumentSpec()
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid Request Body", http.StatusBadRequest)
+		return
+	}
+
+	book, err := h.exchange.CreateMarket(request.Symbol, request.Instrument)
+	if err != nil {
+		http.Error(w, err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(exchange.MarketInfo{Symbol: book.Tag, ID: book.ID, Instrument: book.Instrument()}); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Handler for DeleteMarket function
+func (h *Handler) DeleteMarket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.exchange.DeleteMarket(r.PathValue("symbol")); err != nil {
+		http.Error(w, err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handler for HaltMarket function
+func (h *Handler) HaltMarket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.exchange.HaltMarket(r.PathValue("symbol")); err != nil {
+		http.Error(w, err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handler for CloseMarket function
+func (h *Handler) CloseMarket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.exchange.CloseMarket(r.PathValue("symbol")); err != nil {
+		http.Error(w, err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Handler for ResumeMarket function
+func (h *Handler) ResumeMarket(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := h.exchange.ResumeMarket(r.PathValue("symbol")); err != nil {
+		http.Error(w, err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"strings"
+	"testing"
+)
+
+func TestMarketRoutes(t *testing.T) {
+	ex, _ := exchange.New()
+	mux := NewRouter(NewHandler(ex)).SetupRoutes()
+
+	tests := []struct {
+		name         string
+		method       string
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{"Create Market", "POST", "/markets", `{"symbol": "BTC-USD"}`, http.StatusCreated},
+		{"Create Duplicate", "POST", "/markets", `{"symbol": "BTC-USD"}`, http.StatusConflict},
+		{"Create Invalid Symbol", "POST", "/markets", `{"symbol": "a/b"}`, http.StatusBadRequest},
+		{"Create Second Market", "POST", "/markets", `{"symbol": "ETH-USD", "instrument": {"price_precision": 2, "tick_size": "0.05", "min_quantity": "0.1"}}`, http.StatusCreated},
+		{"Create Invalid Instrument", "POST", "/markets", `{"symbol": "SOL-USD", "instrument": {"price_precision": 1, "tick_size": "0.05"}}`, http.StatusBadRequest},
+		{"Create Pro-Rata Market", "POST", "/markets", `{"symbol": "ES", "instrument": {"lot_size": "1", "matching": {"algorithm": "PRO_RATA", "min_allocation": "2"}}}`, http.StatusCreated},
+		{"Create Invalid Matching", "POST", "/markets", `{"symbol": "NQ", "instrument": {"matching": {"algorithm": "LIFO"}}}`, http.StatusBadRequest},
+		{"Pro-Rata Order", "POST", "/markets/ES/orders/place", `{"side": "BUY", "price": "100", "amount": "3"}`, http.StatusCreated},
+		{"Close Pro-Rata Market", "POST", "/markets/ES/close", "", http.StatusOK},
+		{"Delete Closed Market", "DELETE", "/markets/ES", "", http.StatusOK},
+		{"Order Off Tick", "POST", "/markets/ETH-USD/orders/place", `{"side": "BUY", "price": "100.01", "amount": "1"}`, http.StatusBadRequest},
+		{"Order Below Min Quantity", "POST", "/markets/ETH-USD/orders/place", `{"side": "BUY", "price": "100.05", "amount": "0.05"}`, http.StatusBadRequest},
+		{"Place Order", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "100", "amount": "1"}`, http.StatusCreated},
+		{"Unknown Market", "POST", "/markets/DOGE-USD/orders/place", `{"side": "BUY", "price": "100", "amount": "1"}`, http.StatusNotFound},
+		{"Delete Open Market", "DELETE", "/markets/ETH-USD", "", http.StatusConflict},
+		{"Halt Market", "POST", "/markets/ETH-USD/halt", "", http.StatusOK},
+		{"Trade Halted Market", "POST", "/markets/ETH-USD/orders/process", `{"side": "BUY", "price": "100", "amount": "1"}`, http.StatusLocked},
+		{"Query Halted Market", "GET", "/markets/ETH-USD/orderbook/snapshot", "", http.StatusOK},
+		{"Resume Market", "POST", "/markets/ETH-USD/resume", "", http.StatusOK},
+		{"Trade Resumed Market", "POST", "/markets/ETH-USD/orders/process", `{"side": "BUY", "price": "100", "amount": "1"}`, http.StatusOK},
+		{"Halt Again", "POST", "/markets/ETH-USD/halt", "", http.StatusOK},
+		{"Delete Halted Market", "DELETE", "/markets/ETH-USD", "", http.StatusOK},
+		{"Delete Unknown Market", "DELETE", "/markets/ETH-USD", "", http.StatusNotFound},
+		{"Set Fees", "PUT", "/markets/BTC-USD/fees", `{"currency": "USD", "default": {"maker_bps": -1, "taker_bps": 5}}`, http.StatusOK},
+		{"Set Invalid Fees", "PUT", "/markets/BTC-USD/fees", `{"default": {"taker_bps": -5}}`, http.StatusBadRequest},
+		{"Get Fees", "GET", "/markets/BTC-USD/fees", "", http.StatusOK},
+		{"Fees Wrong Method", "POST", "/markets/BTC-USD/fees", "", http.StatusMethodNotAllowed},
+		{"Charged Trade", "POST", "/markets/BTC-USD/orders/process", `{"side": "SELL", "price": "100", "amount": "1", "account": "alice"}`, http.StatusOK},
+		{"Account Fees", "GET", "/markets/BTC-USD/fees/accounts", "", http.StatusOK},
+		{"Market Status", "GET", "/markets/BTC-USD/status", "", http.StatusOK},
+		{"Close Market", "POST", "/markets/BTC-USD/close", "", http.StatusOK},
+		{"Trade Closed Market", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "100", "amount": "1"}`, http.StatusLocked},
+		{"Reopen Market", "POST", "/markets/BTC-USD/resume", "", http.StatusOK},
+		{"Set Price Protection", "PUT", "/markets/BTC-USD/protection", `{"band_bps": 500, "breaker_bps": 1000, "breaker_window": "1m", "cooldown": "30s"}`, http.StatusOK},
+		{"Set Invalid Price Protection", "PUT", "/markets/BTC-USD/protection", `{"band_bps": -1}`, http.StatusBadRequest},
+		{"Set Unparsable Price Protection", "PUT", "/markets/BTC-USD/protection", `{"cooldown": "soon"}`, http.StatusBadRequest},
+		{"Get Price Protection", "GET", "/markets/BTC-USD/protection", "", http.StatusOK},
+		{"Status Wrong Method", "POST", "/markets/BTC-USD/status", "", http.StatusMethodNotAllowed},
+		{"Indicative Without Auction", "GET", "/markets/BTC-USD/auction", "", http.StatusLocked},
+		{"Open Auction On Open Market", "POST", "/markets/BTC-USD/auction", `{"type": "OPENING"}`, http.StatusLocked},
+		{"Auction Invalid Duration", "POST", "/markets/BTC-USD/auction", `{"type": "CLOSING", "duration": "soon"}`, http.StatusBadRequest},
+		{"Auction Unknown Type", "POST", "/markets/BTC-USD/auction", `{"type": "LUNCH"}`, http.StatusBadRequest},
+		{"Start Closing Auction", "POST", "/markets/BTC-USD/auction", `{"type": "CLOSING", "duration": "5m"}`, http.StatusOK},
+		{"Auction Order", "POST", "/markets/BTC-USD/orders/place", `{"side": "SELL", "price": "99", "amount": "1"}`, http.StatusCreated},
+		{"Auction Market Order", "POST", "/markets/BTC-USD/orders/process", `{"side": "SELL", "type": "MARKET", "amount": "1"}`, http.StatusLocked},
+		{"Indicative Price", "GET", "/markets/BTC-USD/auction", "", http.StatusOK},
+		{"Uncross", "POST", "/markets/BTC-USD/auction/uncross", "", http.StatusOK},
+		{"Uncross Without Auction", "POST", "/markets/BTC-USD/auction/uncross", "", http.StatusLocked},
+		{"Open After Close", "POST", "/markets/BTC-USD/resume", "", http.StatusOK},
+		{"Halt Wrong Method", "GET", "/markets/BTC-USD/halt", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, w.Code, w.Body)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/markets", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var markets []exchange.MarketInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &markets); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(markets) != 1 || markets[0].Symbol != "BTC-USD" || markets[0].Halted {
+		t.Errorf("Expected only BTC-USD open, got %+v", markets)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/markets/BTC-USD/fees/accounts", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var fees map[string]orderbook.AccountFees
+	if err := json.Unmarshal(w.Body.Bytes(), &fees); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if fees["alice"].TakerFees != dec(0.05) {
+		t.Errorf("Expected alice to have paid 0.05, got %+v", fees)
+	}
+}
@@ -0,0 +1,5 @@
+// Package orderbookpb holds the protobuf messages and gRPC service generated
+// from orderbook.proto.
+package orderbookpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative orderbook.proto
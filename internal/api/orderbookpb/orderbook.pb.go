@@ -0,0 +1,1907 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: orderbook.proto
+
+package orderbookpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Side int32
+
+const (
+	Side_SIDE_UNSPECIFIED Side = 0
+	Side_SIDE_BUY         Side = 1
+	Side_SIDE_SELL        Side = 2
+)
+
+// Enum value maps for Side.
+var (
+	Side_name = map[int32]string{
+		0: "SIDE_UNSPECIFIED",
+		1: "SIDE_BUY",
+		2: "SIDE_SELL",
+	}
+	Side_value = map[string]int32{
+		"SIDE_UNSPECIFIED": 0,
+		"SIDE_BUY":         1,
+		"SIDE_SELL":        2,
+	}
+)
+
+func (x Side) Enum() *Side {
+	p := new(Side)
+	*p = x
+	return p
+}
+
+func (x Side) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Side) Descriptor() protoreflect.EnumDescriptor {
+	return file_orderbook_proto_enumTypes[0].Descriptor()
+}
+
+func (Side) Type() protoreflect.EnumType {
+	return &file_orderbook_proto_enumTypes[0]
+}
+
+func (x Side) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Side.Descriptor instead.
+func (Side) EnumDescriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{0}
+}
+
+type OrderType int32
+
+const (
+	OrderType_ORDER_TYPE_UNSPECIFIED OrderType = 0 // Limit
+	OrderType_ORDER_TYPE_LIMIT       OrderType = 1
+	OrderType_ORDER_TYPE_MARKET      OrderType = 2
+	OrderType_ORDER_TYPE_STOP        OrderType = 3
+	OrderType_ORDER_TYPE_STOP_LIMIT  OrderType = 4
+)
+
+// Enum value maps for OrderType.
+var (
+	OrderType_name = map[int32]string{
+		0: "ORDER_TYPE_UNSPECIFIED",
+		1: "ORDER_TYPE_LIMIT",
+		2: "ORDER_TYPE_MARKET",
+		3: "ORDER_TYPE_STOP",
+		4: "ORDER_TYPE_STOP_LIMIT",
+	}
+	OrderType_value = map[string]int32{
+		"ORDER_TYPE_UNSPECIFIED": 0,
+		"ORDER_TYPE_LIMIT":       1,
+		"ORDER_TYPE_MARKET":      2,
+		"ORDER_TYPE_STOP":        3,
+		"ORDER_TYPE_STOP_LIMIT":  4,
+	}
+)
+
+func (x OrderType) Enum() *OrderType {
+	p := new(OrderType)
+	*p = x
+	return p
+}
+
+func (x OrderType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderType) Descriptor() protoreflect.EnumDescriptor {
+	return file_orderbook_proto_enumTypes[1].Descriptor()
+}
+
+func (OrderType) Type() protoreflect.EnumType {
+	return &file_orderbook_proto_enumTypes[1]
+}
+
+func (x OrderType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderType.Descriptor instead.
+func (OrderType) EnumDescriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{1}
+}
+
+type TimeInForce int32
+
+const (
+	TimeInForce_TIME_IN_FORCE_UNSPECIFIED TimeInForce = 0 // GTC
+	TimeInForce_TIME_IN_FORCE_GTC         TimeInForce = 1
+	TimeInForce_TIME_IN_FORCE_IOC         TimeInForce = 2
+	TimeInForce_TIME_IN_FORCE_FOK         TimeInForce = 3
+	TimeInForce_TIME_IN_FORCE_GTD         TimeInForce = 4
+)
+
+// Enum value maps for TimeInForce.
+var (
+	TimeInForce_name = map[int32]string{
+		0: "TIME_IN_FORCE_UNSPECIFIED",
+		1: "TIME_IN_FORCE_GTC",
+		2: "TIME_IN_FORCE_IOC",
+		3: "TIME_IN_FORCE_FOK",
+		4: "TIME_IN_FORCE_GTD",
+	}
+	TimeInForce_value = map[string]int32{
+		"TIME_IN_FORCE_UNSPECIFIED": 0,
+		"TIME_IN_FORCE_GTC":         1,
+		"TIME_IN_FORCE_IOC":         2,
+		"TIME_IN_FORCE_FOK":         3,
+		"TIME_IN_FORCE_GTD":         4,
+	}
+)
+
+func (x TimeInForce) Enum() *TimeInForce {
+	p := new(TimeInForce)
+	*p = x
+	return p
+}
+
+func (x TimeInForce) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TimeInForce) Descriptor() protoreflect.EnumDescriptor {
+	return file_orderbook_proto_enumTypes[2].Descriptor()
+}
+
+func (TimeInForce) Type() protoreflect.EnumType {
+	return &file_orderbook_proto_enumTypes[2]
+}
+
+func (x TimeInForce) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TimeInForce.Descriptor instead.
+func (TimeInForce) EnumDescriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{2}
+}
+
+type SelfTradePrevention int32
+
+const (
+	SelfTradePrevention_SELF_TRADE_PREVENTION_UNSPECIFIED          SelfTradePrevention = 0 // The mode of the book
+	SelfTradePrevention_SELF_TRADE_PREVENTION_CANCEL_NEWEST        SelfTradePrevention = 1
+	SelfTradePrevention_SELF_TRADE_PREVENTION_CANCEL_OLDEST        SelfTradePrevention = 2
+	SelfTradePrevention_SELF_TRADE_PREVENTION_CANCEL_BOTH          SelfTradePrevention = 3
+	SelfTradePrevention_SELF_TRADE_PREVENTION_DECREMENT_AND_CANCEL SelfTradePrevention = 4
+)
+
+// Enum value maps for SelfTradePrevention.
+var (
+	SelfTradePrevention_name = map[int32]string{
+		0: "SELF_TRADE_PREVENTION_UNSPECIFIED",
+		1: "SELF_TRADE_PREVENTION_CANCEL_NEWEST",
+		2: "SELF_TRADE_PREVENTION_CANCEL_OLDEST",
+		3: "SELF_TRADE_PREVENTION_CANCEL_BOTH",
+		4: "SELF_TRADE_PREVENTION_DECREMENT_AND_CANCEL",
+	}
+	SelfTradePrevention_value = map[string]int32{
+		"SELF_TRADE_PREVENTION_UNSPECIFIED":          0,
+		"SELF_TRADE_PREVENTION_CANCEL_NEWEST":        1,
+		"SELF_TRADE_PREVENTION_CANCEL_OLDEST":        2,
+		"SELF_TRADE_PREVENTION_CANCEL_BOTH":          3,
+		"SELF_TRADE_PREVENTION_DECREMENT_AND_CANCEL": 4,
+	}
+)
+
+func (x SelfTradePrevention) Enum() *SelfTradePrevention {
+	p := new(SelfTradePrevention)
+	*p = x
+	return p
+}
+
+func (x SelfTradePrevention) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SelfTradePrevention) Descriptor() protoreflect.EnumDescriptor {
+	return file_orderbook_proto_enumTypes[3].Descriptor()
+}
+
+func (SelfTradePrevention) Type() protoreflect.EnumType {
+	return &file_orderbook_proto_enumTypes[3]
+}
+
+func (x SelfTradePrevention) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SelfTradePrevention.Descriptor instead.
+func (SelfTradePrevention) EnumDescriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{3}
+}
+
+type OrderStatus int32
+
+const (
+	OrderStatus_ORDER_STATUS_UNSPECIFIED      OrderStatus = 0
+	OrderStatus_ORDER_STATUS_NEW              OrderStatus = 1
+	OrderStatus_ORDER_STATUS_PARTIALLY_FILLED OrderStatus = 2
+	OrderStatus_ORDER_STATUS_FILLED           OrderStatus = 3
+	OrderStatus_ORDER_STATUS_CANCELLED        OrderStatus = 4
+	OrderStatus_ORDER_STATUS_EXPIRED          OrderStatus = 5
+	OrderStatus_ORDER_STATUS_UNTRIGGERED      OrderStatus = 6
+	OrderStatus_ORDER_STATUS_REJECTED         OrderStatus = 7
+)
+
+// Enum value maps for OrderStatus.
+var (
+	OrderStatus_name = map[int32]string{
+		0: "ORDER_STATUS_UNSPECIFIED",
+		1: "ORDER_STATUS_NEW",
+		2: "ORDER_STATUS_PARTIALLY_FILLED",
+		3: "ORDER_STATUS_FILLED",
+		4: "ORDER_STATUS_CANCELLED",
+		5: "ORDER_STATUS_EXPIRED",
+		6: "ORDER_STATUS_UNTRIGGERED",
+		7: "ORDER_STATUS_REJECTED",
+	}
+	OrderStatus_value = map[string]int32{
+		"ORDER_STATUS_UNSPECIFIED":      0,
+		"ORDER_STATUS_NEW":              1,
+		"ORDER_STATUS_PARTIALLY_FILLED": 2,
+		"ORDER_STATUS_FILLED":           3,
+		"ORDER_STATUS_CANCELLED":        4,
+		"ORDER_STATUS_EXPIRED":          5,
+		"ORDER_STATUS_UNTRIGGERED":      6,
+		"ORDER_STATUS_REJECTED":         7,
+	}
+)
+
+func (x OrderStatus) Enum() *OrderStatus {
+	p := new(OrderStatus)
+	*p = x
+	return p
+}
+
+func (x OrderStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_orderbook_proto_enumTypes[4].Descriptor()
+}
+
+func (OrderStatus) Type() protoreflect.EnumType {
+	return &file_orderbook_proto_enumTypes[4]
+}
+
+func (x OrderStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderStatus.Descriptor instead.
+func (OrderStatus) EnumDescriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{4}
+}
+
+type Order struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Id                  string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // Assigned by the server on PlaceOrder
+	Type                OrderType              `protobuf:"varint,2,opt,name=type,proto3,enum=orderbook.v1.OrderType" json:"type,omitempty"`
+	Side                Side                   `protobuf:"varint,3,opt,name=side,proto3,enum=orderbook.v1.Side" json:"side,omitempty"`
+	Price               string                 `protobuf:"bytes,4,opt,name=price,proto3" json:"price,omitempty"`
+	Amount              string                 `protobuf:"bytes,5,opt,name=amount,proto3" json:"amount,omitempty"`
+	Account             string                 `protobuf:"bytes,6,opt,name=account,proto3" json:"account,omitempty"`
+	SelfTradePrevention SelfTradePrevention    `protobuf:"varint,7,opt,name=self_trade_prevention,json=selfTradePrevention,proto3,enum=orderbook.v1.SelfTradePrevention" json:"self_trade_prevention,omitempty"`
+	StopPrice           string                 `protobuf:"bytes,8,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`
+	TimeInForce         TimeInForce            `protobuf:"varint,9,opt,name=time_in_force,json=timeInForce,proto3,enum=orderbook.v1.TimeInForce" json:"time_in_force,omitempty"`
+	ExpireAt            *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=expire_at,json=expireAt,proto3" json:"expire_at,omitempty"` // Required for GTD
+	PostOnly            bool                   `protobuf:"varint,11,opt,name=post_only,json=postOnly,proto3" json:"post_only,omitempty"`
+	PostOnlySlide       bool                   `protobuf:"varint,12,opt,name=post_only_slide,json=postOnlySlide,proto3" json:"post_only_slide,omitempty"`
+	PeakAmount          string                 `protobuf:"bytes,13,opt,name=peak_amount,json=peakAmount,proto3" json:"peak_amount,omitempty"`
+	MaxSlippageBps      int64                  `protobuf:"varint,14,opt,name=max_slippage_bps,json=maxSlippageBps,proto3" json:"max_slippage_bps,omitempty"`
+	MaxNotional         string                 `protobuf:"bytes,15,opt,name=max_notional,json=maxNotional,proto3" json:"max_notional,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_orderbook_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Order) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order) GetType() OrderType {
+	if x != nil {
+		return x.Type
+	}
+	return OrderType_ORDER_TYPE_UNSPECIFIED
+}
+
+func (x *Order) GetSide() Side {
+	if x != nil {
+		return x.Side
+	}
+	return Side_SIDE_UNSPECIFIED
+}
+
+func (x *Order) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Order) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Order) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *Order) GetSelfTradePrevention() SelfTradePrevention {
+	if x != nil {
+		return x.SelfTradePrevention
+	}
+	return SelfTradePrevention_SELF_TRADE_PREVENTION_UNSPECIFIED
+}
+
+func (x *Order) GetStopPrice() string {
+	if x != nil {
+		return x.StopPrice
+	}
+	return ""
+}
+
+func (x *Order) GetTimeInForce() TimeInForce {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return TimeInForce_TIME_IN_FORCE_UNSPECIFIED
+}
+
+func (x *Order) GetExpireAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpireAt
+	}
+	return nil
+}
+
+func (x *Order) GetPostOnly() bool {
+	if x != nil {
+		return x.PostOnly
+	}
+	return false
+}
+
+func (x *Order) GetPostOnlySlide() bool {
+	if x != nil {
+		return x.PostOnlySlide
+	}
+	return false
+}
+
+func (x *Order) GetPeakAmount() string {
+	if x != nil {
+		return x.PeakAmount
+	}
+	return ""
+}
+
+func (x *Order) GetMaxSlippageBps() int64 {
+	if x != nil {
+		return x.MaxSlippageBps
+	}
+	return 0
+}
+
+func (x *Order) GetMaxNotional() string {
+	if x != nil {
+		return x.MaxNotional
+	}
+	return ""
+}
+
+type Trade struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Seq           uint64                 `protobuf:"varint,2,opt,name=seq,proto3" json:"seq,omitempty"`
+	Symbol        string                 `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	BuyOrderId    string                 `protobuf:"bytes,5,opt,name=buy_order_id,json=buyOrderId,proto3" json:"buy_order_id,omitempty"`
+	SellOrderId   string                 `protobuf:"bytes,6,opt,name=sell_order_id,json=sellOrderId,proto3" json:"sell_order_id,omitempty"`
+	MakerOrderId  string                 `protobuf:"bytes,7,opt,name=maker_order_id,json=makerOrderId,proto3" json:"maker_order_id,omitempty"`
+	TakerOrderId  string                 `protobuf:"bytes,8,opt,name=taker_order_id,json=takerOrderId,proto3" json:"taker_order_id,omitempty"`
+	AggressorSide Side                   `protobuf:"varint,9,opt,name=aggressor_side,json=aggressorSide,proto3,enum=orderbook.v1.Side" json:"aggressor_side,omitempty"`
+	Price         string                 `protobuf:"bytes,10,opt,name=price,proto3" json:"price,omitempty"`
+	Amount        string                 `protobuf:"bytes,11,opt,name=amount,proto3" json:"amount,omitempty"`
+	MakerFee      string                 `protobuf:"bytes,12,opt,name=maker_fee,json=makerFee,proto3" json:"maker_fee,omitempty"`
+	TakerFee      string                 `protobuf:"bytes,13,opt,name=taker_fee,json=takerFee,proto3" json:"taker_fee,omitempty"`
+	FeeCurrency   string                 `protobuf:"bytes,14,opt,name=fee_currency,json=feeCurrency,proto3" json:"fee_currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Trade) Reset() {
+	*x = Trade{}
+	mi := &file_orderbook_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Trade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Trade) ProtoMessage() {}
+
+func (x *Trade) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Trade.ProtoReflect.Descriptor instead.
+func (*Trade) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Trade) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Trade) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Trade) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Trade) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Trade) GetBuyOrderId() string {
+	if x != nil {
+		return x.BuyOrderId
+	}
+	return ""
+}
+
+func (x *Trade) GetSellOrderId() string {
+	if x != nil {
+		return x.SellOrderId
+	}
+	return ""
+}
+
+func (x *Trade) GetMakerOrderId() string {
+	if x != nil {
+		return x.MakerOrderId
+	}
+	return ""
+}
+
+func (x *Trade) GetTakerOrderId() string {
+	if x != nil {
+		return x.TakerOrderId
+	}
+	return ""
+}
+
+func (x *Trade) GetAggressorSide() Side {
+	if x != nil {
+		return x.AggressorSide
+	}
+	return Side_SIDE_UNSPECIFIED
+}
+
+func (x *Trade) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Trade) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Trade) GetMakerFee() string {
+	if x != nil {
+		return x.MakerFee
+	}
+	return ""
+}
+
+func (x *Trade) GetTakerFee() string {
+	if x != nil {
+		return x.TakerFee
+	}
+	return ""
+}
+
+func (x *Trade) GetFeeCurrency() string {
+	if x != nil {
+		return x.FeeCurrency
+	}
+	return ""
+}
+
+type PublicTrade struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Price         string                 `protobuf:"bytes,2,opt,name=price,proto3" json:"price,omitempty"`
+	Amount        string                 `protobuf:"bytes,3,opt,name=amount,proto3" json:"amount,omitempty"`
+	AggressorSide Side                   `protobuf:"varint,4,opt,name=aggressor_side,json=aggressorSide,proto3,enum=orderbook.v1.Side" json:"aggressor_side,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublicTrade) Reset() {
+	*x = PublicTrade{}
+	mi := &file_orderbook_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublicTrade) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublicTrade) ProtoMessage() {}
+
+func (x *PublicTrade) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublicTrade.ProtoReflect.Descriptor instead.
+func (*PublicTrade) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PublicTrade) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PublicTrade) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *PublicTrade) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *PublicTrade) GetAggressorSide() Side {
+	if x != nil {
+		return x.AggressorSide
+	}
+	return Side_SIDE_UNSPECIFIED
+}
+
+func (x *PublicTrade) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type PreventedMatch struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	RestingOrderId string                 `protobuf:"bytes,1,opt,name=resting_order_id,json=restingOrderId,proto3" json:"resting_order_id,omitempty"`
+	Amount         string                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *PreventedMatch) Reset() {
+	*x = PreventedMatch{}
+	mi := &file_orderbook_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PreventedMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PreventedMatch) ProtoMessage() {}
+
+func (x *PreventedMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PreventedMatch.ProtoReflect.Descriptor instead.
+func (*PreventedMatch) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PreventedMatch) GetRestingOrderId() string {
+	if x != nil {
+		return x.RestingOrderId
+	}
+	return ""
+}
+
+func (x *PreventedMatch) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+type ProcessResult struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	OrderId         string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Status          OrderStatus            `protobuf:"varint,2,opt,name=status,proto3,enum=orderbook.v1.OrderStatus" json:"status,omitempty"`
+	TimeInForce     TimeInForce            `protobuf:"varint,3,opt,name=time_in_force,json=timeInForce,proto3,enum=orderbook.v1.TimeInForce" json:"time_in_force,omitempty"`
+	Price           string                 `protobuf:"bytes,4,opt,name=price,proto3" json:"price,omitempty"`
+	Trades          []*Trade               `protobuf:"bytes,5,rep,name=trades,proto3" json:"trades,omitempty"`
+	FilledAmount    string                 `protobuf:"bytes,6,opt,name=filled_amount,json=filledAmount,proto3" json:"filled_amount,omitempty"`
+	RestingAmount   string                 `protobuf:"bytes,7,opt,name=resting_amount,json=restingAmount,proto3" json:"resting_amount,omitempty"`
+	CancelledAmount string                 `protobuf:"bytes,8,opt,name=cancelled_amount,json=cancelledAmount,proto3" json:"cancelled_amount,omitempty"`
+	PreventedAmount string                 `protobuf:"bytes,9,opt,name=prevented_amount,json=preventedAmount,proto3" json:"prevented_amount,omitempty"`
+	Prevented       []*PreventedMatch      `protobuf:"bytes,10,rep,name=prevented,proto3" json:"prevented,omitempty"`
+	Triggered       []*ProcessResult       `protobuf:"bytes,11,rep,name=triggered,proto3" json:"triggered,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ProcessResult) Reset() {
+	*x = ProcessResult{}
+	mi := &file_orderbook_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessResult) ProtoMessage() {}
+
+func (x *ProcessResult) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessResult.ProtoReflect.Descriptor instead.
+func (*ProcessResult) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ProcessResult) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ProcessResult) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *ProcessResult) GetTimeInForce() TimeInForce {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return TimeInForce_TIME_IN_FORCE_UNSPECIFIED
+}
+
+func (x *ProcessResult) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *ProcessResult) GetTrades() []*Trade {
+	if x != nil {
+		return x.Trades
+	}
+	return nil
+}
+
+func (x *ProcessResult) GetFilledAmount() string {
+	if x != nil {
+		return x.FilledAmount
+	}
+	return ""
+}
+
+func (x *ProcessResult) GetRestingAmount() string {
+	if x != nil {
+		return x.RestingAmount
+	}
+	return ""
+}
+
+func (x *ProcessResult) GetCancelledAmount() string {
+	if x != nil {
+		return x.CancelledAmount
+	}
+	return ""
+}
+
+func (x *ProcessResult) GetPreventedAmount() string {
+	if x != nil {
+		return x.PreventedAmount
+	}
+	return ""
+}
+
+func (x *ProcessResult) GetPrevented() []*PreventedMatch {
+	if x != nil {
+		return x.Prevented
+	}
+	return nil
+}
+
+func (x *ProcessResult) GetTriggered() []*ProcessResult {
+	if x != nil {
+		return x.Triggered
+	}
+	return nil
+}
+
+type Level struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Price         string                 `protobuf:"bytes,1,opt,name=price,proto3" json:"price,omitempty"`
+	Amount        string                 `protobuf:"bytes,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Orders        int32                  `protobuf:"varint,3,opt,name=orders,proto3" json:"orders,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Level) Reset() {
+	*x = Level{}
+	mi := &file_orderbook_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Level) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Level) ProtoMessage() {}
+
+func (x *Level) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Level.ProtoReflect.Descriptor instead.
+func (*Level) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Level) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *Level) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *Level) GetOrders() int32 {
+	if x != nil {
+		return x.Orders
+	}
+	return 0
+}
+
+type Snapshot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Bids          []*Level               `protobuf:"bytes,1,rep,name=bids,proto3" json:"bids,omitempty"`
+	Asks          []*Level               `protobuf:"bytes,2,rep,name=asks,proto3" json:"asks,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	Seq           uint64                 `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	Checksum      uint32                 `protobuf:"varint,5,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Snapshot) Reset() {
+	*x = Snapshot{}
+	mi := &file_orderbook_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Snapshot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Snapshot) ProtoMessage() {}
+
+func (x *Snapshot) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Snapshot.ProtoReflect.Descriptor instead.
+func (*Snapshot) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Snapshot) GetBids() []*Level {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *Snapshot) GetAsks() []*Level {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+func (x *Snapshot) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Snapshot) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Snapshot) GetChecksum() uint32 {
+	if x != nil {
+		return x.Checksum
+	}
+	return 0
+}
+
+// DepthUpdate carries the levels that changed, with amount "0" for a level
+// that left the top, and the checksum of the top levels once applied.
+type DepthUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Snapshot      bool                   `protobuf:"varint,1,opt,name=snapshot,proto3" json:"snapshot,omitempty"` // The first message, with every top level
+	Bids          []*Level               `protobuf:"bytes,2,rep,name=bids,proto3" json:"bids,omitempty"`
+	Asks          []*Level               `protobuf:"bytes,3,rep,name=asks,proto3" json:"asks,omitempty"`
+	Checksum      uint32                 `protobuf:"varint,4,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DepthUpdate) Reset() {
+	*x = DepthUpdate{}
+	mi := &file_orderbook_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DepthUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepthUpdate) ProtoMessage() {}
+
+func (x *DepthUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepthUpdate.ProtoReflect.Descriptor instead.
+func (*DepthUpdate) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *DepthUpdate) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *DepthUpdate) GetBids() []*Level {
+	if x != nil {
+		return x.Bids
+	}
+	return nil
+}
+
+func (x *DepthUpdate) GetAsks() []*Level {
+	if x != nil {
+		return x.Asks
+	}
+	return nil
+}
+
+func (x *DepthUpdate) GetChecksum() uint32 {
+	if x != nil {
+		return x.Checksum
+	}
+	return 0
+}
+
+type PlaceOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Order         *Order                 `protobuf:"bytes,2,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderRequest) Reset() {
+	*x = PlaceOrderRequest{}
+	mi := &file_orderbook_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderRequest) ProtoMessage() {}
+
+func (x *PlaceOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderRequest.ProtoReflect.Descriptor instead.
+func (*PlaceOrderRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *PlaceOrderRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *PlaceOrderRequest) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type PlaceOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlaceOrderResponse) Reset() {
+	*x = PlaceOrderResponse{}
+	mi := &file_orderbook_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlaceOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlaceOrderResponse) ProtoMessage() {}
+
+func (x *PlaceOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlaceOrderResponse.ProtoReflect.Descriptor instead.
+func (*PlaceOrderResponse) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *PlaceOrderResponse) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type ProcessOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Order         *Order                 `protobuf:"bytes,2,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessOrderRequest) Reset() {
+	*x = ProcessOrderRequest{}
+	mi := &file_orderbook_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessOrderRequest) ProtoMessage() {}
+
+func (x *ProcessOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessOrderRequest.ProtoReflect.Descriptor instead.
+func (*ProcessOrderRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ProcessOrderRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ProcessOrderRequest) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type CancelOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_orderbook_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CancelOrderRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type CancelOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderResponse) Reset() {
+	*x = CancelOrderResponse{}
+	mi := &file_orderbook_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderResponse) ProtoMessage() {}
+
+func (x *CancelOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderResponse.ProtoReflect.Descriptor instead.
+func (*CancelOrderResponse) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{12}
+}
+
+type ModifyOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	OrderId       string                 `protobuf:"bytes,2,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Price         string                 `protobuf:"bytes,3,opt,name=price,proto3" json:"price,omitempty"`
+	Amount        string                 `protobuf:"bytes,4,opt,name=amount,proto3" json:"amount,omitempty"` // New open amount
+	StopPrice     string                 `protobuf:"bytes,5,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModifyOrderRequest) Reset() {
+	*x = ModifyOrderRequest{}
+	mi := &file_orderbook_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModifyOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModifyOrderRequest) ProtoMessage() {}
+
+func (x *ModifyOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModifyOrderRequest.ProtoReflect.Descriptor instead.
+func (*ModifyOrderRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ModifyOrderRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ModifyOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *ModifyOrderRequest) GetPrice() string {
+	if x != nil {
+		return x.Price
+	}
+	return ""
+}
+
+func (x *ModifyOrderRequest) GetAmount() string {
+	if x != nil {
+		return x.Amount
+	}
+	return ""
+}
+
+func (x *ModifyOrderRequest) GetStopPrice() string {
+	if x != nil {
+		return x.StopPrice
+	}
+	return ""
+}
+
+type ModifyOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ModifyOrderResponse) Reset() {
+	*x = ModifyOrderResponse{}
+	mi := &file_orderbook_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ModifyOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ModifyOrderResponse) ProtoMessage() {}
+
+func (x *ModifyOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ModifyOrderResponse.ProtoReflect.Descriptor instead.
+func (*ModifyOrderResponse) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{14}
+}
+
+type GetBestBidRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBestBidRequest) Reset() {
+	*x = GetBestBidRequest{}
+	mi := &file_orderbook_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBestBidRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBestBidRequest) ProtoMessage() {}
+
+func (x *GetBestBidRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBestBidRequest.ProtoReflect.Descriptor instead.
+func (*GetBestBidRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *GetBestBidRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type GetBestAskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBestAskRequest) Reset() {
+	*x = GetBestAskRequest{}
+	mi := &file_orderbook_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBestAskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBestAskRequest) ProtoMessage() {}
+
+func (x *GetBestAskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBestAskRequest.ProtoReflect.Descriptor instead.
+func (*GetBestAskRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetBestAskRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type GetSnapshotRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSnapshotRequest) Reset() {
+	*x = GetSnapshotRequest{}
+	mi := &file_orderbook_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSnapshotRequest) ProtoMessage() {}
+
+func (x *GetSnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSnapshotRequest.ProtoReflect.Descriptor instead.
+func (*GetSnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetSnapshotRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type StreamTradesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamTradesRequest) Reset() {
+	*x = StreamTradesRequest{}
+	mi := &file_orderbook_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamTradesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamTradesRequest) ProtoMessage() {}
+
+func (x *StreamTradesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamTradesRequest.ProtoReflect.Descriptor instead.
+func (*StreamTradesRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *StreamTradesRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+type StreamDepthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Symbol        string                 `protobuf:"bytes,1,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Depth         uint32                 `protobuf:"varint,2,opt,name=depth,proto3" json:"depth,omitempty"` // Levels per side, 10 if zero and at most 1000
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamDepthRequest) Reset() {
+	*x = StreamDepthRequest{}
+	mi := &file_orderbook_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamDepthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamDepthRequest) ProtoMessage() {}
+
+func (x *StreamDepthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orderbook_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamDepthRequest.ProtoReflect.Descriptor instead.
+func (*StreamDepthRequest) Descriptor() ([]byte, []int) {
+	return file_orderbook_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *StreamDepthRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *StreamDepthRequest) GetDepth() uint32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+var File_orderbook_proto protoreflect.FileDescriptor
+
+const file_orderbook_proto_rawDesc = "" +
+	"\n" +
+	"\x0forderbook.proto\x12\forderbook.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xd5\x04\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12+\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x17.orderbook.v1.OrderTypeR\x04type\x12&\n" +
+	"\x04side\x18\x03 \x01(\x0e2\x12.orderbook.v1.SideR\x04side\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\tR\x05price\x12\x16\n" +
+	"\x06amount\x18\x05 \x01(\tR\x06amount\x12\x18\n" +
+	"\aaccount\x18\x06 \x01(\tR\aaccount\x12U\n" +
+	"\x15self_trade_prevention\x18\a \x01(\x0e2!.orderbook.v1.SelfTradePreventionR\x13selfTradePrevention\x12\x1d\n" +
+	"\n" +
+	"stop_price\x18\b \x01(\tR\tstopPrice\x12=\n" +
+	"\rtime_in_force\x18\t \x01(\x0e2\x19.orderbook.v1.TimeInForceR\vtimeInForce\x127\n" +
+	"\texpire_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\bexpireAt\x12\x1b\n" +
+	"\tpost_only\x18\v \x01(\bR\bpostOnly\x12&\n" +
+	"\x0fpost_only_slide\x18\f \x01(\bR\rpostOnlySlide\x12\x1f\n" +
+	"\vpeak_amount\x18\r \x01(\tR\n" +
+	"peakAmount\x12(\n" +
+	"\x10max_slippage_bps\x18\x0e \x01(\x03R\x0emaxSlippageBps\x12!\n" +
+	"\fmax_notional\x18\x0f \x01(\tR\vmaxNotional\"\xd3\x03\n" +
+	"\x05Trade\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03seq\x18\x02 \x01(\x04R\x03seq\x12\x16\n" +
+	"\x06symbol\x18\x03 \x01(\tR\x06symbol\x128\n" +
+	"\ttimestamp\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12 \n" +
+	"\fbuy_order_id\x18\x05 \x01(\tR\n" +
+	"buyOrderId\x12\"\n" +
+	"\rsell_order_id\x18\x06 \x01(\tR\vsellOrderId\x12$\n" +
+	"\x0emaker_order_id\x18\a \x01(\tR\fmakerOrderId\x12$\n" +
+	"\x0etaker_order_id\x18\b \x01(\tR\ftakerOrderId\x129\n" +
+	"\x0eaggressor_side\x18\t \x01(\x0e2\x12.orderbook.v1.SideR\raggressorSide\x12\x14\n" +
+	"\x05price\x18\n" +
+	" \x01(\tR\x05price\x12\x16\n" +
+	"\x06amount\x18\v \x01(\tR\x06amount\x12\x1b\n" +
+	"\tmaker_fee\x18\f \x01(\tR\bmakerFee\x12\x1b\n" +
+	"\ttaker_fee\x18\r \x01(\tR\btakerFee\x12!\n" +
+	"\ffee_currency\x18\x0e \x01(\tR\vfeeCurrency\"\xc0\x01\n" +
+	"\vPublicTrade\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05price\x18\x02 \x01(\tR\x05price\x12\x16\n" +
+	"\x06amount\x18\x03 \x01(\tR\x06amount\x129\n" +
+	"\x0eaggressor_side\x18\x04 \x01(\x0e2\x12.orderbook.v1.SideR\raggressorSide\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"R\n" +
+	"\x0ePreventedMatch\x12(\n" +
+	"\x10resting_order_id\x18\x01 \x01(\tR\x0erestingOrderId\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\tR\x06amount\"\xf8\x03\n" +
+	"\rProcessResult\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x121\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x19.orderbook.v1.OrderStatusR\x06status\x12=\n" +
+	"\rtime_in_force\x18\x03 \x01(\x0e2\x19.orderbook.v1.TimeInForceR\vtimeInForce\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\tR\x05price\x12+\n" +
+	"\x06trades\x18\x05 \x03(\v2\x13.orderbook.v1.TradeR\x06trades\x12#\n" +
+	"\rfilled_amount\x18\x06 \x01(\tR\ffilledAmount\x12%\n" +
+	"\x0eresting_amount\x18\a \x01(\tR\rrestingAmount\x12)\n" +
+	"\x10cancelled_amount\x18\b \x01(\tR\x0fcancelledAmount\x12)\n" +
+	"\x10prevented_amount\x18\t \x01(\tR\x0fpreventedAmount\x12:\n" +
+	"\tprevented\x18\n" +
+	" \x03(\v2\x1c.orderbook.v1.PreventedMatchR\tprevented\x129\n" +
+	"\ttriggered\x18\v \x03(\v2\x1b.orderbook.v1.ProcessResultR\ttriggered\"M\n" +
+	"\x05Level\x12\x14\n" +
+	"\x05price\x18\x01 \x01(\tR\x05price\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\tR\x06amount\x12\x16\n" +
+	"\x06orders\x18\x03 \x01(\x05R\x06orders\"\xba\x01\n" +
+	"\bSnapshot\x12'\n" +
+	"\x04bids\x18\x01 \x03(\v2\x13.orderbook.v1.LevelR\x04bids\x12'\n" +
+	"\x04asks\x18\x02 \x03(\v2\x13.orderbook.v1.LevelR\x04asks\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x10\n" +
+	"\x03seq\x18\x04 \x01(\x04R\x03seq\x12\x1a\n" +
+	"\bchecksum\x18\x05 \x01(\rR\bchecksum\"\x97\x01\n" +
+	"\vDepthUpdate\x12\x1a\n" +
+	"\bsnapshot\x18\x01 \x01(\bR\bsnapshot\x12'\n" +
+	"\x04bids\x18\x02 \x03(\v2\x13.orderbook.v1.LevelR\x04bids\x12'\n" +
+	"\x04asks\x18\x03 \x03(\v2\x13.orderbook.v1.LevelR\x04asks\x12\x1a\n" +
+	"\bchecksum\x18\x04 \x01(\rR\bchecksum\"V\n" +
+	"\x11PlaceOrderRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12)\n" +
+	"\x05order\x18\x02 \x01(\v2\x13.orderbook.v1.OrderR\x05order\"/\n" +
+	"\x12PlaceOrderResponse\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"X\n" +
+	"\x13ProcessOrderRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12)\n" +
+	"\x05order\x18\x02 \x01(\v2\x13.orderbook.v1.OrderR\x05order\"G\n" +
+	"\x12CancelOrderRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\"\x15\n" +
+	"\x13CancelOrderResponse\"\x94\x01\n" +
+	"\x12ModifyOrderRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x19\n" +
+	"\border_id\x18\x02 \x01(\tR\aorderId\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\tR\x05price\x12\x16\n" +
+	"\x06amount\x18\x04 \x01(\tR\x06amount\x12\x1d\n" +
+	"\n" +
+	"stop_price\x18\x05 \x01(\tR\tstopPrice\"\x15\n" +
+	"\x13ModifyOrderResponse\"+\n" +
+	"\x11GetBestBidRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"+\n" +
+	"\x11GetBestAskRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\",\n" +
+	"\x12GetSnapshotRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"-\n" +
+	"\x13StreamTradesRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\"B\n" +
+	"\x12StreamDepthRequest\x12\x16\n" +
+	"\x06symbol\x18\x01 \x01(\tR\x06symbol\x12\x14\n" +
+	"\x05depth\x18\x02 \x01(\rR\x05depth*9\n" +
+	"\x04Side\x12\x14\n" +
+	"\x10SIDE_UNSPECIFIED\x10\x00\x12\f\n" +
+	"\bSIDE_BUY\x10\x01\x12\r\n" +
+	"\tSIDE_SELL\x10\x02*\x84\x01\n" +
+	"\tOrderType\x12\x1a\n" +
+	"\x16ORDER_TYPE_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10ORDER_TYPE_LIMIT\x10\x01\x12\x15\n" +
+	"\x11ORDER_TYPE_MARKET\x10\x02\x12\x13\n" +
+	"\x0fORDER_TYPE_STOP\x10\x03\x12\x19\n" +
+	"\x15ORDER_TYPE_STOP_LIMIT\x10\x04*\x88\x01\n" +
+	"\vTimeInForce\x12\x1d\n" +
+	"\x19TIME_IN_FORCE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11TIME_IN_FORCE_GTC\x10\x01\x12\x15\n" +
+	"\x11TIME_IN_FORCE_IOC\x10\x02\x12\x15\n" +
+	"\x11TIME_IN_FORCE_FOK\x10\x03\x12\x15\n" +
+	"\x11TIME_IN_FORCE_GTD\x10\x04*\xe5\x01\n" +
+	"\x13SelfTradePrevention\x12%\n" +
+	"!SELF_TRADE_PREVENTION_UNSPECIFIED\x10\x00\x12'\n" +
+	"#SELF_TRADE_PREVENTION_CANCEL_NEWEST\x10\x01\x12'\n" +
+	"#SELF_TRADE_PREVENTION_CANCEL_OLDEST\x10\x02\x12%\n" +
+	"!SELF_TRADE_PREVENTION_CANCEL_BOTH\x10\x03\x12.\n" +
+	"*SELF_TRADE_PREVENTION_DECREMENT_AND_CANCEL\x10\x04*\xec\x01\n" +
+	"\vOrderStatus\x12\x1c\n" +
+	"\x18ORDER_STATUS_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10ORDER_STATUS_NEW\x10\x01\x12!\n" +
+	"\x1dORDER_STATUS_PARTIALLY_FILLED\x10\x02\x12\x17\n" +
+	"\x13ORDER_STATUS_FILLED\x10\x03\x12\x1a\n" +
+	"\x16ORDER_STATUS_CANCELLED\x10\x04\x12\x18\n" +
+	"\x14ORDER_STATUS_EXPIRED\x10\x05\x12\x1c\n" +
+	"\x18ORDER_STATUS_UNTRIGGERED\x10\x06\x12\x19\n" +
+	"\x15ORDER_STATUS_REJECTED\x10\a2\xc3\x05\n" +
+	"\tOrderBook\x12O\n" +
+	"\n" +
+	"PlaceOrder\x12\x1f.orderbook.v1.PlaceOrderRequest\x1a .orderbook.v1.PlaceOrderResponse\x12N\n" +
+	"\fProcessOrder\x12!.orderbook.v1.ProcessOrderRequest\x1a\x1b.orderbook.v1.ProcessResult\x12R\n" +
+	"\vCancelOrder\x12 .orderbook.v1.CancelOrderRequest\x1a!.orderbook.v1.CancelOrderResponse\x12R\n" +
+	"\vModifyOrder\x12 .orderbook.v1.ModifyOrderRequest\x1a!.orderbook.v1.ModifyOrderResponse\x12B\n" +
+	"\n" +
+	"GetBestBid\x12\x1f.orderbook.v1.GetBestBidRequest\x1a\x13.orderbook.v1.Order\x12B\n" +
+	"\n" +
+	"GetBestAsk\x12\x1f.orderbook.v1.GetBestAskRequest\x1a\x13.orderbook.v1.Order\x12G\n" +
+	"\vGetSnapshot\x12 .orderbook.v1.GetSnapshotRequest\x1a\x16.orderbook.v1.Snapshot\x12N\n" +
+	"\fStreamTrades\x12!.orderbook.v1.StreamTradesRequest\x1a\x19.orderbook.v1.PublicTrade0\x01\x12L\n" +
+	"\vStreamDepth\x12 .orderbook.v1.StreamDepthRequest\x1a\x19.orderbook.v1.DepthUpdate0\x01B$Z\"orderbook/internal/api/orderbookpbb\x06proto3"
+
+var (
+	file_orderbook_proto_rawDescOnce sync.Once
+	file_orderbook_proto_rawDescData []byte
+)
+
+func file_orderbook_proto_rawDescGZIP() []byte {
+	file_orderbook_proto_rawDescOnce.Do(func() {
+		file_orderbook_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_orderbook_proto_rawDesc), len(file_orderbook_proto_rawDesc)))
+	})
+	return file_orderbook_proto_rawDescData
+}
+
+var file_orderbook_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_orderbook_proto_msgTypes = make([]protoimpl.MessageInfo, 20)
+var file_orderbook_proto_goTypes = []any{
+	(Side)(0),                     // 0: orderbook.v1.Side
+	(OrderType)(0),                // 1: orderbook.v1.OrderType
+	(TimeInForce)(0),              // 2: orderbook.v1.TimeInForce
+	(SelfTradePrevention)(0),      // 3: orderbook.v1.SelfTradePrevention
+	(OrderStatus)(0),              // 4: orderbook.v1.OrderStatus
+	(*Order)(nil),                 // 5: orderbook.v1.Order
+	(*Trade)(nil),                 // 6: orderbook.v1.Trade
+	(*PublicTrade)(nil),           // 7: orderbook.v1.PublicTrade
+	(*PreventedMatch)(nil),        // 8: orderbook.v1.PreventedMatch
+	(*ProcessResult)(nil),         // 9: orderbook.v1.ProcessResult
+	(*Level)(nil),                 // 10: orderbook.v1.Level
+	(*Snapshot)(nil),              // 11: orderbook.v1.Snapshot
+	(*DepthUpdate)(nil),           // 12: orderbook.v1.DepthUpdate
+	(*PlaceOrderRequest)(nil),     // 13: orderbook.v1.PlaceOrderRequest
+	(*PlaceOrderResponse)(nil),    // 14: orderbook.v1.PlaceOrderResponse
+	(*ProcessOrderRequest)(nil),   // 15: orderbook.v1.ProcessOrderRequest
+	(*CancelOrderRequest)(nil),    // 16: orderbook.v1.CancelOrderRequest
+	(*CancelOrderResponse)(nil),   // 17: orderbook.v1.CancelOrderResponse
+	(*ModifyOrderRequest)(nil),    // 18: orderbook.v1.ModifyOrderRequest
+	(*ModifyOrderResponse)(nil),   // 19: orderbook.v1.ModifyOrderResponse
+	(*GetBestBidRequest)(nil),     // 20: orderbook.v1.GetBestBidRequest
+	(*GetBestAskRequest)(nil),     // 21: orderbook.v1.GetBestAskRequest
+	(*GetSnapshotRequest)(nil),    // 22: orderbook.v1.GetSnapshotRequest
+	(*StreamTradesRequest)(nil),   // 23: orderbook.v1.StreamTradesRequest
+	(*StreamDepthRequest)(nil),    // 24: orderbook.v1.StreamDepthRequest
+	(*timestamppb.Timestamp)(nil), // 25: google.protobuf.Timestamp
+}
+var file_orderbook_proto_depIdxs = []int32{
+	1,  // 0: orderbook.v1.Order.type:type_name -> orderbook.v1.OrderType
+	0,  // 1: orderbook.v1.Order.side:type_name -> orderbook.v1.Side
+	3,  // 2: orderbook.v1.Order.self_trade_prevention:type_name -> orderbook.v1.SelfTradePrevention
+	2,  // 3: orderbook.v1.Order.time_in_force:type_name -> orderbook.v1.TimeInForce
+	25, // 4: orderbook.v1.Order.expire_at:type_name -> google.protobuf.Timestamp
+	25, // 5: orderbook.v1.Trade.timestamp:type_name -> google.protobuf.Timestamp
+	0,  // 6: orderbook.v1.Trade.aggressor_side:type_name -> orderbook.v1.Side
+	0,  // 7: orderbook.v1.PublicTrade.aggressor_side:type_name -> orderbook.v1.Side
+	25, // 8: orderbook.v1.PublicTrade.timestamp:type_name -> google.protobuf.Timestamp
+	4,  // 9: orderbook.v1.ProcessResult.status:type_name -> orderbook.v1.OrderStatus
+	2,  // 10: orderbook.v1.ProcessResult.time_in_force:type_name -> orderbook.v1.TimeInForce
+	6,  // 11: orderbook.v1.ProcessResult.trades:type_name -> orderbook.v1.Trade
+	8,  // 12: orderbook.v1.ProcessResult.prevented:type_name -> orderbook.v1.PreventedMatch
+	9,  // 13: orderbook.v1.ProcessResult.triggered:type_name -> orderbook.v1.ProcessResult
+	10, // 14: orderbook.v1.Snapshot.bids:type_name -> orderbook.v1.Level
+	10, // 15: orderbook.v1.Snapshot.asks:type_name -> orderbook.v1.Level
+	25, // 16: orderbook.v1.Snapshot.time:type_name -> google.protobuf.Timestamp
+	10, // 17: orderbook.v1.DepthUpdate.bids:type_name -> orderbook.v1.Level
+	10, // 18: orderbook.v1.DepthUpdate.asks:type_name -> orderbook.v1.Level
+	5,  // 19: orderbook.v1.PlaceOrderRequest.order:type_name -> orderbook.v1.Order
+	5,  // 20: orderbook.v1.ProcessOrderRequest.order:type_name -> orderbook.v1.Order
+	13, // 21: orderbook.v1.OrderBook.PlaceOrder:input_type -> orderbook.v1.PlaceOrderRequest
+	15, // 22: orderbook.v1.OrderBook.ProcessOrder:input_type -> orderbook.v1.ProcessOrderRequest
+	16, // 23: orderbook.v1.OrderBook.CancelOrder:input_type -> orderbook.v1.CancelOrderRequest
+	18, // 24: orderbook.v1.OrderBook.ModifyOrder:input_type -> orderbook.v1.ModifyOrderRequest
+	20, // 25: orderbook.v1.OrderBook.GetBestBid:input_type -> orderbook.v1.GetBestBidRequest
+	21, // 26: orderbook.v1.OrderBook.GetBestAsk:input_type -> orderbook.v1.GetBestAskRequest
+	22, // 27: orderbook.v1.OrderBook.GetSnapshot:input_type -> orderbook.v1.GetSnapshotRequest
+	23, // 28: orderbook.v1.OrderBook.StreamTrades:input_type -> orderbook.v1.StreamTradesRequest
+	24, // 29: orderbook.v1.OrderBook.StreamDepth:input_type -> orderbook.v1.StreamDepthRequest
+	14, // 30: orderbook.v1.OrderBook.PlaceOrder:output_type -> orderbook.v1.PlaceOrderResponse
+	9,  // 31: orderbook.v1.OrderBook.ProcessOrder:output_type -> orderbook.v1.ProcessResult
+	17, // 32: orderbook.v1.OrderBook.CancelOrder:output_type -> orderbook.v1.CancelOrderResponse
+	19, // 33: orderbook.v1.OrderBook.ModifyOrder:output_type -> orderbook.v1.ModifyOrderResponse
+	5,  // 34: orderbook.v1.OrderBook.GetBestBid:output_type -> orderbook.v1.Order
+	5,  // 35: orderbook.v1.OrderBook.GetBestAsk:output_type -> orderbook.v1.Order
+	11, // 36: orderbook.v1.OrderBook.GetSnapshot:output_type -> orderbook.v1.Snapshot
+	7,  // 37: orderbook.v1.OrderBook.StreamTrades:output_type -> orderbook.v1.PublicTrade
+	12, // 38: orderbook.v1.OrderBook.StreamDepth:output_type -> orderbook.v1.DepthUpdate
+	30, // [30:39] is the sub-list for method output_type
+	21, // [21:30] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_orderbook_proto_init() }
+func file_orderbook_proto_init() {
+	if File_orderbook_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_orderbook_proto_rawDesc), len(file_orderbook_proto_rawDesc)),
+			NumEnums:      5,
+			NumMessages:   20,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_orderbook_proto_goTypes,
+		DependencyIndexes: file_orderbook_proto_depIdxs,
+		EnumInfos:         file_orderbook_proto_enumTypes,
+		MessageInfos:      file_orderbook_proto_msgTypes,
+	}.Build()
+	File_orderbook_proto = out.File
+	file_orderbook_proto_goTypes = nil
+	file_orderbook_proto_depIdxs = nil
+}
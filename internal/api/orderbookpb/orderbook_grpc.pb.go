@@ -0,0 +1,460 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: orderbook.proto
+
+package orderbookpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	OrderBook_PlaceOrder_FullMethodName   = "/orderbook.v1.OrderBook/PlaceOrder"
+	OrderBook_ProcessOrder_FullMethodName = "/orderbook.v1.OrderBook/ProcessOrder"
+	OrderBook_CancelOrder_FullMethodName  = "/orderbook.v1.OrderBook/CancelOrder"
+	OrderBook_ModifyOrder_FullMethodName  = "/orderbook.v1.OrderBook/ModifyOrder"
+	OrderBook_GetBestBid_FullMethodName   = "/orderbook.v1.OrderBook/GetBestBid"
+	OrderBook_GetBestAsk_FullMethodName   = "/orderbook.v1.OrderBook/GetBestAsk"
+	OrderBook_GetSnapshot_FullMethodName  = "/orderbook.v1.OrderBook/GetSnapshot"
+	OrderBook_StreamTrades_FullMethodName = "/orderbook.v1.OrderBook/StreamTrades"
+	OrderBook_StreamDepth_FullMethodName  = "/orderbook.v1.OrderBook/StreamDepth"
+)
+
+// OrderBookClient is the client API for OrderBook service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// OrderBook is the gRPC counterpart of the order and order book endpoints
+// of the HTTP API, plus streams of the trades and depth of a market. Every
+// request names its market by symbol. Prices and amounts are decimal
+// strings, as in the JSON API, so that they keep their exact value.
+type OrderBookClient interface {
+	// Adds a limit order to the book without matching it.
+	PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*PlaceOrderResponse, error)
+	// Matches an order against the book and rests what is left of a GTC or
+	// GTD limit order.
+	ProcessOrder(ctx context.Context, in *ProcessOrderRequest, opts ...grpc.CallOption) (*ProcessResult, error)
+	CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error)
+	// Changes the price and open amount of a resting order, or of a stop
+	// order when stop_price is set.
+	ModifyOrder(ctx context.Context, in *ModifyOrderRequest, opts ...grpc.CallOption) (*ModifyOrderResponse, error)
+	GetBestBid(ctx context.Context, in *GetBestBidRequest, opts ...grpc.CallOption) (*Order, error)
+	GetBestAsk(ctx context.Context, in *GetBestAskRequest, opts ...grpc.CallOption) (*Order, error)
+	GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*Snapshot, error)
+	// Public trades from now on, without the orders or accounts behind them.
+	// The headers arrive once the stream is subscribed.
+	StreamTrades(ctx context.Context, in *StreamTradesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PublicTrade], error)
+	// The top levels of each side: a snapshot first, then only the levels
+	// that changed.
+	StreamDepth(ctx context.Context, in *StreamDepthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DepthUpdate], error)
+}
+
+type orderBookClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrderBookClient(cc grpc.ClientConnInterface) OrderBookClient {
+	return &orderBookClient{cc}
+}
+
+func (c *orderBookClient) PlaceOrder(ctx context.Context, in *PlaceOrderRequest, opts ...grpc.CallOption) (*PlaceOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlaceOrderResponse)
+	err := c.cc.Invoke(ctx, OrderBook_PlaceOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) ProcessOrder(ctx context.Context, in *ProcessOrderRequest, opts ...grpc.CallOption) (*ProcessResult, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ProcessResult)
+	err := c.cc.Invoke(ctx, OrderBook_ProcessOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) CancelOrder(ctx context.Context, in *CancelOrderRequest, opts ...grpc.CallOption) (*CancelOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelOrderResponse)
+	err := c.cc.Invoke(ctx, OrderBook_CancelOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) ModifyOrder(ctx context.Context, in *ModifyOrderRequest, opts ...grpc.CallOption) (*ModifyOrderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ModifyOrderResponse)
+	err := c.cc.Invoke(ctx, OrderBook_ModifyOrder_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) GetBestBid(ctx context.Context, in *GetBestBidRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, OrderBook_GetBestBid_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) GetBestAsk(ctx context.Context, in *GetBestAskRequest, opts ...grpc.CallOption) (*Order, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Order)
+	err := c.cc.Invoke(ctx, OrderBook_GetBestAsk_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*Snapshot, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Snapshot)
+	err := c.cc.Invoke(ctx, OrderBook_GetSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *orderBookClient) StreamTrades(ctx context.Context, in *StreamTradesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[PublicTrade], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderBook_ServiceDesc.Streams[0], OrderBook_StreamTrades_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTradesRequest, PublicTrade]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBook_StreamTradesClient = grpc.ServerStreamingClient[PublicTrade]
+
+func (c *orderBookClient) StreamDepth(ctx context.Context, in *StreamDepthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DepthUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &OrderBook_ServiceDesc.Streams[1], OrderBook_StreamDepth_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamDepthRequest, DepthUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBook_StreamDepthClient = grpc.ServerStreamingClient[DepthUpdate]
+
+// OrderBookServer is the server API for OrderBook service.
+// All implementations must embed UnimplementedOrderBookServer
+// for forward compatibility.
+//
+// OrderBook is the gRPC counterpart of the order and order book endpoints
+// of the HTTP API, plus streams of the trades and depth of a market. Every
+// request names its market by symbol. Prices and amounts are decimal
+// strings, as in the JSON API, so that they keep their exact value.
+type OrderBookServer interface {
+	// Adds a limit order to the book without matching it.
+	PlaceOrder(context.Context, *PlaceOrderRequest) (*PlaceOrderResponse, error)
+	// Matches an order against the book and rests what is left of a GTC or
+	// GTD limit order.
+	ProcessOrder(context.Context, *ProcessOrderRequest) (*ProcessResult, error)
+	CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error)
+	// Changes the price and open amount of a resting order, or of a stop
+	// order when stop_price is set.
+	ModifyOrder(context.Context, *ModifyOrderRequest) (*ModifyOrderResponse, error)
+	GetBestBid(context.Context, *GetBestBidRequest) (*Order, error)
+	GetBestAsk(context.Context, *GetBestAskRequest) (*Order, error)
+	GetSnapshot(context.Context, *GetSnapshotRequest) (*Snapshot, error)
+	// Public trades from now on, without the orders or accounts behind them.
+	// The headers arrive once the stream is subscribed.
+	StreamTrades(*StreamTradesRequest, grpc.ServerStreamingServer[PublicTrade]) error
+	// The top levels of each side: a snapshot first, then only the levels
+	// that changed.
+	StreamDepth(*StreamDepthRequest, grpc.ServerStreamingServer[DepthUpdate]) error
+	mustEmbedUnimplementedOrderBookServer()
+}
+
+// UnimplementedOrderBookServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrderBookServer struct{}
+
+func (UnimplementedOrderBookServer) PlaceOrder(context.Context, *PlaceOrderRequest) (*PlaceOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlaceOrder not implemented")
+}
+func (UnimplementedOrderBookServer) ProcessOrder(context.Context, *ProcessOrderRequest) (*ProcessResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessOrder not implemented")
+}
+func (UnimplementedOrderBookServer) CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelOrder not implemented")
+}
+func (UnimplementedOrderBookServer) ModifyOrder(context.Context, *ModifyOrderRequest) (*ModifyOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ModifyOrder not implemented")
+}
+func (UnimplementedOrderBookServer) GetBestBid(context.Context, *GetBestBidRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBestBid not implemented")
+}
+func (UnimplementedOrderBookServer) GetBestAsk(context.Context, *GetBestAskRequest) (*Order, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBestAsk not implemented")
+}
+func (UnimplementedOrderBookServer) GetSnapshot(context.Context, *GetSnapshotRequest) (*Snapshot, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshot not implemented")
+}
+func (UnimplementedOrderBookServer) StreamTrades(*StreamTradesRequest, grpc.ServerStreamingServer[PublicTrade]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTrades not implemented")
+}
+func (UnimplementedOrderBookServer) StreamDepth(*StreamDepthRequest, grpc.ServerStreamingServer[DepthUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamDepth not implemented")
+}
+func (UnimplementedOrderBookServer) mustEmbedUnimplementedOrderBookServer() {}
+func (UnimplementedOrderBookServer) testEmbeddedByValue()                   {}
+
+// UnsafeOrderBookServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrderBookServer will
+// result in compilation errors.
+type UnsafeOrderBookServer interface {
+	mustEmbedUnimplementedOrderBookServer()
+}
+
+func RegisterOrderBookServer(s grpc.ServiceRegistrar, srv OrderBookServer) {
+	// If the following call pancis, it indicates UnimplementedOrderBookServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&OrderBook_ServiceDesc, srv)
+}
+
+func _OrderBook_PlaceOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlaceOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).PlaceOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_PlaceOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).PlaceOrder(ctx, req.(*PlaceOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_ProcessOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).ProcessOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_ProcessOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).ProcessOrder(ctx, req.(*ProcessOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_CancelOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).CancelOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_CancelOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).CancelOrder(ctx, req.(*CancelOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_ModifyOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModifyOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).ModifyOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_ModifyOrder_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).ModifyOrder(ctx, req.(*ModifyOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_GetBestBid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBestBidRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).GetBestBid(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_GetBestBid_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).GetBestBid(ctx, req.(*GetBestBidRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_GetBestAsk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBestAskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).GetBestAsk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_GetBestAsk_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).GetBestAsk(ctx, req.(*GetBestAskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_GetSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OrderBookServer).GetSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OrderBook_GetSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OrderBookServer).GetSnapshot(ctx, req.(*GetSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OrderBook_StreamTrades_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTradesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderBookServer).StreamTrades(m, &grpc.GenericServerStream[StreamTradesRequest, PublicTrade]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBook_StreamTradesServer = grpc.ServerStreamingServer[PublicTrade]
+
+func _OrderBook_StreamDepth_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamDepthRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(OrderBookServer).StreamDepth(m, &grpc.GenericServerStream[StreamDepthRequest, DepthUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type OrderBook_StreamDepthServer = grpc.ServerStreamingServer[DepthUpdate]
+
+// OrderBook_ServiceDesc is the grpc.ServiceDesc for OrderBook service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OrderBook_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "orderbook.v1.OrderBook",
+	HandlerType: (*OrderBookServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PlaceOrder",
+			Handler:    _OrderBook_PlaceOrder_Handler,
+		},
+		{
+			MethodName: "ProcessOrder",
+			Handler:    _OrderBook_ProcessOrder_Handler,
+		},
+		{
+			MethodName: "CancelOrder",
+			Handler:    _OrderBook_CancelOrder_Handler,
+		},
+		{
+			MethodName: "ModifyOrder",
+			Handler:    _OrderBook_ModifyOrder_Handler,
+		},
+		{
+			MethodName: "GetBestBid",
+			Handler:    _OrderBook_GetBestBid_Handler,
+		},
+		{
+			MethodName: "GetBestAsk",
+			Handler:    _OrderBook_GetBestAsk_Handler,
+		},
+		{
+			MethodName: "GetSnapshot",
+			Handler:    _OrderBook_GetSnapshot_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTrades",
+			Handler:       _OrderBook_StreamTrades_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamDepth",
+			Handler:       _OrderBook_StreamDepth_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "orderbook.proto",
+}
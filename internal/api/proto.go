@@ -0,0 +1,242 @@
+package api
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"orderbook/internal/api/orderbookpb"
+	"orderbook/internal/orderbook"
+)
+
+// The unspecified value of each enum stands for the empty value of the
+// book, which picks its default.
+var (
+	protoSides = map[orderbook.Side]orderbookpb.Side{
+		orderbook.Buy:  orderbookpb.Side_SIDE_BUY,
+		orderbook.Sell: orderbookpb.Side_SIDE_SELL,
+	}
+	protoOrderTypes = map[orderbook.OrderType]orderbookpb.OrderType{
+		orderbook.Limit:     orderbookpb.OrderType_ORDER_TYPE_LIMIT,
+		orderbook.Market:    orderbookpb.OrderType_ORDER_TYPE_MARKET,
+		orderbook.Stop:      orderbookpb.OrderType_ORDER_TYPE_STOP,
+		orderbook.StopLimit: orderbookpb.OrderType_ORDER_TYPE_STOP_LIMIT,
+	}
+	protoTimeInForces = map[orderbook.TimeInForce]orderbookpb.TimeInForce{
+		orderbook.GTC: orderbookpb.TimeInForce_TIME_IN_FORCE_GTC,
+		orderbook.IOC: orderbookpb.TimeInForce_TIME_IN_FORCE_IOC,
+		orderbook.FOK: orderbookpb.TimeInForce_TIME_IN_FORCE_FOK,
+		orderbook.GTD: orderbookpb.TimeInForce_TIME_IN_FORCE_GTD,
+	}
+	protoSelfTradePreventions = map[orderbook.SelfTradePrevention]orderbookpb.SelfTradePrevention{
+		orderbook.CancelNewest:       orderbookpb.SelfTradePrevention_SELF_TRADE_PREVENTION_CANCEL_NEWEST,
+		orderbook.CancelOldest:       orderbookpb.SelfTradePrevention_SELF_TRADE_PREVENTION_CANCEL_OLDEST,
+		orderbook.CancelBoth:         orderbookpb.SelfTradePrevention_SELF_TRADE_PREVENTION_CANCEL_BOTH,
+		orderbook.DecrementAndCancel: orderbookpb.SelfTradePrevention_SELF_TRADE_PREVENTION_DECREMENT_AND_CANCEL,
+	}
+	protoOrderStatuses = map[orderbook.OrderStatus]orderbookpb.OrderStatus{
+		orderbook.StatusNew:             orderbookpb.OrderStatus_ORDER_STATUS_NEW,
+		orderbook.StatusPartiallyFilled: orderbookpb.OrderStatus_ORDER_STATUS_PARTIALLY_FILLED,
+		orderbook.StatusFilled:          orderbookpb.OrderStatus_ORDER_STATUS_FILLED,
+		orderbook.StatusCancelled:       orderbookpb.OrderStatus_ORDER_STATUS_CANCELLED,
+		orderbook.StatusExpired:         orderbookpb.OrderStatus_ORDER_STATUS_EXPIRED,
+		orderbook.StatusUntriggered:     orderbookpb.OrderStatus_ORDER_STATUS_UNTRIGGERED,
+		orderbook.StatusRejected:        orderbookpb.OrderStatus_ORDER_STATUS_REJECTED,
+	}
+
+	sides                = invert(protoSides)
+	orderTypes           = invert(protoOrderTypes)
+	timeInForces         = invert(protoTimeInForces)
+	selfTradePreventions = invert(protoSelfTradePreventions)
+)
+
+func invert[K, V comparable](m map[K]V) map[V]K {
+	inverted := make(map[V]K, len(m))
+	for k, v := range m {
+		inverted[v] = k
+	}
+	return inverted
+}
+
+// fromEnum looks up the book value of an enum, the empty value for the
+// unspecified one.
+func fromEnum[E ~int32, T any](values map[E]T, e E, name string) (T, error) {
+	value, ok := values[e]
+	if !ok && e != 0 {
+		return value, status.Errorf(codes.InvalidArgument, "Invalid %s", name)
+	}
+	return value, nil
+}
+
+// fromDecimal parses a decimal field, zero if it is empty.
+func fromDecimal(value, name string) (orderbook.Decimal, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := orderbook.ParseDecimal(value)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "%s is Not a Number", name)
+	}
+	return d, nil
+}
+
+func orderFromProto(o *orderbookpb.Order) (orderbook.Order, error) {
+	if o == nil {
+		return orderbook.Order{}, status.Error(codes.InvalidArgument, "Order is Required")
+	}
+	order := orderbook.Order{
+		ID:             o.Id,
+		Account:        o.Account,
+		PostOnly:       o.PostOnly,
+		PostOnlySlide:  o.PostOnlySlide,
+		MaxSlippageBps: o.MaxSlippageBps,
+	}
+	if o.ExpireAt != nil {
+		expireAt := o.ExpireAt.AsTime()
+		order.ExpireAt = &expireAt
+	}
+
+	var err error
+	if order.Type, err = fromEnum(orderTypes, o.Type, "Order Type"); err != nil {
+		return order, err
+	}
+	if order.Side, err = fromEnum(sides, o.Side, "Side"); err != nil {
+		return order, err
+	}
+	if order.TimeInForce, err = fromEnum(timeInForces, o.TimeInForce, "Time In Force"); err != nil {
+		return order, err
+	}
+	if order.SelfTradePrevention, err = fromEnum(selfTradePreventions, o.SelfTradePrevention, "Self Trade Prevention"); err != nil {
+		return order, err
+	}
+
+	decimals := []struct {
+		value string
+		name  string
+		dest  *orderbook.Decimal
+	}{
+		{o.Price, "Price", &order.Price},
+		{o.Amount, "Amount", &order.Amount},
+		{o.StopPrice, "Stop Price", &order.StopPrice},
+		{o.PeakAmount, "Peak Amount", &order.PeakAmount},
+		{o.MaxNotional, "Max Notional", &order.MaxNotional},
+	}
+	for _, d := range decimals {
+		if *d.dest, err = fromDecimal(d.value, d.name); err != nil {
+			return order, err
+		}
+	}
+	return order, nil
+}
+
+// decimalProto formats a decimal field, empty if it is zero.
+func decimalProto(d orderbook.Decimal) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func orderProto(order orderbook.Order) *orderbookpb.Order {
+	o := &orderbookpb.Order{
+		Id:                  order.ID,
+		Type:                protoOrderTypes[order.Type],
+		Side:                protoSides[order.Side],
+		Price:               order.Price.String(),
+		Amount:              order.Amount.String(),
+		Account:             order.Account,
+		SelfTradePrevention: protoSelfTradePreventions[order.SelfTradePrevention],
+		StopPrice:           decimalProto(order.StopPrice),
+		TimeInForce:         protoTimeInForces[order.TimeInForce],
+		PostOnly:            order.PostOnly,
+		PostOnlySlide:       order.PostOnlySlide,
+		PeakAmount:          decimalProto(order.PeakAmount),
+		MaxSlippageBps:      order.MaxSlippageBps,
+		MaxNotional:         decimalProto(order.MaxNotional),
+	}
+	if order.ExpireAt != nil {
+		o.ExpireAt = timestamppb.New(*order.ExpireAt)
+	}
+	return o
+}
+
+func tradeProto(t *orderbook.Trade) *orderbookpb.Trade {
+	return &orderbookpb.Trade{
+		Id:            t.ID,
+		Seq:           t.Seq,
+		Symbol:        t.Symbol,
+		Timestamp:     timestamppb.New(t.Timestamp),
+		BuyOrderId:    t.BuyOrderID,
+		SellOrderId:   t.SellOrderID,
+		MakerOrderId:  t.MakerOrderID,
+		TakerOrderId:  t.TakerOrderID,
+		AggressorSide: protoSides[t.AggressorSide],
+		Price:         t.Price.String(),
+		Amount:        t.Amount.String(),
+		MakerFee:      t.MakerFee.String(),
+		TakerFee:      t.TakerFee.String(),
+		FeeCurrency:   t.FeeCurrency,
+	}
+}
+
+func publicTradeProto(t feedTrade) *orderbookpb.PublicTrade {
+	return &orderbookpb.PublicTrade{
+		Id:            t.ID,
+		Price:         t.Price.String(),
+		Amount:        t.Amount.String(),
+		AggressorSide: protoSides[t.AggressorSide],
+		Timestamp:     timestamppb.New(t.Timestamp),
+	}
+}
+
+func processResultProto(result *orderbook.ProcessResult) *orderbookpb.ProcessResult {
+	r := &orderbookpb.ProcessResult{
+		OrderId:         result.OrderID,
+		Status:          protoOrderStatuses[result.Status],
+		TimeInForce:     protoTimeInForces[result.TimeInForce],
+		Price:           decimalProto(result.Price),
+		FilledAmount:    result.FilledAmount.String(),
+		RestingAmount:   result.RestingAmount.String(),
+		CancelledAmount: result.CancelledAmount.String(),
+		PreventedAmount: decimalProto(result.PreventedAmount),
+	}
+	for _, t := range result.Trades {
+		r.Trades = append(r.Trades, tradeProto(t))
+	}
+	for _, p := range result.Prevented {
+		r.Prevented = append(r.Prevented, &orderbookpb.PreventedMatch{RestingOrderId: p.RestingOrderID, Amount: p.Amount.String()})
+	}
+	for _, triggered := range result.Triggered {
+		r.Triggered = append(r.Triggered, processResultProto(triggered))
+	}
+	return r
+}
+
+func snapshotProto(snapshot orderbook.OrderBookSnapshot) *orderbookpb.Snapshot {
+	s := &orderbookpb.Snapshot{
+		Time:     timestamppb.New(snapshot.Time),
+		Seq:      snapshot.Seq,
+		Checksum: snapshot.Checksum,
+	}
+	for _, level := range snapshot.Bids {
+		s.Bids = append(s.Bids, levelProto(level.Price, level.TotalAmount, level.OrderCount))
+	}
+	for _, level := range snapshot.Asks {
+		s.Asks = append(s.Asks, levelProto(level.Price, level.TotalAmount, level.OrderCount))
+	}
+	return s
+}
+
+func depthProto(depth feedDepth, snapshot bool) *orderbookpb.DepthUpdate {
+	update := &orderbookpb.DepthUpdate{Snapshot: snapshot, Checksum: depth.Checksum}
+	for _, level := range depth.Bids {
+		update.Bids = append(update.Bids, levelProto(level.Price, level.Amount, level.Orders))
+	}
+	for _, level := range depth.Asks {
+		update.Asks = append(update.Asks, levelProto(level.Price, level.Amount, level.Orders))
+	}
+	return update
+}
+
+func levelProto(price, amount orderbook.Decimal, orders int) *orderbookpb.Level {
+	return &orderbookpb.Level{Price: price.String(), Amount: amount.String(), Orders: int32(orders)}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"orderbook/internal/risk"
+)
+
+// Handler for GetRiskLimits function
+func (h *Handler) GetRiskLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limits, err := h.exchange.RiskLimits(r.PathValue("symbol"))
+	if err != nil {
+		http.Error(w, err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(limits); err != nil {
+		http.Error(w, "Error Encoding Response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// Handler for SetRiskLimits function
+func (h *Handler) SetRiskLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var limits risk.Limits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		http.Error(w, "Invalid Request Body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.exchange.SetRiskLimits(r.PathValue("symbol"), limits); err != nil {
+		http.Error(w, err.Error(), marketErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Risk rejections of the book are answered with their reason as JSON so
+// clients can tell which limit stopped the order, other book errors as
+// errorStatus says
+func writeRiskError(w http.ResponseWriter, err error) {
+	var rejection *risk.Rejection
+	if !errors.As(err, &rejection) {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(rejection)
+}
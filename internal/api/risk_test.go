@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+	"strings"
+	"testing"
+)
+
+func TestRiskRoutes(t *testing.T) {
+	ex, _ := exchange.New()
+	ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	mux := NewRouter(NewHandler(ex)).SetupRoutes()
+
+	tests := []struct {
+		name         string
+		method       string
+		url          string
+		body         string
+		expectedCode int
+	}{
+		{"Set Limits", "PUT", "/markets/BTC-USD/risk", `{"default": {"max_quantity": "5", "max_open_orders": 1}, "accounts": {"mm": {"max_open_orders": 10}}}`, http.StatusOK},
+		{"Set Negative Limits", "PUT", "/markets/BTC-USD/risk", `{"default": {"max_quantity": "-5"}}`, http.StatusBadRequest},
+		{"Set Unknown Market", "PUT", "/markets/ETH-USD/risk", `{}`, http.StatusNotFound},
+		{"Get Limits", "GET", "/markets/BTC-USD/risk", "", http.StatusOK},
+		{"Place Within Limits", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "100", "amount": "1", "account": "alice"}`, http.StatusCreated},
+		{"Place Too Many", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "99", "amount": "1", "account": "alice"}`, http.StatusUnprocessableEntity},
+		{"Place Account Limit", "POST", "/markets/BTC-USD/orders/place", `{"side": "BUY", "price": "99", "amount": "1", "account": "mm"}`, http.StatusCreated},
+		{"Process Too Large", "POST", "/markets/BTC-USD/orders/process", `{"side": "SELL", "price": "100", "amount": "6", "account": "bob"}`, http.StatusUnprocessableEntity},
+		{"Modify Unknown Order", "PATCH", "/markets/BTC-USD/orders/modify?id=missing&price=100&amount=6", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			mux.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d: %s", tt.expectedCode, w.Code, w.Body)
+			}
+		})
+	}
+
+	// Rejections carry the rule that stopped the order
+	req := httptest.NewRequest(http.MethodPost, "/markets/BTC-USD/orders/process", strings.NewReader(`{"side": "SELL", "price": "100", "amount": "6"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	var rejection risk.Rejection
+	if err := json.Unmarshal(w.Body.Bytes(), &rejection); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	expected := risk.Rejection{Rule: risk.RuleMaxQuantity, Reason: "Order quantity is above the limit", Limit: "5", Value: "6"}
+	if rejection != expected {
+		t.Errorf("Expected %+v, got %+v", expected, rejection)
+	}
+}
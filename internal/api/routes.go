@@ -18,16 +18,54 @@ func (r *Router) SetupRoutes() *http.ServeMux {
 	mux := http.NewServeMux()
 	prefix := "" // API versioning maybe?
 
+	// Market registry endpoints
+	mux.HandleFunc("GET "+prefix+"/markets", r.handler.ListMarkets)
+	mux.HandleFunc("POST "+prefix+"/markets", r.handler.CreateMarket)
+	mux.HandleFunc(prefix+"/markets/{symbol}", r.handler.DeleteMarket)
+	mux.HandleFunc(prefix+"/markets/{symbol}/halt", r.handler.HaltMarket)
+	mux.HandleFunc(prefix+"/markets/{symbol}/resume", r.handler.ResumeMarket)
+	mux.HandleFunc(prefix+"/markets/{symbol}/close", r.handler.CloseMarket)
+
 	// Order management endpoints
-	mux.HandleFunc(prefix+"/orders/place", r.handler.PlaceOrder)
-	mux.HandleFunc(prefix+"/orders/cancel", r.handler.CancelOrder)
-	mux.HandleFunc(prefix+"/orders/modify", r.handler.ModifyOrder)
-	mux.HandleFunc(prefix+"/orders/process", r.handler.ProcessOrder)
+	market := prefix + "/markets/{symbol}"
+	mux.HandleFunc(market+"/orders/place", r.handler.PlaceOrder)
+	mux.HandleFunc(market+"/orders/cancel", r.handler.CancelOrder)
+	mux.HandleFunc(market+"/orders/modify", r.handler.ModifyOrder)
+	mux.HandleFunc(market+"/orders/process", r.handler.ProcessOrder)
 
 	// Order book query endpoints
-	mux.HandleFunc(prefix+"/orderbook/best-bid", r.handler.GetBestBid)
-	mux.HandleFunc(prefix+"/orderbook/best-ask", r.handler.GetBestAsk)
-	mux.HandleFunc(prefix+"/orderbook/snapshot", r.handler.GetOrderbookSnapshot)
+	mux.HandleFunc(market+"/orderbook/best-bid", r.handler.GetBestBid)
+	mux.HandleFunc(market+"/orderbook/best-ask", r.handler.GetBestAsk)
+	mux.HandleFunc(market+"/orderbook/snapshot", r.handler.GetOrderbookSnapshot)
+
+	// Fee endpoints
+	mux.HandleFunc("GET "+market+"/fees", r.handler.GetFeeSchedule)
+	mux.HandleFunc("PUT "+market+"/fees", r.handler.SetFeeSchedule)
+	mux.HandleFunc(market+"/fees/accounts", r.handler.GetAccountFees)
+
+	// Trading status and price protection endpoints
+	mux.HandleFunc(market+"/status", r.handler.GetMarketStatus)
+	mux.HandleFunc("GET "+market+"/protection", r.handler.GetPriceProtection)
+	mux.HandleFunc("PUT "+market+"/protection", r.handler.SetPriceProtection)
+
+	// Call auction endpoints
+	mux.HandleFunc("GET "+market+"/auction", r.handler.GetIndicative)
+	mux.HandleFunc("POST "+market+"/auction", r.handler.StartAuction)
+	mux.HandleFunc(market+"/auction/uncross", r.handler.Uncross)
+
+	// Risk limit endpoints
+	mux.HandleFunc("GET "+market+"/risk", r.handler.GetRiskLimits)
+	mux.HandleFunc("PUT "+market+"/risk", r.handler.SetRiskLimits)
+
+	// Account balance endpoints
+	account := market + "/accounts/{account}"
+	mux.HandleFunc(account+"/balances", r.handler.GetBalances)
+	mux.HandleFunc(account+"/deposit", r.handler.Deposit)
+	mux.HandleFunc(account+"/withdraw", r.handler.Withdraw)
+
+	// Market data feed and event stream
+	mux.HandleFunc(prefix+"/ws", r.handler.MarketData)
+	mux.HandleFunc(market+"/events", r.handler.StreamEvents)
 
 	return mux
 }
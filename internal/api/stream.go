@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"orderbook/internal/orderbook"
+)
+
+const streamKeepAlive = 15 * time.Second
+
+// streamOrder is an order status change on the event stream, with the
+// order as it stands after it.
+type streamOrder struct {
+	Status orderbook.EventType `json:"status"`
+	Order  streamOrderView     `json:"order"`
+	Amount orderbook.Decimal   `json:"amount,omitempty"` // Filled, cancelled or expired
+	Reason string              `json:"reason,omitempty"` // Why the order was rejected
+}
+
+// streamOrderView is what the stream shows of an order: its amount is only
+// what the book displays of it, so the hidden part of an iceberg stays
+// hidden.
+type streamOrderView struct {
+	ID          string                `json:"id"`
+	Account     string                `json:"account"`
+	Type        orderbook.OrderType   `json:"type,omitempty"`
+	Side        orderbook.Side        `json:"side"`
+	Price       orderbook.Decimal     `json:"price"`
+	StopPrice   orderbook.Decimal     `json:"stop_price,omitempty"`
+	Amount      orderbook.Decimal     `json:"amount"`
+	TimeInForce orderbook.TimeInForce `json:"time_in_force,omitempty"`
+}
+
+func orderView(o *orderbook.Order) streamOrderView {
+	return streamOrderView{
+		ID:          o.ID,
+		Account:     o.Account,
+		Type:        o.Type,
+		Side:        o.Side,
+		Price:       o.Price,
+		StopPrice:   o.StopPrice,
+		Amount:      o.DisplayAmount(),
+		TimeInForce: o.TimeInForce,
+	}
+}
+
+// Handler for StreamEvents function
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	book, ok := h.market(w, r)
+	if !ok {
+		return
+	}
+	account := r.URL.Query().Get("account")
+
+	// Browsers resume with the header, clients that cannot set it use the query
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var sub *orderbook.Subscription
+	var resumeErr error
+	if lastID != "" {
+		seq, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid Last Event ID", http.StatusBadRequest)
+			return
+		}
+		sub, resumeErr = book.SubscribeFrom(seq)
+	}
+	if sub == nil {
+		sub = book.Subscribe()
+	}
+	defer sub.Close()
+
+	// Streams outlive any write timeout of the server
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if resumeErr != nil {
+		// Events were missed, so whatever the client built from them is stale
+		fmt.Fprintf(w, "event: reset\ndata: %s\n\n", resumeErr)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			name, data := streamEvent(event, account)
+			if name == "" {
+				continue
+			}
+			payload, err := json.Marshal(data)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, name, payload)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// streamEvent returns the name and data an event is streamed with, or no
+// name if it is not streamed: trades are streamed as public prints, order
+// events only to a stream for the account that placed the order.
+func streamEvent(event orderbook.Event, account string) (string, any) {
+	switch {
+	case event.Type == orderbook.EventTrade:
+		return "trade", publicTrade(event.Trade)
+	case event.Order != nil && account != "" && event.Order.Account == account:
+		return "order", streamOrder{Status: event.Type, Order: orderView(event.Order), Amount: event.Amount, Reason: event.Reason}
+	}
+	return "", nil
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"strings"
+	"testing"
+	"time"
+)
+
+type streamFrame struct {
+	id, event, data string
+}
+
+// readFrame reads the next event of a stream, skipping comments.
+func readFrame(t *testing.T, r *bufio.Reader) streamFrame {
+	t.Helper()
+	var frame streamFrame
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read from the stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && frame.event != "":
+			return frame
+		case strings.HasPrefix(line, "id: "):
+			frame.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			frame.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			frame.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	ex, _ := exchange.New(exchange.WithEventHistory(100))
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	server := httptest.NewServer(NewRouter(NewHandler(ex)).SetupRoutes())
+	defer server.Close()
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	open := func(url, lastID string) (*http.Response, *bufio.Reader) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, server.URL+url, nil)
+		if lastID != "" {
+			req.Header.Set("Last-Event-ID", lastID)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Failed to open the stream: %v", err)
+		}
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	resp, stream := open("/markets/BTC-USD/events?account=alice", "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	public, publicStream := open("/markets/BTC-USD/events", "")
+	defer public.Body.Close()
+
+	book.PlaceOrder(orderbook.Order{ID: "a1", Account: "alice", Side: orderbook.Buy, Price: dec(100.0), Amount: dec(2.0)})
+	book.ProcessOrder(orderbook.Order{ID: "b1", Account: "bob", Side: orderbook.Sell, Price: dec(100.0), Amount: dec(1.0)})
+	book.CancelOrder("a1")
+	book.PlaceOrder(orderbook.Order{ID: "a2", Account: "alice", Side: orderbook.Buy, Price: dec(99.0), Amount: dec(5.0), PeakAmount: dec(1.0)})
+
+	// Bob's order events are left out
+	expected := []struct {
+		event  string
+		status orderbook.EventType
+	}{
+		{"order", orderbook.EventAccepted},
+		{"trade", ""},
+		{"order", orderbook.EventPartiallyFilled},
+		{"order", orderbook.EventCancelled},
+	}
+	var frames []streamFrame
+	for _, e := range expected {
+		frame := readFrame(t, stream)
+		frames = append(frames, frame)
+		var order streamOrder
+		json.Unmarshal([]byte(frame.data), &order)
+		if frame.event != e.event || order.Status != e.status || (e.event == "order" && order.Order.ID != "a1") {
+			t.Errorf("Expected %s %s, got %+v", e.event, e.status, frame)
+		}
+	}
+
+	// Icebergs only show what the book displays of them
+	frame := readFrame(t, stream)
+	if frame.event != "order" || strings.Contains(frame.data, "peak_amount") || !strings.Contains(frame.data, `"amount":"1"`) {
+		t.Errorf("Expected the displayed amount of the iceberg only, got %+v", frame)
+	}
+
+	// Without an account only trades are streamed
+	if frame := readFrame(t, publicStream); frame != frames[1] {
+		t.Errorf("Expected only the trade without an account, got %+v", frame)
+	}
+
+	// Resuming picks up after the last event received
+	resumed, stream := open("/markets/BTC-USD/events?account=alice", frames[1].id)
+	defer resumed.Body.Close()
+	if frame := readFrame(t, stream); frame != frames[2] {
+		t.Errorf("Expected to resume with %+v, got %+v", frames[2], frame)
+	}
+
+	// Events the history no longer has are reported missed
+	reset, stream := open("/markets/BTC-USD/events?last_event_id=1000", "")
+	defer reset.Body.Close()
+	if frame := readFrame(t, stream); frame.event != "reset" {
+		t.Errorf("Expected a reset, got %+v", frame)
+	}
+
+	errorTests := []struct {
+		name         string
+		url          string
+		lastID       string
+		expectedCode int
+	}{
+		{"Invalid Last Event ID", "/markets/BTC-USD/events", "abc", http.StatusBadRequest},
+		{"Unknown Market", "/markets/ETH-USD/events", "", http.StatusNotFound},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := open(tt.url, tt.lastID)
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedCode {
+				t.Errorf("Expected status code %d, got %d", tt.expectedCode, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+// Package exchange runs many order books side by side, one per market.
+package exchange
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"orderbook/internal/journal"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+var (
+	ErrMarketExists   = errors.New("Market already exists")
+	ErrMarketNotFound = errors.New("Market not found")
+	ErrInvalidSymbol  = errors.New("Invalid market symbol")
+	ErrMarketActive   = errors.New("Market must be halted or closed before it is deleted")
+)
+
+// Symbols are used in URLs and directory names, so keep them simple.
+var symbolPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,31}$`)
+
+// MarketInfo describes a market of the exchange.
+type MarketInfo struct {
+	Symbol     string                   `json:"symbol"`
+	ID         string                   `json:"id"`
+	Halted     bool                     `json:"halted"`
+	Status     orderbook.BookStatus     `json:"status"`
+	Instrument orderbook.InstrumentSpec `json:"instrument"`
+}
+
+// market is an order book together with its persistence.
+type market struct {
+	book *orderbook.OrderBook
+	wal  *journal.Journal // nil for in-memory exchanges
+	dir  string
+}
+
+// Exchange owns the order books of every market, keyed by symbol. Each book's
+// Tag is its symbol.
+type Exchange struct {
+	mu      sync.RWMutex
+	markets map[string]*market
+
+	dir      string // Data directory, empty to keep everything in memory
+	policy   journal.SyncPolicy
+	balances bool         // Whether markets hold account balances
+	history  int          // Events each market keeps for resuming subscriptions
+	risk     *risk.Engine // Pre-trade checks, with limits per market
+}
+
+// Option configures an Exchange at construction time.
+type Option func(*Exchange)
+
+// WithDataDir persists every market under dir: the market registry, and a
+// journal and snapshots per market, fsynced according to policy.
+func WithDataDir(dir string, policy journal.SyncPolicy) Option {
+	return func(e *Exchange) {
+		e.dir = dir
+		e.policy = policy
+	}
+}
+
+// WithBalances makes every market hold the funds of its accounts, so that
+// orders must be covered by a balance deposited in that market.
+func WithBalances() Option {
+	return func(e *Exchange) {
+		e.balances = true
+	}
+}
+
+// WithEventHistory makes every market keep its last n events, so that
+// subscribers can resume from them.
+func WithEventHistory(n int) Option {
+	return func(e *Exchange) {
+		e.history = n
+	}
+}
+
+// WithRiskRules checks every order of every market against rules, on top of
+// the risk limits set per market.
+func WithRiskRules(rules ...risk.Rule) Option {
+	return func(e *Exchange) {
+		e.risk = risk.NewEngine(rules...)
+	}
+}
+
+// New creates an exchange. With a data directory, every market it lists is
+// recovered before New returns.
+func New(opts ...Option) (*Exchange, error) {
+	e := &Exchange{
+		markets: make(map[string]*market),
+		risk:    risk.NewEngine(),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	if e.dir != "" {
+		if err := e.load(); err != nil {
+			e.Close()
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// CreateMarket opens a new, empty market trading the instrument described by
+// spec. Returns orderbook.ErrInvalidInstrument if the spec is inconsistent.
+func (e *Exchange) CreateMarket(symbol string, spec orderbook.InstrumentSpec) (*orderbook.OrderBook, error) {
+	if !symbolPattern.MatchString(symbol) {
+		return nil, ErrInvalidSymbol
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.markets[symbol]; ok {
+		return nil, ErrMarketExists
+	}
+
+	m, err := e.openMarket(symbol, "", spec)
+	if err != nil {
+		return nil, err
+	}
+	e.markets[symbol] = m
+
+	if err := e.saveRegistry(); err != nil {
+		delete(e.markets, symbol)
+		m.close()
+		return nil, err
+	}
+	return m.book, nil
+}
+
+// Market returns the book of a market.
+func (e *Exchange) Market(symbol string) (*orderbook.OrderBook, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	m, ok := e.markets[symbol]
+	if !ok {
+		return nil, ErrMarketNotFound
+	}
+	return m.book, nil
+}
+
+// Markets lists every market ordered by symbol.
+func (e *Exchange) Markets() []MarketInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	markets := make([]MarketInfo, 0, len(e.markets))
+	for symbol, m := range e.markets {
+		markets = append(markets, MarketInfo{
+			Symbol:     symbol,
+			ID:         m.book.ID,
+			Halted:     m.book.Halted(),
+			Status:     m.book.Status(),
+			Instrument: m.book.Instrument(),
+		})
+	}
+	sort.Slice(markets, func(i, j int) bool { return markets[i].Symbol < markets[j].Symbol })
+	return markets
+}
+
+// HaltMarket stops trading in a market. Orders can still be cancelled.
+func (e *Exchange) HaltMarket(symbol string) error {
+	book, err := e.Market(symbol)
+	if err != nil {
+		return err
+	}
+	return book.Halt()
+}
+
+// ResumeMarket lifts the halt of a market.
+func (e *Exchange) ResumeMarket(symbol string) error {
+	book, err := e.Market(symbol)
+	if err != nil {
+		return err
+	}
+	return book.Resume()
+}
+
+// CloseMarket closes a market until it is resumed. Orders can still be
+// cancelled.
+func (e *Exchange) CloseMarket(symbol string) error {
+	book, err := e.Market(symbol)
+	if err != nil {
+		return err
+	}
+	return book.Close()
+}
+
+// DeleteMarket removes a halted or closed market together with its orders
+// and data, and ends the subscriptions to its events.
+func (e *Exchange) DeleteMarket(symbol string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	m, ok := e.markets[symbol]
+	if !ok {
+		return ErrMarketNotFound
+	}
+	if status := m.book.Status(); status != orderbook.BookHalted && status != orderbook.BookClosed {
+		return ErrMarketActive
+	}
+
+	delete(e.markets, symbol)
+	if err := e.saveRegistry(); err != nil {
+		e.markets[symbol] = m
+		return err
+	}
+	e.risk.RemoveLimits(symbol)
+	m.book.EndSubscriptions()
+	if err := m.close(); err != nil {
+		log.Printf("Failed to close the journal of market %s: %v", symbol, err)
+	}
+	return m.remove()
+}
+
+// RunExpirySweeper expires GTD orders in every market each interval until
+// ctx is done, moving markets on from circuit breaker halts and auctions
+// that are due.
+func (e *Exchange) RunExpirySweeper(ctx context.Context, interval time.Duration) {
+	e.every(ctx, interval, func(symbol string, m *market) {
+		m.book.ExpireOrders(time.Now())
+	})
+}
+
+// every calls fn for each market every interval until ctx is done.
+func (e *Exchange) every(ctx context.Context, interval time.Duration, fn func(string, *market)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.mu.RLock()
+			markets := make(map[string]*market, len(e.markets))
+			for symbol, m := range e.markets {
+				markets[symbol] = m
+			}
+			e.mu.RUnlock()
+
+			for symbol, m := range markets {
+				fn(symbol, m)
+			}
+		}
+	}
+}
+
+// Close closes the journals of every market.
+func (e *Exchange) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var errs []error
+	for _, m := range e.markets {
+		errs = append(errs, m.close())
+	}
+	return errors.Join(errs...)
+}
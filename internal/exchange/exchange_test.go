@@ -0,0 +1,265 @@
+package exchange
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"orderbook/internal/journal"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+func TestExchange_Markets(t *testing.T) {
+	ex, _ := New()
+
+	btc, err := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	if err != nil {
+		t.Fatalf("Failed to create market: %v", err)
+	}
+	if btc.Tag != "BTC-USD" || btc.ID == "" {
+		t.Errorf("Expected a book tagged with its symbol, got %q/%q", btc.Tag, btc.ID)
+	}
+	ex.CreateMarket("ETH-USD", orderbook.DefaultInstrumentSpec())
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"Duplicate", second(ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())), ErrMarketExists},
+		{"Empty symbol", second(ex.CreateMarket("", orderbook.DefaultInstrumentSpec())), ErrInvalidSymbol},
+		{"Path symbol", second(ex.CreateMarket("../etc", orderbook.DefaultInstrumentSpec())), ErrInvalidSymbol},
+		{"Invalid instrument", second(ex.CreateMarket("XRP-USD", orderbook.InstrumentSpec{PricePrecision: 9})), orderbook.ErrInvalidInstrument},
+		{"Unknown market", second(ex.Market("DOGE-USD")), ErrMarketNotFound},
+		{"Halt unknown", ex.HaltMarket("DOGE-USD"), ErrMarketNotFound},
+		{"Delete open market", ex.DeleteMarket("ETH-USD"), ErrMarketActive},
+	}
+	for _, tt := range tests {
+		if tt.err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.err)
+		}
+	}
+
+	// Books are independent
+	btc.PlaceOrder(orderbook.Order{Price: dec(100.0), Amount: dec(1.0), Side: orderbook.Buy})
+	eth, _ := ex.Market("ETH-USD")
+	if _, err := eth.GetBestBid(); err != orderbook.ErrNoOrders {
+		t.Errorf("Expected no bids on ETH-USD, got %v", err)
+	}
+
+	ex.HaltMarket("ETH-USD")
+	expected := []MarketInfo{
+		{Symbol: "BTC-USD", ID: btc.ID, Status: orderbook.BookOpen, Instrument: orderbook.DefaultInstrumentSpec()},
+		{Symbol: "ETH-USD", ID: eth.ID, Halted: true, Status: orderbook.BookHalted, Instrument: orderbook.DefaultInstrumentSpec()},
+	}
+	if markets := ex.Markets(); !reflect.DeepEqual(markets, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, markets)
+	}
+
+	// Deleting a market ends the subscriptions to it
+	sub := eth.Subscribe()
+	defer sub.Close()
+	if err := ex.DeleteMarket("ETH-USD"); err != nil {
+		t.Fatalf("Failed to delete market: %v", err)
+	}
+	if len(ex.Markets()) != 1 {
+		t.Errorf("Expected one market left, got %+v", ex.Markets())
+	}
+	select {
+	case _, ok := <-sub.C:
+		if ok || sub.Err() != orderbook.ErrSubscriptionEnded {
+			t.Errorf("Expected the subscription ended, got %v", sub.Err())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the subscription to the deleted market to be closed")
+	}
+}
+
+func TestExchange_Persistence(t *testing.T) {
+	dir := t.TempDir()
+	ex, err := New(WithDataDir(dir, journal.SyncNever))
+	if err != nil {
+		t.Fatalf("Failed to open exchange: %v", err)
+	}
+
+	spec := orderbook.InstrumentSpec{PricePrecision: 2, AmountPrecision: 4, TickSize: dec(0.5), MinNotional: dec(10.0),
+		Matching: &orderbook.MatchingSpec{Algorithm: orderbook.MatchingProRata, MinAllocation: dec(1.0)}}
+	btc, _ := ex.CreateMarket("BTC-USD", spec)
+	eth, _ := ex.CreateMarket("ETH-USD", orderbook.DefaultInstrumentSpec())
+	old, _ := ex.CreateMarket("OLD", orderbook.DefaultInstrumentSpec())
+	btc.PlaceOrder(orderbook.Order{ID: "bid", Price: dec(100.0), Amount: dec(2.0), Side: orderbook.Buy})
+	ex.markets["BTC-USD"].snapshot()
+	btc.ProcessOrder(orderbook.Order{ID: "ask", Price: dec(100.0), Amount: dec(0.5), Side: orderbook.Sell})
+	eth.PlaceOrder(orderbook.Order{ID: "eth", Price: dec(10.0), Amount: dec(1.0), Side: orderbook.Sell})
+	ex.HaltMarket("ETH-USD")
+	old.Halt()
+	ex.DeleteMarket("OLD")
+	ex.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, marketsDir, "OLD")); !os.IsNotExist(err) {
+		t.Errorf("Expected the deleted market's data to be removed, got %v", err)
+	}
+
+	ex, err = New(WithDataDir(dir, journal.SyncNever))
+	if err != nil {
+		t.Fatalf("Failed to reopen exchange: %v", err)
+	}
+	defer ex.Close()
+
+	expected := []MarketInfo{
+		{Symbol: "BTC-USD", ID: btc.ID, Status: orderbook.BookOpen, Instrument: spec},
+		{Symbol: "ETH-USD", ID: eth.ID, Halted: true, Status: orderbook.BookHalted, Instrument: orderbook.DefaultInstrumentSpec()},
+	}
+	if markets := ex.Markets(); !reflect.DeepEqual(markets, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, markets)
+	}
+	recovered, _ := ex.Market("BTC-USD")
+	if bid, err := recovered.GetBestBid(); err != nil || bid.ID != "bid" || bid.Amount != dec(1.5) {
+		t.Errorf("Expected bid with 1.5 left, got %+v, %v", bid, err)
+	}
+	if err := recovered.PlaceOrder(orderbook.Order{Price: dec(100.1), Amount: dec(1.0), Side: orderbook.Buy}); err != orderbook.ErrInvalidTickSize {
+		t.Errorf("Expected the recovered market to keep its tick size, got %v", err)
+	}
+	recoveredEth, _ := ex.Market("ETH-USD")
+	if ask, err := recoveredEth.GetBestAsk(); err != nil || ask.ID != "eth" {
+		t.Errorf("Expected eth ask, got %+v, %v", ask, err)
+	}
+}
+
+func TestExchange_RunSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	ex, _ := New(WithDataDir(dir, journal.SyncNever))
+	defer ex.Close()
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	book.PlaceOrder(orderbook.Order{Price: dec(100.0), Amount: dec(1.0), Side: orderbook.Buy})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ex.RunSnapshots(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	path := filepath.Join(dir, marketsDir, "BTC-USD", snapshotFile)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a snapshot to be written")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
+
+func dec(f float64) orderbook.Decimal {
+	return orderbook.DecimalFromFloat(f)
+}
+
+func TestExchange_Balances(t *testing.T) {
+	ex, _ := New(WithBalances())
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+
+	order := orderbook.Order{Price: dec(100.0), Amount: dec(1.0), Side: orderbook.Buy, Account: "alice"}
+	if err := book.PlaceOrder(order); err != orderbook.ErrInsufficientBalance {
+		t.Errorf("Expected ErrInsufficientBalance, got %v", err)
+	}
+	book.Deposit("alice", orderbook.Quote, dec(100.0))
+	if err := book.PlaceOrder(order); err != nil {
+		t.Errorf("Failed to place funded order: %v", err)
+	}
+}
+
+func TestExchange_RiskLimits(t *testing.T) {
+	dir := t.TempDir()
+	ex, _ := New(WithDataDir(dir, journal.SyncNever))
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+
+	limits := risk.Limits{Default: risk.Limit{MaxQuantity: dec(5.0)}}
+	if err := ex.SetRiskLimits("BTC-USD", limits); err != nil {
+		t.Fatalf("Failed to set limits: %v", err)
+	}
+	if err := ex.SetRiskLimits("DOGE-USD", limits); err != ErrMarketNotFound {
+		t.Errorf("Expected ErrMarketNotFound, got %v", err)
+	}
+
+	// The book runs the checks, and rejected orders are not journaled
+	_, err := book.ProcessOrder(orderbook.Order{Price: dec(100.0), Amount: dec(6.0), Side: orderbook.Buy})
+	if rejection, ok := err.(*risk.Rejection); !ok || rejection.Rule != risk.RuleMaxQuantity {
+		t.Errorf("Expected a MAX_QUANTITY rejection, got %v", err)
+	}
+
+	// Modifications are checked as the order would be afterwards
+	book.PlaceOrder(orderbook.Order{ID: "bid", Price: dec(100.0), Amount: dec(1.0), Side: orderbook.Buy})
+	if err := book.ModifyOrder("bid", dec(100.0), dec(6.0)); err == nil {
+		t.Error("Expected the modification to be rejected")
+	}
+	if err := book.ModifyOrder("bid", dec(100.0), dec(2.0)); err != nil {
+		t.Errorf("Expected the modification to pass, got %v", err)
+	}
+
+	// Orders the limits let through are replayed even under tighter ones
+	if err := ex.SetRiskLimits("BTC-USD", risk.Limits{Default: risk.Limit{MaxQuantity: dec(1.0)}}); err != nil {
+		t.Fatalf("Failed to set limits: %v", err)
+	}
+	ex.Close()
+	ex, err = New(WithDataDir(dir, journal.SyncNever))
+	if err != nil {
+		t.Fatalf("Failed to reopen exchange: %v", err)
+	}
+	book, _ = ex.Market("BTC-USD")
+	if order, err := book.GetOrder("bid"); err != nil || order.Amount != dec(2.0) {
+		t.Errorf("Expected the modified bid after restart, got %+v, %v", order, err)
+	}
+	if err := ex.SetRiskLimits("BTC-USD", limits); err != nil {
+		t.Fatalf("Failed to set limits: %v", err)
+	}
+	ex.Close()
+
+	ex, err = New(WithDataDir(dir, journal.SyncNever))
+	if err != nil {
+		t.Fatalf("Failed to reopen exchange: %v", err)
+	}
+	defer ex.Close()
+	if recovered, _ := ex.RiskLimits("BTC-USD"); !reflect.DeepEqual(recovered, limits) {
+		t.Errorf("Expected limits %+v after restart, got %+v", limits, recovered)
+	}
+}
+
+func TestExchange_RiskChecksUnderLock(t *testing.T) {
+	ex, _ := New()
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	ex.SetRiskLimits("BTC-USD", risk.Limits{Default: risk.Limit{MaxOpenOrders: 5, MaxPosition: dec(100.0)}})
+
+	// Orders sent at once are each checked with the ones before them
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if book.PlaceOrder(orderbook.Order{Price: dec(100.0), Amount: dec(1.0), Side: orderbook.Buy, Account: "alice"}) == nil {
+				accepted.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if accepted.Load() != 5 || book.OpenOrders("alice") != 5 {
+		t.Errorf("Expected 5 orders accepted, got %d with %d open", accepted.Load(), book.OpenOrders("alice"))
+	}
+}
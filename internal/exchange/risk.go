@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+// RiskLimits returns the risk limits of a market.
+func (e *Exchange) RiskLimits(symbol string) (risk.Limits, error) {
+	if _, err := e.Market(symbol); err != nil {
+		return risk.Limits{}, err
+	}
+	return e.risk.Limits(symbol), nil
+}
+
+// SetRiskLimits replaces the risk limits of a market and records them in the
+// registry. Returns risk.ErrInvalidLimits if any limit is negative.
+func (e *Exchange) SetRiskLimits(symbol string, limits risk.Limits) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, ok := e.markets[symbol]; !ok {
+		return ErrMarketNotFound
+	}
+
+	previous := e.risk.Limits(symbol)
+	if err := e.risk.SetLimits(symbol, limits); err != nil {
+		return err
+	}
+	if err := e.saveRegistry(); err != nil {
+		e.risk.SetLimits(symbol, previous)
+		return err
+	}
+	return nil
+}
+
+// checkOrder returns the pre-trade check of the book of a market, which runs
+// its risk checks on every new and modified order. The book runs it under
+// its lock, so orders sent at the same time by an account are each checked
+// with the others that got in first. A failed check returns a
+// *risk.Rejection from the book.
+func (e *Exchange) checkOrder(symbol string) orderbook.PreTradeCheck {
+	return func(order *orderbook.Order, old *orderbook.Order, view orderbook.PreTradeView) error {
+		return e.risk.Check(symbol, view, order, old)
+	}
+}
@@ -0,0 +1,239 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"orderbook/internal/journal"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+const (
+	registryFile = "markets.json"
+	marketsDir   = "markets"
+	journalDir   = "journal"
+	snapshotFile = "snapshot.bin"
+)
+
+// registryEntry is how a market is listed in the registry file. Markets
+// registered before instruments existed have none and get the default spec.
+type registryEntry struct {
+	Symbol     string                    `json:"symbol"`
+	ID         string                    `json:"id"`
+	Instrument *orderbook.InstrumentSpec `json:"instrument,omitempty"`
+	Risk       *risk.Limits              `json:"risk,omitempty"`
+}
+
+// load recovers every market listed in the registry.
+func (e *Exchange) load() error {
+	if err := os.MkdirAll(filepath.Join(e.dir, marketsDir), 0o755); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(filepath.Join(e.dir, registryFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []registryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("reading %s: %w", registryFile, err)
+	}
+	for _, entry := range entries {
+		spec := orderbook.DefaultInstrumentSpec()
+		if entry.Instrument != nil {
+			spec = *entry.Instrument
+		}
+		m, err := e.openMarket(entry.Symbol, entry.ID, spec)
+		if err != nil {
+			return fmt.Errorf("recovering market %s: %w", entry.Symbol, err)
+		}
+		e.markets[entry.Symbol] = m
+		if entry.Risk != nil {
+			if err := e.risk.SetLimits(entry.Symbol, *entry.Risk); err != nil {
+				return fmt.Errorf("recovering market %s: %w", entry.Symbol, err)
+			}
+		}
+	}
+	return nil
+}
+
+// saveRegistry atomically rewrites the registry file.
+// Must be called with the lock held.
+func (e *Exchange) saveRegistry() error {
+	if e.dir == "" {
+		return nil
+	}
+
+	entries := make([]registryEntry, 0, len(e.markets))
+	for symbol, m := range e.markets {
+		spec := m.book.Instrument()
+		limits := e.risk.Limits(symbol)
+		entries = append(entries, registryEntry{Symbol: symbol, ID: m.book.ID, Instrument: &spec, Risk: &limits})
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(e.dir, registryFile), func(f *os.File) error {
+		_, err := f.Write(data)
+		return err
+	})
+}
+
+// openMarket creates the book of a market, recovering its persisted state.
+// An empty id marks a brand new market whose ID is generated.
+func (e *Exchange) openMarket(symbol, id string, spec orderbook.InstrumentSpec) (*market, error) {
+	if e.dir == "" {
+		m := &market{book: orderbook.NewOrderBook(symbol, e.bookOptions(symbol, spec)...)}
+		if id != "" {
+			m.book.ID = id
+		}
+		return m, nil
+	}
+
+	dir := filepath.Join(e.dir, marketsDir, symbol)
+	if id == "" {
+		// Leftovers of a market that was never registered or was deleted
+		if err := os.RemoveAll(dir); err != nil {
+			return nil, err
+		}
+	}
+
+	wal, err := journal.Open(filepath.Join(dir, journalDir), journal.WithSyncPolicy(e.policy))
+	if err != nil {
+		return nil, err
+	}
+	m := &market{book: orderbook.NewOrderBook(symbol, append(e.bookOptions(symbol, spec), orderbook.WithJournal(wal))...), wal: wal, dir: dir}
+	if id != "" {
+		m.book.ID = id
+	}
+	if err := m.recover(); err != nil {
+		wal.Close()
+		return nil, err
+	}
+	return m, nil
+}
+
+// bookOptions returns how the book of a market is configured.
+func (e *Exchange) bookOptions(symbol string, spec orderbook.InstrumentSpec) []orderbook.Option {
+	opts := []orderbook.Option{orderbook.WithInstrument(spec), orderbook.WithPreTradeCheck(e.checkOrder(symbol))}
+	if e.balances {
+		opts = append(opts, orderbook.WithBalances())
+	}
+	if e.history > 0 {
+		opts = append(opts, orderbook.WithEventHistory(e.history))
+	}
+	return opts
+}
+
+// recover loads the latest snapshot of the market, if there is one, and
+// replays the journal records written after it.
+func (m *market) recover() error {
+	var lsn uint64
+	file, err := os.Open(filepath.Join(m.dir, snapshotFile))
+	switch {
+	case err == nil:
+		lsn, err = m.book.LoadSnapshot(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("loading snapshot: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if first := m.wal.FirstLSN(); first > lsn+1 {
+		return fmt.Errorf("journal starts at record %d but the snapshot only covers up to %d", first, lsn)
+	}
+	if err := m.wal.Replay(lsn, m.book.Replay); err != nil {
+		return fmt.Errorf("replaying journal: %w", err)
+	}
+	return nil
+}
+
+// snapshot atomically replaces the snapshot of the market, then drops the
+// journal segments it makes redundant.
+func (m *market) snapshot() error {
+	if m.wal == nil {
+		return nil
+	}
+
+	var lsn uint64
+	err := writeFileAtomic(filepath.Join(m.dir, snapshotFile), func(f *os.File) error {
+		var err error
+		lsn, err = m.book.WriteSnapshot(f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return m.wal.Compact(lsn)
+}
+
+func (m *market) close() error {
+	if m.wal == nil {
+		return nil
+	}
+	return m.wal.Close()
+}
+
+// remove deletes the persisted data of the market.
+func (m *market) remove() error {
+	if m.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(m.dir)
+}
+
+// RunSnapshots snapshots every market and compacts its journal each interval
+// until ctx is done.
+func (e *Exchange) RunSnapshots(ctx context.Context, interval time.Duration) {
+	e.every(ctx, interval, func(symbol string, m *market) {
+		if err := m.snapshot(); err != nil {
+			log.Printf("Failed to snapshot market %s: %v", symbol, err)
+		}
+	})
+}
+
+// writeFileAtomic writes path through a synced temporary file renamed over it.
+func writeFileAtomic(path string, write func(*os.File) error) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	err = write(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,232 @@
+package fix
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"net"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"orderbook/internal/exchange"
+)
+
+var ErrAcceptorClosed = errors.New("FIX acceptor is closed")
+
+const (
+	DefaultCompID = "ORDERBOOK"
+
+	logonTimeout  = 10 * time.Second
+	writeTimeout  = 5 * time.Second
+	maxHeartBtInt = 3600 // Seconds
+)
+
+// Comp IDs name the directory of a session, so keep them simple.
+var compIDPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Acceptor accepts FIX sessions and routes their orders to the markets of
+// an exchange, the Symbol of an order naming its market. Each client is a
+// session named after its SenderCompID, whose sequence numbers and
+// messages are persisted under the directory of the acceptor. Sessions
+// live on while their client is logged out: execution reports for its
+// orders are kept and can be resent when it logs on again.
+type Acceptor struct {
+	exchange *exchange.Exchange
+	dir      string
+	compID   string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	sessions  map[string]*session
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+// Option configures an Acceptor at construction time.
+type Option func(*Acceptor)
+
+// WithCompID sets the comp ID of the acceptor, which clients must send as
+// TargetCompID. Defaults to DefaultCompID.
+func WithCompID(id string) Option {
+	return func(a *Acceptor) {
+		a.compID = id
+	}
+}
+
+// WithClock replaces the clock that stamps sent messages.
+func WithClock(now func() time.Time) Option {
+	return func(a *Acceptor) {
+		a.now = now
+	}
+}
+
+// NewAcceptor creates an acceptor for the markets of an exchange, keeping
+// its sessions under dir.
+func NewAcceptor(ex *exchange.Exchange, dir string, opts ...Option) *Acceptor {
+	a := &Acceptor{
+		exchange:  ex,
+		dir:       dir,
+		compID:    DefaultCompID,
+		now:       time.Now,
+		sessions:  make(map[string]*session),
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// ListenAndServe listens on the TCP address addr and serves FIX sessions
+// until the acceptor is closed.
+func (a *Acceptor) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return a.Serve(l)
+}
+
+// Serve accepts connections on l until the acceptor is closed, when it
+// returns ErrAcceptorClosed.
+func (a *Acceptor) Serve(l net.Listener) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		l.Close()
+		return ErrAcceptorClosed
+	}
+	a.listeners[l] = struct{}{}
+	a.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			a.mu.Lock()
+			closed := a.closed
+			a.mu.Unlock()
+			if closed {
+				return ErrAcceptorClosed
+			}
+			return err
+		}
+		if !a.track(conn) {
+			conn.Close()
+			return ErrAcceptorClosed
+		}
+		go a.handle(conn)
+	}
+}
+
+func (a *Acceptor) track(conn net.Conn) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return false
+	}
+	a.conns[conn] = struct{}{}
+	return true
+}
+
+func (a *Acceptor) untrack(conn net.Conn) {
+	a.mu.Lock()
+	delete(a.conns, conn)
+	a.mu.Unlock()
+}
+
+// Close stops accepting connections, disconnects every client and closes
+// the sessions.
+func (a *Acceptor) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	listeners, conns := a.listeners, a.conns
+	a.listeners, a.conns = nil, nil
+	sessions := a.sessions
+	a.sessions = nil
+	a.mu.Unlock()
+
+	for l := range listeners {
+		l.Close()
+	}
+	for conn := range conns {
+		conn.Close()
+	}
+	var err error
+	for _, s := range sessions {
+		err = errors.Join(err, s.close())
+	}
+	return err
+}
+
+// handle runs a connection: a Logon first, then the messages of the session
+// until either side logs out or the connection drops. A panic only ends the
+// connection it happened on.
+func (a *Acceptor) handle(conn net.Conn) {
+	defer a.untrack(conn)
+	defer conn.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("FIX connection %s: panic: %v\n%s", conn.RemoteAddr(), r, debug.Stack())
+		}
+	}()
+
+	r := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(logonTimeout))
+	logon, err := readMessage(r)
+	if err != nil || logon.msgType() != msgLogon {
+		return
+	}
+	sender := logon.str(tagSenderCompID)
+	heartbeat, err := logon.int(tagHeartBtInt)
+	if !compIDPattern.MatchString(sender) || logon.str(tagTargetCompID) != a.compID || err != nil || heartbeat == 0 || heartbeat > maxHeartBtInt {
+		return
+	}
+
+	s, err := a.session(sender)
+	if err != nil {
+		log.Printf("FIX session %s: %v", sender, err)
+		return
+	}
+	c := newConnection(conn, time.Duration(heartbeat)*time.Second)
+	if !s.logon(c, logon) {
+		return
+	}
+	defer s.disconnect(c)
+	defer s.recover(c)
+
+	go s.keepAlive(c)
+	for {
+		conn.SetReadDeadline(time.Now().Add(c.timeout()))
+		m, err := readMessage(r)
+		if err != nil {
+			return
+		}
+		c.received()
+		if !s.receive(c, m) {
+			return
+		}
+	}
+}
+
+// session returns the session of a client, opening it on its first logon.
+func (a *Acceptor) session(id string) (*session, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return nil, ErrAcceptorClosed
+	}
+	if s, ok := a.sessions[id]; ok {
+		return s, nil
+	}
+	s, err := newSession(a, id, filepath.Join(a.dir, id))
+	if err != nil {
+		return nil, err
+	}
+	a.sessions[id] = s
+	return s, nil
+}
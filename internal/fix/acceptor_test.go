@@ -0,0 +1,260 @@
+package fix
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"orderbook/internal/exchange"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+// client is the other end of a FIX session.
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+	seq  uint64
+}
+
+func dial(t *testing.T, addr net.Addr) *client {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr.String())
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &client{t: t, conn: conn, r: bufio.NewReader(conn), seq: 1}
+}
+
+// send sends a message of the client, with fields as tag and value pairs.
+func (c *client) send(msgType string, fields ...any) {
+	c.t.Helper()
+	m := newMessage(msgType)
+	for i := 0; i < len(fields); i += 2 {
+		m = m.add(fields[i].(int), fields[i+1].(string))
+	}
+	if _, err := c.conn.Write(m.header("CLIENT", DefaultCompID, c.seq, time.Now()).encode()); err != nil {
+		c.t.Fatalf("Failed to send: %v", err)
+	}
+	c.seq++
+}
+
+// expect reads the next message and checks its type and fields.
+func (c *client) expect(msgType string, fields map[int]string) message {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	m, err := readMessage(c.r)
+	if err != nil {
+		c.t.Fatalf("Expected a message of type %s, got %v", msgType, err)
+	}
+	if m.msgType() != msgType {
+		c.t.Fatalf("Expected a message of type %s, got %v", msgType, m)
+	}
+	for tag, value := range fields {
+		if got := m.str(tag); got != value {
+			c.t.Errorf("Expected %d=%s in %v, got %q", tag, value, m, got)
+		}
+	}
+	return m
+}
+
+func (c *client) expectClosed() {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if m, err := readMessage(c.r); err == nil {
+		c.t.Fatalf("Expected the connection to close, got %v", m)
+	}
+}
+
+func serve(t *testing.T, ex *exchange.Exchange, dir string) (*Acceptor, net.Addr) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	a := NewAcceptor(ex, dir)
+	go a.Serve(l)
+	t.Cleanup(func() { a.Close() })
+	return a, l.Addr()
+}
+
+func dec(f float64) orderbook.Decimal {
+	return orderbook.DecimalFromFloat(f)
+}
+
+func TestAcceptor_Orders(t *testing.T) {
+	ex, _ := exchange.New()
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	_, addr := serve(t, ex, t.TempDir())
+
+	c := dial(t, addr)
+	c.send(msgLogon, tagEncryptMethod, "0", tagHeartBtInt, "30")
+	c.expect(msgLogon, map[int]string{tagMsgSeqNum: "1", tagHeartBtInt: "30"})
+
+	c.send(msgNewOrderSingle, tagClOrdID, "1", tagSymbol, "BTC-USD", tagSide, "1",
+		tagOrderQty, "2", tagOrdType, "2", tagPrice, "100", tagAccount, "alice")
+	c.expect(msgExecutionReport, map[int]string{tagOrderID: "CLIENT:1", tagClOrdID: "1", tagExecType: execNew,
+		tagOrdStatus: statusNew, tagLeavesQty: "2", tagCumQty: "0", tagAccount: "alice"})
+
+	book.ProcessOrder(orderbook.Order{ID: "other", Type: orderbook.Limit, Side: orderbook.Sell, Price: dec(100.0), Amount: dec(1.0)})
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "1", tagExecType: execTrade, tagOrdStatus: statusPartial,
+		tagLastQty: "1", tagLastPx: "100", tagLeavesQty: "1", tagCumQty: "1", tagAvgPx: "100"})
+
+	c.send(msgOrderCancelReplace, tagClOrdID, "2", tagOrigClOrdID, "1", tagSymbol, "BTC-USD", tagSide, "1",
+		tagOrderQty, "3", tagOrdType, "2", tagPrice, "99")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "2", tagOrigClOrdID: "1", tagExecType: execReplaced,
+		tagOrdStatus: statusPartial, tagOrderQty: "3", tagPrice: "99", tagLeavesQty: "2", tagCumQty: "1"})
+	if order, _ := book.GetOrder("CLIENT:1"); order.Price != dec(99.0) || order.Amount != dec(2.0) {
+		t.Errorf("Expected the order replaced in the book, got %+v", order)
+	}
+
+	c.send(msgOrderCancelRequest, tagClOrdID, "3", tagOrigClOrdID, "2", tagSymbol, "BTC-USD", tagSide, "1")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "3", tagOrigClOrdID: "2", tagExecType: execCanceled,
+		tagOrdStatus: statusCanceled, tagLeavesQty: "0", tagCumQty: "1"})
+
+	c.send(msgOrderCancelRequest, tagClOrdID, "4", tagOrigClOrdID, "2", tagSymbol, "BTC-USD", tagSide, "1")
+	c.expect(msgOrderCancelReject, map[int]string{tagClOrdID: "4", tagCxlRejResponseTo: "1", tagCxlRejReason: cxlRejectUnknown})
+
+	// Rejected by the gateway and by the book
+	c.send(msgNewOrderSingle, tagClOrdID, "5", tagSymbol, "DOGE-USD", tagSide, "1",
+		tagOrderQty, "1", tagOrdType, "2", tagPrice, "1")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "5", tagExecType: execRejected, tagOrdRejReason: rejectUnknownSymbol})
+	c.send(msgNewOrderSingle, tagClOrdID, "6", tagSymbol, "BTC-USD", tagSide, "1",
+		tagOrderQty, "1", tagOrdType, "2")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "6", tagExecType: execRejected, tagOrdStatus: statusRejected})
+	c.send(msgNewOrderSingle, tagClOrdID, "1", tagSymbol, "BTC-USD", tagSide, "1",
+		tagOrderQty, "1", tagOrdType, "2", tagPrice, "100")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "1", tagExecType: execRejected, tagOrdRejReason: rejectDuplicateOrder})
+	c.send(msgNewOrderSingle, tagClOrdID, "7", tagSymbol, "BTC-USD", tagSide, "1",
+		tagOrderQty, "1", tagOrdType, "2", tagPrice, "100", tagTimeInForce, "0")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "7", tagExecType: execRejected, tagText: "Invalid value for tag 59"})
+
+	// Market orders without a time in force get the book's, IOC
+	book.ProcessOrder(orderbook.Order{ID: "ask", Type: orderbook.Limit, Side: orderbook.Sell, Price: dec(101.0), Amount: dec(1.0)})
+	c.send(msgNewOrderSingle, tagClOrdID, "8", tagSymbol, "BTC-USD", tagSide, "1", tagOrderQty, "2", tagOrdType, "1")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "8", tagExecType: execNew, tagOrdStatus: statusNew})
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "8", tagExecType: execTrade, tagLastQty: "1", tagLastPx: "101"})
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "8", tagExecType: execCanceled, tagOrdStatus: statusCanceled,
+		tagOrderQty: "2", tagLeavesQty: "0", tagCumQty: "1"})
+
+	c.send("Z")
+	c.expect(msgReject, map[int]string{tagRefMsgType: "Z", tagSessionRejectReason: rejectInvalidMsgType})
+
+	c.send(msgTestRequest, tagTestReqID, "ping")
+	c.expect(msgHeartbeat, map[int]string{tagTestReqID: "ping"})
+
+	c.send(msgLogout)
+	c.expect(msgLogout, nil)
+	c.expectClosed()
+}
+
+func TestAcceptor_Resend(t *testing.T) {
+	dir := t.TempDir()
+	ex, _ := exchange.New()
+	book, _ := ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	a, addr := serve(t, ex, dir)
+
+	c := dial(t, addr)
+	c.send(msgLogon, tagHeartBtInt, "30")
+	c.expect(msgLogon, nil)
+	c.send(msgNewOrderSingle, tagClOrdID, "1", tagSymbol, "BTC-USD", tagSide, "2",
+		tagOrderQty, "1", tagOrdType, "2", tagPrice, "200")
+	c.expect(msgExecutionReport, map[int]string{tagMsgSeqNum: "2", tagExecType: execNew})
+	c.send(msgLogout)
+	c.expect(msgLogout, map[int]string{tagMsgSeqNum: "3"})
+	c.expectClosed()
+
+	// Filled while logged out, then the gateway restarts
+	book.ProcessOrder(orderbook.Order{ID: "other", Type: orderbook.Limit, Side: orderbook.Buy, Price: dec(200.0), Amount: dec(1.0)})
+	s, _ := a.session("CLIENT")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		s.mu.Lock()
+		reported := s.out == 5
+		s.mu.Unlock()
+		if reported || time.Now().After(deadline) {
+			break
+		}
+	}
+	a.Close()
+	_, addr = serve(t, ex, dir)
+
+	next := c.seq
+	c = dial(t, addr)
+	c.seq = 1
+	c.send(msgLogon, tagHeartBtInt, "30")
+	c.expect(msgLogout, map[int]string{tagText: "MsgSeqNum too low, expecting 4 but received 1"})
+	c.expectClosed()
+
+	c = dial(t, addr)
+	c.seq = next
+	c.send(msgLogon, tagHeartBtInt, "30")
+	c.expect(msgLogon, map[int]string{tagMsgSeqNum: "6"})
+
+	c.send(msgResendRequest, tagBeginSeqNo, "1", tagEndSeqNo, "0")
+	c.expect(msgSequenceReset, map[int]string{tagMsgSeqNum: "1", tagGapFillFlag: "Y", tagNewSeqNo: "2"})
+	c.expect(msgExecutionReport, map[int]string{tagMsgSeqNum: "2", tagPossDupFlag: "Y", tagExecType: execNew})
+	c.expect(msgSequenceReset, map[int]string{tagMsgSeqNum: "3", tagGapFillFlag: "Y", tagNewSeqNo: "4"})
+	m := c.expect(msgExecutionReport, map[int]string{tagMsgSeqNum: "4", tagPossDupFlag: "Y", tagExecType: execTrade,
+		tagOrdStatus: statusFilled, tagLastPx: "200", tagLeavesQty: "0"})
+	if m.str(tagOrigSendingTime) == "" {
+		t.Errorf("Expected the original sending time on a resent message, got %v", m)
+	}
+	c.expect(msgSequenceReset, map[int]string{tagMsgSeqNum: "5", tagGapFillFlag: "Y", tagNewSeqNo: "7"})
+
+	// A gap in what the client sends is asked for again
+	c.seq += 2
+	c.send(msgHeartbeat)
+	c.expect(msgResendRequest, map[int]string{tagBeginSeqNo: strconv.FormatUint(next+2, 10), tagEndSeqNo: "0"})
+	c.seq = next + 2
+	c.send(msgSequenceReset, tagGapFillFlag, "Y", tagNewSeqNo, strconv.FormatUint(next+5, 10))
+	c.seq = next + 5
+	c.send(msgTestRequest, tagTestReqID, "caught up")
+	c.expect(msgHeartbeat, map[int]string{tagTestReqID: "caught up"})
+}
+
+func TestAcceptor_Panic(t *testing.T) {
+	crash := risk.RuleFunc(func(order *orderbook.Order, old *orderbook.Order, book risk.Book) *risk.Rejection {
+		if order.Account == "crash" {
+			panic("crash")
+		}
+		return nil
+	})
+	ex, _ := exchange.New(exchange.WithRiskRules(crash))
+	ex.CreateMarket("BTC-USD", orderbook.DefaultInstrumentSpec())
+	_, addr := serve(t, ex, t.TempDir())
+
+	c := dial(t, addr)
+	c.send(msgLogon, tagEncryptMethod, "0", tagHeartBtInt, "30")
+	c.expect(msgLogon, nil)
+	c.send(msgNewOrderSingle, tagClOrdID, "1", tagSymbol, "BTC-USD", tagSide, "1",
+		tagOrderQty, "1", tagOrdType, "2", tagPrice, "100", tagAccount, "crash")
+	c.expect(msgLogout, map[int]string{tagText: "Internal error"})
+	c.expectClosed()
+
+	// The acceptor and the session carry on
+	c = dial(t, addr)
+	c.send(msgLogon, tagEncryptMethod, "0", tagHeartBtInt, "30", tagResetSeqNumFlag, "Y")
+	c.expect(msgLogon, map[int]string{tagMsgSeqNum: "1"})
+	c.send(msgNewOrderSingle, tagClOrdID, "2", tagSymbol, "BTC-USD", tagSide, "1",
+		tagOrderQty, "1", tagOrdType, "2", tagPrice, "100", tagAccount, "alice")
+	c.expect(msgExecutionReport, map[int]string{tagClOrdID: "2", tagExecType: execNew})
+}
+
+func TestAcceptor_LogonHeartbeat(t *testing.T) {
+	ex, _ := exchange.New()
+	_, addr := serve(t, ex, t.TempDir())
+
+	for _, heartbeat := range []string{"0", "3601", "9300000000"} {
+		c := dial(t, addr)
+		c.send(msgLogon, tagEncryptMethod, "0", tagHeartBtInt, heartbeat)
+		c.expectClosed()
+	}
+
+	c := dial(t, addr)
+	c.send(msgLogon, tagEncryptMethod, "0", tagHeartBtInt, "3600")
+	c.expect(msgLogon, map[int]string{tagHeartBtInt: "3600"})
+}
@@ -0,0 +1,289 @@
+// Package fix is a FIX 4.4 order entry gateway. Clients log on to an
+// Acceptor over TCP and trade every market of an exchange with
+// NewOrderSingle, OrderCancelRequest and OrderCancelReplaceRequest, and
+// receive ExecutionReports for everything that happens to their orders.
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var ErrGarbled = errors.New("Garbled FIX message")
+
+const (
+	beginString = "FIX.4.4"
+	soh         = '\x01'
+
+	// Timestamps are UTC with milliseconds
+	timeFormat = "20060102-15:04:05.000"
+)
+
+// Tags of the fields the gateway reads or writes.
+const (
+	tagAccount             = 1
+	tagAvgPx               = 6
+	tagBeginSeqNo          = 7
+	tagBeginString         = 8
+	tagBodyLength          = 9
+	tagCheckSum            = 10
+	tagClOrdID             = 11
+	tagCumQty              = 14
+	tagEndSeqNo            = 16
+	tagExecID              = 17
+	tagExecInst            = 18
+	tagLastPx              = 31
+	tagLastQty             = 32
+	tagMsgSeqNum           = 34
+	tagMsgType             = 35
+	tagNewSeqNo            = 36
+	tagOrderID             = 37
+	tagOrderQty            = 38
+	tagOrdStatus           = 39
+	tagOrdType             = 40
+	tagOrigClOrdID         = 41
+	tagPossDupFlag         = 43
+	tagPrice               = 44
+	tagRefSeqNum           = 45
+	tagSenderCompID        = 49
+	tagSendingTime         = 52
+	tagSide                = 54
+	tagSymbol              = 55
+	tagTargetCompID        = 56
+	tagText                = 58
+	tagTimeInForce         = 59
+	tagTransactTime        = 60
+	tagEncryptMethod       = 98
+	tagStopPx              = 99
+	tagCxlRejReason        = 102
+	tagOrdRejReason        = 103
+	tagHeartBtInt          = 108
+	tagMaxFloor            = 111
+	tagTestReqID           = 112
+	tagOrigSendingTime     = 122
+	tagGapFillFlag         = 123
+	tagExpireTime          = 126
+	tagResetSeqNumFlag     = 141
+	tagExecType            = 150
+	tagLeavesQty           = 151
+	tagRefMsgType          = 372
+	tagSessionRejectReason = 373
+	tagCxlRejResponseTo    = 434
+)
+
+// Message types.
+const (
+	msgHeartbeat          = "0"
+	msgTestRequest        = "1"
+	msgResendRequest      = "2"
+	msgReject             = "3"
+	msgSequenceReset      = "4"
+	msgLogout             = "5"
+	msgExecutionReport    = "8"
+	msgOrderCancelReject  = "9"
+	msgLogon              = "A"
+	msgNewOrderSingle     = "D"
+	msgOrderCancelRequest = "F"
+	msgOrderCancelReplace = "G"
+)
+
+// isAdmin reports whether a message type belongs to the session layer.
+// Those are never resent; a resend skips them with a gap fill.
+func isAdmin(msgType string) bool {
+	switch msgType {
+	case msgHeartbeat, msgTestRequest, msgResendRequest, msgReject, msgSequenceReset, msgLogout, msgLogon:
+		return true
+	}
+	return false
+}
+
+type field struct {
+	tag   int
+	value string
+}
+
+// message is the fields of a FIX message in wire order, without the
+// BeginString, BodyLength and CheckSum that frame it.
+type message []field
+
+func newMessage(msgType string) message {
+	return message{{tagMsgType, msgType}}
+}
+
+// get returns the value of the first field with the tag.
+func (m message) get(tag int) (string, bool) {
+	for _, f := range m {
+		if f.tag == tag {
+			return f.value, true
+		}
+	}
+	return "", false
+}
+
+func (m message) str(tag int) string {
+	value, _ := m.get(tag)
+	return value
+}
+
+// int returns the value of an integer field, zero if it is missing.
+func (m message) int(tag int) (uint64, error) {
+	value, ok := m.get(tag)
+	if !ok {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func (m message) msgType() string {
+	return m.str(tagMsgType)
+}
+
+func (m message) seqNum() uint64 {
+	seq, _ := m.int(tagMsgSeqNum)
+	return seq
+}
+
+func (m message) add(tag int, value string) message {
+	return append(m, field{tag, value})
+}
+
+// set replaces the first field with the tag, or adds it.
+func (m message) set(tag int, value string) message {
+	for i := range m {
+		if m[i].tag == tag {
+			m[i].value = value
+			return m
+		}
+	}
+	return m.add(tag, value)
+}
+
+// header returns the message with the standard header in front of its body:
+// MsgType, the comp IDs, MsgSeqNum, PossDupFlag, SendingTime and
+// OrigSendingTime, then every other field in order.
+func (m message) header(sender, target string, seq uint64, sent time.Time) message {
+	header := []int{tagMsgType, tagSenderCompID, tagTargetCompID, tagMsgSeqNum, tagPossDupFlag, tagSendingTime, tagOrigSendingTime}
+	m = m.set(tagSenderCompID, sender).
+		set(tagTargetCompID, target).
+		set(tagMsgSeqNum, strconv.FormatUint(seq, 10)).
+		set(tagSendingTime, sent.UTC().Format(timeFormat))
+
+	ordered := make(message, 0, len(m))
+	for _, tag := range header {
+		if value, ok := m.get(tag); ok {
+			ordered = ordered.add(tag, value)
+		}
+	}
+	for _, f := range m {
+		if !slices.Contains(header, f.tag) {
+			ordered = append(ordered, f)
+		}
+	}
+	return ordered
+}
+
+// encode frames the message for the wire.
+func (m message) encode() []byte {
+	var body bytes.Buffer
+	for _, f := range m {
+		body.WriteString(strconv.Itoa(f.tag))
+		body.WriteByte('=')
+		body.WriteString(f.value)
+		body.WriteByte(soh)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "8=%s%c9=%d%c", beginString, soh, body.Len(), soh)
+	out.Write(body.Bytes())
+	fmt.Fprintf(&out, "10=%03d%c", checksum(out.Bytes()), soh)
+	return out.Bytes()
+}
+
+func checksum(data []byte) int {
+	var sum int
+	for _, b := range data {
+		sum += int(b)
+	}
+	return sum % 256
+}
+
+// readMessage reads the next message, checking its BeginString, BodyLength
+// and CheckSum. Returns ErrGarbled if the framing is wrong.
+func readMessage(r *bufio.Reader) (message, error) {
+	var frame bytes.Buffer
+	begin, err := readField(r, &frame)
+	if err != nil {
+		return nil, err
+	}
+	if begin.tag != tagBeginString || begin.value != beginString {
+		return nil, ErrGarbled
+	}
+	length, err := readField(r, &frame)
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(length.value)
+	if length.tag != tagBodyLength || err != nil || n <= 0 || n > maxBodyLength {
+		return nil, ErrGarbled
+	}
+
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	frame.Write(body)
+	sum := checksum(frame.Bytes())
+	trailer, err := readField(r, &frame)
+	if err != nil {
+		return nil, err
+	}
+	if trailer.tag != tagCheckSum || trailer.value != fmt.Sprintf("%03d", sum) {
+		return nil, ErrGarbled
+	}
+
+	return parseFields(body)
+}
+
+// Longest body accepted, far more than any message the gateway handles
+const maxBodyLength = 64 << 10
+
+// readField reads one tag=value field, appending its bytes to frame.
+func readField(r *bufio.Reader, frame *bytes.Buffer) (field, error) {
+	data, err := r.ReadSlice(soh)
+	if err == bufio.ErrBufferFull {
+		return field{}, ErrGarbled
+	}
+	if err != nil {
+		return field{}, err
+	}
+	frame.Write(data)
+	fields, err := parseFields(data)
+	if err != nil || len(fields) != 1 {
+		return field{}, ErrGarbled
+	}
+	return fields[0], nil
+}
+
+func parseFields(data []byte) (message, error) {
+	var m message
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, soh)
+		if end < 0 {
+			return nil, ErrGarbled
+		}
+		tag, value, ok := bytes.Cut(data[:end], []byte{'='})
+		n, err := strconv.Atoi(string(tag))
+		if !ok || err != nil || n <= 0 {
+			return nil, ErrGarbled
+		}
+		m = append(m, field{n, string(value)})
+		data = data[end+1:]
+	}
+	return m, nil
+}
@@ -0,0 +1,53 @@
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMessage_Encode(t *testing.T) {
+	sent := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	m := newMessage(msgTestRequest).add(tagTestReqID, "ping").header("ORDERBOOK", "CLIENT", 7, sent)
+
+	expected := "8=FIX.4.4\x019=67\x0135=1\x0149=ORDERBOOK\x0156=CLIENT\x0134=7\x0152=20240301-12:30:00.000\x01112=ping\x0110=168\x01"
+	if data := string(m.encode()); data != expected {
+		t.Fatalf("Expected %q, got %q", expected, data)
+	}
+
+	read, err := readMessage(bufio.NewReader(bytes.NewReader(m.encode())))
+	if err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	if !reflect.DeepEqual(read, m) {
+		t.Errorf("Expected %v, got %v", m, read)
+	}
+}
+
+func TestMessage_Read(t *testing.T) {
+	valid := string(newMessage(msgHeartbeat).header("A", "B", 1, time.Unix(0, 0)).encode())
+
+	tests := []struct {
+		name     string
+		data     string
+		expected error
+	}{
+		{"Valid", valid, nil},
+		{"Wrong version", "8=FIX.4.2" + valid[9:], ErrGarbled},
+		{"Bad checksum", valid[:len(valid)-4] + "000\x01", ErrGarbled},
+		{"Bad length", "8=FIX.4.4\x019=x\x01", ErrGarbled},
+		{"Bad field", "8=FIX.4.4\x019=5\x01abcd\x0110=000\x01", ErrGarbled},
+		{"Truncated", valid[:20], io.ErrUnexpectedEOF},
+		{"Empty", "", io.EOF},
+	}
+	for _, tt := range tests {
+		_, err := readMessage(bufio.NewReader(bytes.NewReader([]byte(tt.data))))
+		if !errors.Is(err, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, err)
+		}
+	}
+}
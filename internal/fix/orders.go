@@ -0,0 +1,499 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"orderbook/internal/orderbook"
+	"orderbook/internal/risk"
+)
+
+// ExecType values
+const (
+	execNew      = "0"
+	execCanceled = "4"
+	execReplaced = "5"
+	execRejected = "8"
+	execExpired  = "C"
+	execRestated = "D"
+	execTrade    = "F"
+)
+
+// OrdStatus values
+const (
+	statusNew      = "0"
+	statusPartial  = "1"
+	statusFilled   = "2"
+	statusCanceled = "4"
+	statusRejected = "8"
+	statusExpired  = "C"
+)
+
+// OrdRejReason and CxlRejReason values
+const (
+	rejectUnknownSymbol  = "1"
+	rejectExceedsLimit   = "3"
+	rejectDuplicateOrder = "6"
+	rejectOther          = "99"
+	cxlRejectUnknown     = "1"
+	cxlRejectOther       = "99"
+)
+
+// order is what a session keeps of one of its open orders to report on it.
+type order struct {
+	id          string // Book order ID
+	clOrdID     string // Latest ClOrdID
+	origClOrdID string // ClOrdID before the latest replace
+	pending     string // ClOrdID of a cancel or replace the book is handling
+	symbol      string
+	account     string
+	side        orderbook.Side
+	price       orderbook.Decimal
+	qty         orderbook.Decimal // OrderQty: filled plus open
+	cum         orderbook.Decimal
+	value       orderbook.Decimal // Quote value filled, for the average price
+	lastPx      orderbook.Decimal // Price of the trade being reported
+}
+
+// bookID returns the book order ID of an order of the session. Prefixing
+// the ClOrdID keeps the IDs of different clients apart, and tells the
+// orders of the session from everyone else's in the events of the book.
+func (s *session) bookID(clOrdID string) string {
+	return s.id + ":" + clOrdID
+}
+
+func (s *session) owns(id string) bool {
+	return strings.HasPrefix(id, s.id+":")
+}
+
+// watch subscribes to the events of a market, unless it already does.
+// Must be called with the lock held, or before the session is shared.
+func (s *session) watch(symbol string, book *orderbook.OrderBook) {
+	if w, ok := s.watched[symbol]; ok {
+		if w.book == book {
+			return
+		}
+		w.sub.Close() // The market was deleted and created again
+	}
+	sub := book.Subscribe()
+	s.watched[symbol] = watched{book: book, sub: sub}
+	go func() {
+		for event := range sub.C {
+			s.onEvent(event)
+		}
+		if err := sub.Err(); err != nil {
+			s.unwatch(symbol, sub, err)
+		}
+	}()
+}
+
+// unwatch forgets a subscription the book ended, so that the next order for
+// the market subscribes again. If the session fell behind, it also logs the
+// client out: reports were lost, and it has to check its orders once it
+// logs on again.
+func (s *session) unwatch(symbol string, sub *orderbook.Subscription, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.watched[symbol]; ok && w.sub == sub {
+		delete(s.watched, symbol)
+	}
+	if errors.Is(err, orderbook.ErrSubscriberBehind) {
+		log.Printf("FIX session %s: events of %s: %v", s.id, symbol, err)
+		if !s.closed {
+			s.logout("Execution reports lost")
+		}
+	}
+}
+
+// newOrder enters a NewOrderSingle into the book of its market. Everything
+// the book does with it is reported from its events, so only orders that
+// never reach the book are rejected here.
+func (s *session) newOrder(m message) {
+	clOrdID := m.str(tagClOrdID)
+	if clOrdID == "" {
+		s.reject(m, rejectRequiredTagMissing, "ClOrdID is required")
+		return
+	}
+	o := &order{
+		id:      s.bookID(clOrdID),
+		clOrdID: clOrdID,
+		symbol:  m.str(tagSymbol),
+		account: m.str(tagAccount),
+	}
+	reject := func(reason string, err error) {
+		s.send(s.report(o, execRejected, statusRejected, 0, s.acceptor.now()).
+			add(tagOrdRejReason, reason).
+			add(tagText, err.Error()))
+	}
+
+	order, err := parseOrder(m)
+	o.side, o.price, o.qty = order.Side, order.Price, order.Amount
+	if err != nil {
+		reject(rejectOther, err)
+		return
+	}
+	order.ID = o.id
+	book, err := s.acceptor.exchange.Market(o.symbol)
+	if err != nil {
+		reject(rejectUnknownSymbol, err)
+		return
+	}
+	if _, ok := s.clOrdIDs[clOrdID]; ok {
+		reject(rejectDuplicateOrder, orderbook.ErrDuplicateOrder)
+		return
+	}
+	s.watch(o.symbol, book)
+	s.orders[o.id] = o
+	s.clOrdIDs[clOrdID] = o.id
+	_, err = book.ProcessOrder(order)
+	var rejection *risk.Rejection
+	switch {
+	case errors.As(err, &rejection):
+		delete(s.orders, o.id)
+		delete(s.clOrdIDs, clOrdID)
+		reject(rejectExceedsLimit, err)
+	case errors.Is(err, orderbook.ErrJournal):
+		// The book rejected every other error with an event
+		delete(s.orders, o.id)
+		delete(s.clOrdIDs, clOrdID)
+		reject(rejectOther, err)
+	}
+}
+
+// cancelOrder cancels an order for an OrderCancelRequest.
+func (s *session) cancelOrder(m message) {
+	o, book, err := s.lookup(m)
+	if err == nil {
+		o.pending = m.str(tagClOrdID)
+		if err = book.CancelOrder(o.id); err != nil {
+			o.pending = ""
+		}
+	}
+	if err != nil {
+		s.cancelReject(m, o, "1", err)
+	}
+}
+
+// replaceOrder changes the price and quantity of an order for an
+// OrderCancelReplaceRequest. OrderQty counts what was already filled.
+func (s *session) replaceOrder(m message) {
+	o, book, err := s.lookup(m)
+	var price, qty, stop orderbook.Decimal
+	if err == nil {
+		price, qty, stop, err = parseReplace(m)
+	}
+	if err == nil && qty <= o.cum {
+		err = orderbook.ErrInvalidModification
+	}
+	if err == nil {
+		o.pending = m.str(tagClOrdID)
+		if stop > 0 {
+			err = book.ModifyStopOrder(o.id, stop, price, qty-o.cum)
+		} else {
+			err = book.ModifyOrder(o.id, price, qty-o.cum)
+		}
+		if err != nil {
+			o.pending = ""
+		}
+	}
+	if err != nil {
+		s.cancelReject(m, o, "2", err)
+	}
+}
+
+// lookup finds the order a cancel or replace request is for, by OrderID or
+// OrigClOrdID. Orders from before a restart are picked up from the book.
+func (s *session) lookup(m message) (*order, *orderbook.OrderBook, error) {
+	if m.str(tagClOrdID) == "" {
+		return nil, nil, errors.New("ClOrdID is required")
+	}
+	id := m.str(tagOrderID)
+	if id == "" {
+		orig := m.str(tagOrigClOrdID)
+		var ok bool
+		if id, ok = s.clOrdIDs[orig]; !ok {
+			id = s.bookID(orig)
+		}
+	}
+	if !s.owns(id) {
+		return nil, nil, orderbook.ErrOrderNotFound
+	}
+
+	o, ok := s.orders[id]
+	symbol := m.str(tagSymbol)
+	if ok {
+		symbol = o.symbol
+	}
+	book, err := s.acceptor.exchange.Market(symbol)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		resting, err := book.GetOrder(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		o = &order{
+			id:      id,
+			clOrdID: strings.TrimPrefix(id, s.id+":"),
+			symbol:  symbol,
+			account: resting.Account,
+			side:    resting.Side,
+			price:   resting.Price,
+			qty:     resting.Amount,
+		}
+		s.orders[id] = o
+	}
+	return o, book, nil
+}
+
+// cancelReject refuses a cancel or replace request.
+func (s *session) cancelReject(m message, o *order, responseTo string, err error) {
+	id, status := "NONE", statusRejected
+	reason := cxlRejectOther
+	if o != nil {
+		id, status = o.id, o.status(o.qty-o.cum)
+	} else if errors.Is(err, orderbook.ErrOrderNotFound) {
+		reason = cxlRejectUnknown
+	}
+	s.send(newMessage(msgOrderCancelReject).
+		add(tagOrderID, id).
+		add(tagClOrdID, m.str(tagClOrdID)).
+		add(tagOrigClOrdID, m.str(tagOrigClOrdID)).
+		add(tagOrdStatus, status).
+		add(tagCxlRejResponseTo, responseTo).
+		add(tagCxlRejReason, reason).
+		add(tagText, err.Error()))
+}
+
+// onEvent reports what happened to an order of the session.
+func (s *session) onEvent(event orderbook.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	if event.Type == orderbook.EventTrade {
+		for _, id := range []string{event.Trade.BuyOrderID, event.Trade.SellOrderID} {
+			if o, ok := s.orders[id]; ok {
+				o.lastPx = event.Trade.Price
+			}
+		}
+		return
+	}
+	if event.Order == nil || !s.owns(event.Order.ID) {
+		return
+	}
+
+	o, ok := s.orders[event.Order.ID]
+	if !ok {
+		// Placed before a restart
+		o = &order{
+			id:      event.Order.ID,
+			clOrdID: strings.TrimPrefix(event.Order.ID, s.id+":"),
+			symbol:  event.Book,
+			account: event.Order.Account,
+			side:    event.Order.Side,
+			price:   event.Order.Price,
+			qty:     event.Order.Amount + event.Amount,
+			lastPx:  event.Order.Price,
+		}
+		s.orders[o.id] = o
+	}
+	leaves := event.Order.Amount
+
+	var report message
+	switch event.Type {
+	case orderbook.EventAccepted:
+		report = s.report(o, execNew, o.status(leaves), leaves, event.Time)
+	case orderbook.EventRejected:
+		report = s.report(o, execRejected, statusRejected, 0, event.Time).
+			add(tagOrdRejReason, rejectOther).
+			add(tagText, event.Reason)
+		leaves = 0
+	case orderbook.EventPartiallyFilled, orderbook.EventFilled:
+		o.cum += event.Amount
+		o.value += event.Amount.Mul(o.lastPx)
+		report = s.report(o, execTrade, o.status(leaves), leaves, event.Time).
+			add(tagLastQty, event.Amount.String()).
+			add(tagLastPx, o.lastPx.String())
+	case orderbook.EventCancelled:
+		o.rotate()
+		if leaves > 0 {
+			o.qty = o.cum + leaves // Restated down, the rest stays open
+			report = s.report(o, execRestated, o.status(leaves), leaves, event.Time)
+		} else {
+			report = s.report(o, execCanceled, statusCanceled, 0, event.Time)
+		}
+	case orderbook.EventModified:
+		o.rotate()
+		o.price, o.qty = event.Order.Price, o.cum+leaves
+		s.clOrdIDs[o.clOrdID] = o.id
+		report = s.report(o, execReplaced, o.status(leaves), leaves, event.Time)
+	case orderbook.EventExpired:
+		report = s.report(o, execExpired, statusExpired, 0, event.Time)
+	default:
+		return
+	}
+	s.send(report)
+	o.origClOrdID = "" // Only the report of the cancel or replace carries it
+
+	if leaves == 0 {
+		delete(s.orders, o.id)
+	}
+}
+
+// rotate makes the ClOrdID of a pending cancel or replace the order's.
+func (o *order) rotate() {
+	if o.pending != "" {
+		o.origClOrdID, o.clOrdID, o.pending = o.clOrdID, o.pending, ""
+	}
+}
+
+// status returns the OrdStatus of an order still open for leaves.
+func (o *order) status(leaves orderbook.Decimal) string {
+	switch {
+	case leaves == 0 && o.cum > 0:
+		return statusFilled
+	case o.cum > 0:
+		return statusPartial
+	}
+	return statusNew
+}
+
+// report builds an ExecutionReport for an order.
+func (s *session) report(o *order, execType, status string, leaves orderbook.Decimal, at time.Time) message {
+	var avgPx orderbook.Decimal
+	if o.cum > 0 {
+		avgPx = o.value.Div(o.cum)
+	}
+	m := newMessage(msgExecutionReport).
+		add(tagOrderID, o.id).
+		add(tagClOrdID, o.clOrdID)
+	if o.origClOrdID != "" {
+		m = m.add(tagOrigClOrdID, o.origClOrdID)
+	}
+	m = m.add(tagExecID, uuid.New().String()).
+		add(tagExecType, execType).
+		add(tagOrdStatus, status).
+		add(tagSymbol, o.symbol).
+		add(tagSide, fixSide(o.side)).
+		add(tagOrderQty, o.qty.String())
+	if o.price > 0 {
+		m = m.add(tagPrice, o.price.String())
+	}
+	if o.account != "" {
+		m = m.add(tagAccount, o.account)
+	}
+	return m.add(tagLeavesQty, leaves.String()).
+		add(tagCumQty, o.cum.String()).
+		add(tagAvgPx, avgPx.String()).
+		add(tagTransactTime, at.UTC().Format(timeFormat))
+}
+
+func fixSide(side orderbook.Side) string {
+	if side == orderbook.Sell {
+		return "2"
+	}
+	return "1"
+}
+
+// parseOrder reads the order of a NewOrderSingle. Values the book checks
+// itself, like a limit order without a price, are left for it to reject.
+func parseOrder(m message) (orderbook.Order, error) {
+	var order orderbook.Order
+	var err error
+	switch m.str(tagSide) {
+	case "1":
+		order.Side = orderbook.Buy
+	case "2":
+		order.Side = orderbook.Sell
+	default:
+		return order, invalidField(tagSide)
+	}
+	if order.Amount, err = decimalField(m, tagOrderQty); err != nil {
+		return order, err
+	}
+
+	switch m.str(tagOrdType) {
+	case "1":
+		order.Type = orderbook.Market
+	case "2":
+		order.Type = orderbook.Limit
+	case "3":
+		order.Type = orderbook.Stop
+	case "4":
+		order.Type = orderbook.StopLimit
+	default:
+		return order, invalidField(tagOrdType)
+	}
+	if order.Price, err = decimalField(m, tagPrice); err != nil {
+		return order, err
+	}
+	if order.StopPrice, err = decimalField(m, tagStopPx); err != nil {
+		return order, err
+	}
+
+	// There is no trading day to end, so Day orders are not supported.
+	// Without the tag the book picks the default, IOC for market orders
+	switch m.str(tagTimeInForce) {
+	case "":
+	case "1":
+		order.TimeInForce = orderbook.GTC
+	case "3":
+		order.TimeInForce = orderbook.IOC
+	case "4":
+		order.TimeInForce = orderbook.FOK
+	case "6":
+		expire, err := time.Parse(timeFormat, m.str(tagExpireTime))
+		if err != nil {
+			return order, invalidField(tagExpireTime)
+		}
+		order.TimeInForce, order.ExpireAt = orderbook.GTD, &expire
+	default:
+		return order, invalidField(tagTimeInForce)
+	}
+
+	// ExecInst 6, participate don't initiate, is a post-only order
+	order.PostOnly = strings.Contains(m.str(tagExecInst), "6")
+	if order.PeakAmount, err = decimalField(m, tagMaxFloor); err != nil {
+		return order, err
+	}
+	order.Account = m.str(tagAccount)
+	return order, nil
+}
+
+// parseReplace reads the new limit price, total quantity and stop price of
+// an OrderCancelReplaceRequest.
+func parseReplace(m message) (price, qty, stop orderbook.Decimal, err error) {
+	if price, err = decimalField(m, tagPrice); err != nil {
+		return
+	}
+	if qty, err = decimalField(m, tagOrderQty); err != nil {
+		return
+	}
+	stop, err = decimalField(m, tagStopPx)
+	return
+}
+
+// decimalField returns the value of a decimal field, zero if it is missing.
+func decimalField(m message, tag int) (orderbook.Decimal, error) {
+	value, ok := m.get(tag)
+	if !ok {
+		return 0, nil
+	}
+	d, err := orderbook.ParseDecimal(value)
+	if err != nil {
+		return 0, invalidField(tag)
+	}
+	return d, nil
+}
+
+func invalidField(tag int) error {
+	return fmt.Errorf("Invalid value for tag %d", tag)
+}
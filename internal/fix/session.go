@@ -0,0 +1,410 @@
+package fix
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"orderbook/internal/orderbook"
+)
+
+// Session reject reasons
+const (
+	rejectRequiredTagMissing = "1"
+	rejectValueIncorrect     = "5"
+	rejectCompIDProblem      = "9"
+	rejectInvalidMsgType     = "11"
+)
+
+// session is the conversation with one client across its connections.
+type session struct {
+	acceptor *Acceptor
+	id       string // SenderCompID of the client
+	store    *store
+
+	mu       sync.Mutex
+	out, in  uint64      // Next sequence numbers to send and to expect
+	resendTo uint64      // Sequence number a requested resend runs up to
+	conn     *connection // nil while logged out
+	closed   bool
+
+	orders   map[string]*order  // Open orders by book order ID
+	clOrdIDs map[string]string  // Book order ID of every ClOrdID
+	watched  map[string]watched // Event subscriptions by market
+}
+
+type watched struct {
+	book *orderbook.OrderBook
+	sub  *orderbook.Subscription
+}
+
+func newSession(a *Acceptor, id, dir string) (*session, error) {
+	st, err := openStore(dir)
+	if err != nil {
+		return nil, err
+	}
+	out, in, err := st.seqNums()
+	if err != nil {
+		st.close()
+		return nil, err
+	}
+	s := &session{
+		acceptor: a,
+		id:       id,
+		store:    st,
+		out:      out,
+		in:       in,
+		orders:   make(map[string]*order),
+		clOrdIDs: make(map[string]string),
+		watched:  make(map[string]watched),
+	}
+
+	// Orders resting from before a restart still get their reports
+	for _, info := range a.exchange.Markets() {
+		if book, err := a.exchange.Market(info.Symbol); err == nil {
+			s.watch(info.Symbol, book)
+		}
+	}
+	return s, nil
+}
+
+// connection is a connection of a logged on client.
+type connection struct {
+	net.Conn
+	heartbeat time.Duration
+	done      chan struct{}
+
+	lastSent     atomic.Int64 // Unix nanoseconds
+	lastReceived atomic.Int64
+	testRequest  atomic.Bool // Whether a TestRequest is unanswered
+}
+
+func newConnection(conn net.Conn, heartbeat time.Duration) *connection {
+	c := &connection{Conn: conn, heartbeat: heartbeat, done: make(chan struct{})}
+	c.lastSent.Store(time.Now().UnixNano())
+	c.received()
+	return c
+}
+
+func (c *connection) received() {
+	c.lastReceived.Store(time.Now().UnixNano())
+	c.testRequest.Store(false)
+}
+
+// timeout is how long the client may stay silent, enough for a heartbeat
+// and the answer to a TestRequest.
+func (c *connection) timeout() time.Duration {
+	return c.heartbeat * 5 / 2
+}
+
+func since(t *atomic.Int64) time.Duration {
+	return time.Since(time.Unix(0, t.Load()))
+}
+
+// logon starts a connection of the client with its Logon, returning false
+// if the session refuses it.
+func (s *session) logon(c *connection, m message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil || s.closed {
+		return false // Already logged on
+	}
+
+	reset := m.str(tagResetSeqNumFlag) == "Y"
+	if reset {
+		if err := s.store.reset(); err != nil {
+			log.Printf("FIX session %s: resetting: %v", s.id, err)
+			return false
+		}
+		s.out, s.in, s.resendTo = 1, 1, 0
+	}
+	seq := m.seqNum()
+	if seq == 0 {
+		return false
+	}
+
+	s.conn = c
+	if seq < s.in {
+		s.logout(fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", s.in, seq))
+		s.conn = nil
+		return false
+	}
+	reply := newMessage(msgLogon).
+		add(tagEncryptMethod, "0").
+		add(tagHeartBtInt, m.str(tagHeartBtInt))
+	if reset {
+		reply = reply.add(tagResetSeqNumFlag, "Y")
+	}
+	s.send(reply)
+	if seq > s.in {
+		s.requestResend(seq)
+	} else {
+		s.in++
+		s.saveSeqNums()
+	}
+	return true
+}
+
+// disconnect ends a connection of the client.
+func (s *session) disconnect(c *connection) {
+	s.mu.Lock()
+	if s.conn == c {
+		s.conn = nil
+	}
+	s.mu.Unlock()
+	close(c.done)
+	c.Close()
+}
+
+// recover logs a panic while handling a message of the client and logs it
+// out, before the connection is dropped. Deferred by the connection.
+func (s *session) recover(c *connection) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	log.Printf("FIX session %s: panic: %v\n%s", s.id, r, debug.Stack())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == c {
+		s.logout("Internal error")
+	}
+}
+
+// keepAlive sends a Heartbeat when the connection has been quiet for the
+// heartbeat interval, and a TestRequest when the client has.
+func (s *session) keepAlive(c *connection) {
+	ticker := time.NewTicker(min(c.heartbeat/2, time.Second))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		if s.conn == c {
+			if since(&c.lastSent) >= c.heartbeat {
+				s.send(newMessage(msgHeartbeat))
+			}
+			if since(&c.lastReceived) >= c.heartbeat*6/5 && !c.testRequest.Swap(true) {
+				s.send(newMessage(msgTestRequest).add(tagTestReqID, strconv.FormatInt(time.Now().UnixNano(), 10)))
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// receive handles a message of the client, returning false once the
+// connection should end.
+func (s *session) receive(c *connection, m message) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != c {
+		return false
+	}
+
+	if m.str(tagSenderCompID) != s.id || m.str(tagTargetCompID) != s.acceptor.compID {
+		s.reject(m, rejectCompIDProblem, "CompID problem")
+		s.logout("CompID problem")
+		return false
+	}
+	seq := m.seqNum()
+	if seq == 0 {
+		s.logout("MsgSeqNum missing")
+		return false
+	}
+
+	msgType := m.msgType()
+	if msgType == msgSequenceReset && m.str(tagGapFillFlag) != "Y" {
+		s.sequenceReset(m)
+		return true
+	}
+	switch {
+	case seq < s.in:
+		if m.str(tagPossDupFlag) == "Y" {
+			return true // Already seen
+		}
+		s.logout(fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", s.in, seq))
+		return false
+	case seq > s.in:
+		// Resends and logouts are answered even out of order
+		switch msgType {
+		case msgResendRequest:
+			s.resend(m)
+		case msgLogout:
+			s.logout("")
+			return false
+		}
+		s.requestResend(seq)
+		return true
+	}
+
+	s.in++
+	defer s.saveSeqNums()
+	switch msgType {
+	case msgHeartbeat, msgReject:
+	case msgTestRequest:
+		s.send(newMessage(msgHeartbeat).add(tagTestReqID, m.str(tagTestReqID)))
+	case msgResendRequest:
+		s.resend(m)
+	case msgSequenceReset:
+		s.sequenceReset(m)
+	case msgLogout:
+		s.logout("")
+		return false
+	case msgNewOrderSingle:
+		s.newOrder(m)
+	case msgOrderCancelRequest:
+		s.cancelOrder(m)
+	case msgOrderCancelReplace:
+		s.replaceOrder(m)
+	default:
+		s.reject(m, rejectInvalidMsgType, "Unsupported MsgType")
+	}
+	return true
+}
+
+// sequenceReset moves the next sequence number expected, as a gap fill
+// for messages the client will not resend or as a reset.
+func (s *session) sequenceReset(m message) {
+	next, err := m.int(tagNewSeqNo)
+	if err != nil || next < s.in {
+		s.reject(m, rejectValueIncorrect, "NewSeqNo is lower than expected")
+		return
+	}
+	s.in = next
+	s.saveSeqNums()
+}
+
+// requestResend asks the client for the messages from the one expected,
+// unless a resend is already under way.
+func (s *session) requestResend(seq uint64) {
+	if s.resendTo > s.in {
+		return
+	}
+	s.resendTo = seq
+	s.send(newMessage(msgResendRequest).
+		add(tagBeginSeqNo, strconv.FormatUint(s.in, 10)).
+		add(tagEndSeqNo, "0"))
+}
+
+// resend sends again the application messages a ResendRequest asks for,
+// with PossDupFlag set, and gap fills over the session messages.
+func (s *session) resend(m message) {
+	begin, err1 := m.int(tagBeginSeqNo)
+	end, err2 := m.int(tagEndSeqNo)
+	if err1 != nil || err2 != nil || begin == 0 {
+		s.reject(m, rejectValueIncorrect, "Invalid resend range")
+		return
+	}
+	if end == 0 || end >= s.out {
+		end = s.out - 1
+	}
+	if begin > end {
+		return
+	}
+	sent, err := s.store.sent(begin, end)
+	if err != nil {
+		log.Printf("FIX session %s: %v", s.id, err)
+	}
+
+	next := begin
+	now := s.acceptor.now()
+	for _, m := range sent {
+		seq := m.seqNum()
+		if seq > next {
+			s.gapFill(next, seq)
+		}
+		m = m.set(tagPossDupFlag, "Y").set(tagOrigSendingTime, m.str(tagSendingTime))
+		s.write(m.header(s.acceptor.compID, s.id, seq, now).encode())
+		next = seq + 1
+	}
+	if next <= end {
+		s.gapFill(next, end+1)
+	}
+}
+
+// gapFill tells the client to skip from seq to next.
+func (s *session) gapFill(seq, next uint64) {
+	m := newMessage(msgSequenceReset).
+		add(tagPossDupFlag, "Y").
+		add(tagGapFillFlag, "Y").
+		add(tagNewSeqNo, strconv.FormatUint(next, 10))
+	s.write(m.header(s.acceptor.compID, s.id, seq, s.acceptor.now()).encode())
+}
+
+func (s *session) logout(text string) {
+	m := newMessage(msgLogout)
+	if text != "" {
+		m = m.add(tagText, text)
+	}
+	s.send(m)
+}
+
+// reject refuses a message the session layer cannot handle.
+func (s *session) reject(ref message, reason, text string) {
+	s.send(newMessage(msgReject).
+		add(tagRefSeqNum, ref.str(tagMsgSeqNum)).
+		add(tagRefMsgType, ref.msgType()).
+		add(tagSessionRejectReason, reason).
+		add(tagText, text))
+}
+
+// send numbers a message and sends it if the client is logged on.
+// Application messages are kept, so that they can be resent even to a
+// client that was logged out when they were sent.
+// Must be called with the lock held.
+func (s *session) send(m message) {
+	seq := s.out
+	data := m.header(s.acceptor.compID, s.id, seq, s.acceptor.now()).encode()
+	s.out++
+	if !isAdmin(m.msgType()) {
+		if err := s.store.save(seq, data); err != nil {
+			log.Printf("FIX session %s: saving message: %v", s.id, err)
+		}
+	}
+	s.saveSeqNums()
+	s.write(data)
+}
+
+// write sends bytes to the client, if it is logged on. A failed write
+// drops the connection.
+// Must be called with the lock held.
+func (s *session) write(data []byte) {
+	c := s.conn
+	if c == nil {
+		return
+	}
+	c.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.Write(data); err != nil {
+		c.Close()
+		return
+	}
+	c.lastSent.Store(time.Now().UnixNano())
+}
+
+// Must be called with the lock held.
+func (s *session) saveSeqNums() {
+	if err := s.store.setSeqNums(s.out, s.in); err != nil {
+		log.Printf("FIX session %s: saving sequence numbers: %v", s.id, err)
+	}
+}
+
+// close stops the event subscriptions and closes the store.
+func (s *session) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for _, w := range s.watched {
+		w.sub.Close()
+	}
+	return s.store.close()
+}
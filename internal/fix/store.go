@@ -0,0 +1,184 @@
+package fix
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	seqNumsFile  = "seqnums"
+	messagesFile = "messages"
+)
+
+// store persists a session under its own directory: the next sequence
+// numbers to send and to expect, and every application message sent, so
+// that they can be resent after a restart. An index of where each message
+// starts in the file lets a resend read only the messages it asks for.
+type store struct {
+	dir      string
+	messages *os.File
+	size     int64   // Of the message file
+	index    []entry // In sequence number order
+}
+
+// entry locates a sent message in the message file.
+type entry struct {
+	seq    uint64
+	offset int64
+}
+
+// openStore opens the store of a session, creating it if necessary, and
+// indexes the messages it holds.
+func openStore(dir string) (*store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	messages, err := os.OpenFile(filepath.Join(dir, messagesFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &store{dir: dir, messages: messages}
+	if err := s.reindex(); err != nil {
+		messages.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// reindex reads the message file through to rebuild the index. A message
+// torn by a crash is cut off the end of the file.
+func (s *store) reindex() error {
+	info, err := s.messages.Stat()
+	if err != nil {
+		return err
+	}
+
+	counted := &countingReader{r: io.NewSectionReader(s.messages, 0, info.Size())}
+	r := bufio.NewReader(counted)
+	for {
+		offset := counted.n - int64(r.Buffered())
+		m, err := readMessage(r)
+		if err != nil {
+			s.size = offset
+			if offset < info.Size() {
+				return s.messages.Truncate(offset)
+			}
+			return nil
+		}
+		s.index = append(s.index, entry{seq: m.seqNum(), offset: offset})
+	}
+}
+
+// seqNums returns the next sequence numbers to send and to expect, both 1
+// for a new session.
+func (s *store) seqNums() (out, in uint64, err error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, seqNumsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 1, 1, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(string(data), "%d %d", &out, &in); err != nil {
+		return 0, 0, fmt.Errorf("reading sequence numbers: %w", err)
+	}
+	return out, in, nil
+}
+
+// setSeqNums replaces the sequence numbers, atomically and durably so that
+// a crash leaves either the old or the new ones: the temporary file is
+// synced before it is renamed over them, and the directory after.
+func (s *store) setSeqNums(out, in uint64) error {
+	path := filepath.Join(s.dir, seqNumsFile)
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(tmp, "%d %d\n", out, in)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	d, err := os.Open(s.dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// save appends a message sent with sequence number seq. What a failed
+// write leaves of it is cut off again.
+func (s *store) save(seq uint64, data []byte) error {
+	if _, err := s.messages.Write(data); err != nil {
+		s.messages.Truncate(s.size)
+		return err
+	}
+	s.index = append(s.index, entry{seq: seq, offset: s.size})
+	s.size += int64(len(data))
+	return nil
+}
+
+// sent returns the messages sent with sequence numbers from begin to end,
+// reading from the first of them on.
+func (s *store) sent(begin, end uint64) ([]message, error) {
+	first := sort.Search(len(s.index), func(i int) bool { return s.index[i].seq >= begin })
+	if first == len(s.index) {
+		return nil, nil
+	}
+	offset := s.index[first].offset
+
+	var found []message
+	r := bufio.NewReader(io.NewSectionReader(s.messages, offset, s.size-offset))
+	for _, e := range s.index[first:] {
+		if e.seq > end {
+			break
+		}
+		m, err := readMessage(r)
+		if err != nil {
+			return found, fmt.Errorf("reading sent messages: %w", err)
+		}
+		found = append(found, m)
+	}
+	return found, nil
+}
+
+// reset forgets every message sent, for a session starting over from
+// sequence number 1.
+func (s *store) reset() error {
+	if err := s.messages.Truncate(0); err != nil {
+		return err
+	}
+	s.size, s.index = 0, nil
+	return s.setSeqNums(1, 1)
+}
+
+func (s *store) close() error {
+	return s.messages.Close()
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
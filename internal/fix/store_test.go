@@ -0,0 +1,68 @@
+package fix
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func seqs(t *testing.T, s *store, begin, end uint64) []uint64 {
+	t.Helper()
+	sent, err := s.sent(begin, end)
+	if err != nil {
+		t.Fatalf("Failed to read sent messages: %v", err)
+	}
+	var found []uint64
+	for _, m := range sent {
+		found = append(found, m.seqNum())
+	}
+	return found
+}
+
+func TestStore_Sent(t *testing.T) {
+	dir := t.TempDir()
+	s, err := openStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	for _, seq := range []uint64{1, 2, 4, 5} {
+		data := newMessage(msgExecutionReport).header(DefaultCompID, "CLIENT", seq, time.Unix(0, 0)).encode()
+		if err := s.save(seq, data); err != nil {
+			t.Fatalf("Failed to save message: %v", err)
+		}
+	}
+	if found := seqs(t, s, 2, 4); !reflect.DeepEqual(found, []uint64{2, 4}) {
+		t.Errorf("Expected messages 2 and 4, got %v", found)
+	}
+	if found := seqs(t, s, 6, 9); found != nil {
+		t.Errorf("Expected no messages past the last, got %v", found)
+	}
+	s.close()
+
+	// A message torn by a crash is dropped on reopening, and the index
+	// rebuilt from the rest
+	file, _ := os.OpenFile(filepath.Join(dir, messagesFile), os.O_WRONLY|os.O_APPEND, 0)
+	file.Write(newMessage(msgExecutionReport).header(DefaultCompID, "CLIENT", 6, time.Unix(0, 0)).encode()[:20])
+	file.Close()
+	s, err = openStore(dir)
+	if err != nil {
+		t.Fatalf("Failed to reopen store: %v", err)
+	}
+	defer s.close()
+	data := newMessage(msgExecutionReport).header(DefaultCompID, "CLIENT", 6, time.Unix(0, 0)).encode()
+	if err := s.save(6, data); err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+	if found := seqs(t, s, 3, 0xffff); !reflect.DeepEqual(found, []uint64{4, 5, 6}) {
+		t.Errorf("Expected messages 4 to 6, got %v", found)
+	}
+
+	if err := s.reset(); err != nil {
+		t.Fatalf("Failed to reset store: %v", err)
+	}
+	if found := seqs(t, s, 1, 0xffff); found != nil {
+		t.Errorf("Expected no messages after a reset, got %v", found)
+	}
+}
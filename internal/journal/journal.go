@@ -0,0 +1,533 @@
+// Package journal implements an append-only log of checksummed records,
+// split across segment files in a directory.
+//
+// Records are numbered from 1 by their log sequence number (LSN). Each
+// segment file is named after the LSN of its first record and stores each
+// record as a 4 byte little endian payload length, the 4 byte CRC-32C of the
+// payload, and the payload itself. A record cut short by a crash at the end
+// of the last segment is discarded when the journal is opened.
+package journal
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	headerSize     = 8
+	maxRecordSize  = 64 << 20
+	segmentSuffix  = ".wal"
+	segmentNameLen = 20
+)
+
+var (
+	ErrCorrupt           = errors.New("Journal record is corrupt")
+	ErrClosed            = errors.New("Journal is closed")
+	ErrInvalidSyncPolicy = errors.New("Invalid sync policy")
+	ErrFailed            = errors.New("Journal failed to sync")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// SyncPolicy decides when appended records are flushed to stable storage.
+type SyncPolicy int
+
+const (
+	SyncAlways   SyncPolicy = iota // fsync after every record
+	SyncInterval                   // fsync in the background every interval
+	SyncNever                      // Leave flushing to the operating system
+)
+
+// ParseSyncPolicy parses "always", "interval" or "never".
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch s {
+	case "always":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "never":
+		return SyncNever, nil
+	}
+	return 0, ErrInvalidSyncPolicy
+}
+
+// Option configures a Journal when it is opened.
+type Option func(*Journal)
+
+// WithSyncPolicy sets when records are fsynced. Defaults to SyncAlways.
+func WithSyncPolicy(policy SyncPolicy) Option {
+	return func(j *Journal) {
+		j.policy = policy
+	}
+}
+
+// WithSyncInterval sets how often SyncInterval fsyncs. Defaults to 100ms.
+func WithSyncInterval(interval time.Duration) Option {
+	return func(j *Journal) {
+		j.interval = interval
+	}
+}
+
+// WithSegmentSize sets the size after which appends start a new segment.
+// Defaults to 64MB.
+func WithSegmentSize(size int64) Option {
+	return func(j *Journal) {
+		j.segmentSize = size
+	}
+}
+
+// segment is one file of the journal.
+type segment struct {
+	first uint64 // LSN of its first record
+	path  string
+}
+
+// Journal is an append-only record log safe for concurrent use.
+type Journal struct {
+	mu          sync.Mutex
+	dir         string
+	segments    []segment // Ordered by first LSN, the last one is written to
+	file        *os.File  // Last segment
+	size        int64     // Offset just past the last valid record of file
+	lsn         uint64    // LSN of the last record
+	policy      SyncPolicy
+	interval    time.Duration
+	segmentSize int64
+	dirty       bool  // Records appended since the last fsync
+	failed      error // Why an fsync failed, after which nothing is appended
+	closed      bool
+	done        chan struct{}
+	wg          sync.WaitGroup
+}
+
+// Open opens the journal in dir, creating it if needed, and positions it
+// after the last valid record. A torn record at the end of the last segment
+// is truncated away; any other damage is reported as ErrCorrupt.
+func Open(dir string, opts ...Option) (*Journal, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	j := &Journal{
+		dir:         dir,
+		policy:      SyncAlways,
+		interval:    100 * time.Millisecond,
+		segmentSize: 64 << 20,
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+
+	if err := j.recover(); err != nil {
+		if j.file != nil {
+			j.file.Close()
+		}
+		return nil, err
+	}
+
+	if j.policy == SyncInterval {
+		j.wg.Add(1)
+		go j.syncLoop()
+	}
+	return j, nil
+}
+
+// recover loads the segment list, checks that the segments follow on from
+// each other and opens the last one for appending.
+func (j *Journal) recover() error {
+	segments, err := listSegments(j.dir)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return j.createSegment(1)
+	}
+
+	next := segments[0].first
+	for i, seg := range segments {
+		if seg.first != next {
+			return fmt.Errorf("%w: segment %s does not follow LSN %d", ErrCorrupt, filepath.Base(seg.path), next-1)
+		}
+
+		last := i == len(segments)-1
+		flags := os.O_RDONLY
+		if last {
+			flags = os.O_RDWR
+		}
+		file, err := os.OpenFile(seg.path, flags, 0)
+		if err != nil {
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return err
+		}
+
+		var count uint64
+		end, err := scan(io.NewSectionReader(file, 0, info.Size()), info.Size(), func([]byte) error {
+			count++
+			return nil
+		})
+		if err == nil && end < info.Size() && !last {
+			err = fmt.Errorf("%w: segment %s is truncated", ErrCorrupt, filepath.Base(seg.path))
+		}
+		if err != nil {
+			file.Close()
+			return err
+		}
+		next += count
+
+		if !last {
+			file.Close()
+			continue
+		}
+		if end < info.Size() {
+			if err := file.Truncate(end); err != nil {
+				file.Close()
+				return err
+			}
+			if err := file.Sync(); err != nil {
+				file.Close()
+				return err
+			}
+		}
+		if _, err := file.Seek(end, io.SeekStart); err != nil {
+			file.Close()
+			return err
+		}
+		j.file, j.size = file, end
+	}
+
+	j.segments = segments
+	j.lsn = next - 1
+	return nil
+}
+
+// createSegment starts a new segment whose first record will have LSN first.
+func (j *Journal) createSegment(first uint64) error {
+	path := filepath.Join(j.dir, segmentName(first))
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := syncDir(j.dir); err != nil {
+		file.Close()
+		return err
+	}
+
+	j.segments = append(j.segments, segment{first: first, path: path})
+	j.file, j.size = file, 0
+	j.lsn = first - 1
+	return nil
+}
+
+// Append writes one record. With SyncAlways it returns once the record is on
+// stable storage. Once an fsync failed, what the file holds is unknown, so
+// the record is dropped and this and every later Append returns ErrFailed.
+func (j *Journal) Append(payload []byte) error {
+	if len(payload) > maxRecordSize {
+		return fmt.Errorf("Journal record of %d bytes is too large", len(payload))
+	}
+
+	buf := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(buf[4:8], crc32.Checksum(payload, castagnoli))
+	copy(buf[headerSize:], payload)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrClosed
+	}
+	if j.failed != nil {
+		return j.failed
+	}
+	if j.size > 0 && j.size+int64(len(buf)) > j.segmentSize {
+		if err := j.roll(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := j.file.Write(buf); err != nil {
+		j.drop()
+		return err
+	}
+
+	if j.policy == SyncAlways {
+		if err := j.file.Sync(); err != nil {
+			// The caller treats the record as never written, so replay
+			// must not find it
+			j.drop()
+			return j.fail(err)
+		}
+	} else {
+		j.dirty = true
+	}
+	j.size += int64(len(buf))
+	j.lsn++
+	return nil
+}
+
+// drop truncates whatever part of a record made it to the file after the
+// last one.
+// Must be called with the lock held.
+func (j *Journal) drop() {
+	j.file.Truncate(j.size)
+	j.file.Seek(j.size, io.SeekStart)
+}
+
+// fail records a failed fsync and returns it as ErrFailed.
+// Must be called with the lock held.
+func (j *Journal) fail(err error) error {
+	j.failed = fmt.Errorf("%w: %v", ErrFailed, err)
+	return j.failed
+}
+
+// roll seals the current segment and starts the next one.
+// Must be called with the lock held.
+func (j *Journal) roll() error {
+	if err := j.file.Sync(); err != nil {
+		return j.fail(err)
+	}
+	j.dirty = false
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	return j.createSegment(j.lsn + 1)
+}
+
+// LastLSN returns the LSN of the last record, or the LSN before the first
+// segment if the journal holds no records.
+func (j *Journal) LastLSN() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.lsn
+}
+
+// FirstLSN returns the LSN of the oldest record still kept.
+func (j *Journal) FirstLSN() uint64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.segments[0].first
+}
+
+// Replay calls fn with the payload of every record after LSN after, in the
+// order they were appended, stopping at the first error fn returns.
+func (j *Journal) Replay(after uint64, fn func(payload []byte) error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrClosed
+	}
+
+	for i, seg := range j.segments {
+		if i+1 < len(j.segments) && j.segments[i+1].first <= after+1 {
+			continue // Every record of this segment is at or before after
+		}
+
+		file := j.file
+		size := j.size
+		if i+1 < len(j.segments) {
+			f, err := os.Open(seg.path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			info, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			file, size = f, info.Size()
+		}
+
+		lsn := seg.first
+		_, err := scan(io.NewSectionReader(file, 0, size), size, func(payload []byte) error {
+			defer func() { lsn++ }()
+			if lsn <= after {
+				return nil
+			}
+			return fn(payload)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact deletes the segments whose records all have an LSN of at most
+// upTo, typically the LSN covered by the latest snapshot. The segment being
+// written to is always kept.
+func (j *Journal) Compact(upTo uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.closed {
+		return ErrClosed
+	}
+
+	var removed int
+	for removed+1 < len(j.segments) && j.segments[removed+1].first-1 <= upTo {
+		if err := os.Remove(j.segments[removed].path); err != nil {
+			return err
+		}
+		removed++
+	}
+	if removed == 0 {
+		return nil
+	}
+	j.segments = append([]segment(nil), j.segments[removed:]...)
+	return syncDir(j.dir)
+}
+
+// Sync flushes appended records to stable storage. If that fails, appends
+// fail with ErrFailed from then on.
+func (j *Journal) Sync() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.sync()
+}
+
+// Must be called with the lock held.
+func (j *Journal) sync() error {
+	if j.closed || !j.dirty {
+		return nil
+	}
+	j.dirty = false
+	if err := j.file.Sync(); err != nil {
+		return j.fail(err)
+	}
+	return nil
+}
+
+// Close syncs and closes the journal.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	if j.closed {
+		j.mu.Unlock()
+		return nil
+	}
+	err := j.sync()
+	j.closed = true
+	close(j.done)
+	j.mu.Unlock()
+
+	j.wg.Wait()
+	if cerr := j.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (j *Journal) syncLoop() {
+	defer j.wg.Done()
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.done:
+			return
+		case <-ticker.C:
+			j.Sync()
+		}
+	}
+}
+
+// scan reads records from r, which holds size bytes, passing each payload to
+// fn. It returns the offset just past the last complete record. A checksum
+// mismatch on the final record is treated as a torn write, anywhere else as
+// corruption.
+func scan(r io.Reader, size int64, fn func([]byte) error) (int64, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, headerSize)
+	var offset int64
+
+	for offset < size {
+		if _, err := io.ReadFull(br, header); err != nil {
+			return offset, nil // Torn header
+		}
+		length := int64(binary.LittleEndian.Uint32(header[0:4]))
+		checksum := binary.LittleEndian.Uint32(header[4:8])
+		if length > maxRecordSize {
+			return offset, fmt.Errorf("%w at offset %d", ErrCorrupt, offset)
+		}
+		end := offset + headerSize + length
+		if end > size {
+			return offset, nil // Torn payload
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return offset, err
+		}
+		if crc32.Checksum(payload, castagnoli) != checksum {
+			if end == size {
+				return offset, nil // Torn write of the last record
+			}
+			return offset, fmt.Errorf("%w at offset %d", ErrCorrupt, offset)
+		}
+
+		if err := fn(payload); err != nil {
+			return offset, err
+		}
+		offset = end
+	}
+	return offset, nil
+}
+
+// listSegments returns the segment files of dir ordered by first LSN.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil || first == 0 {
+			continue
+		}
+		segments = append(segments, segment{first: first, path: filepath.Join(dir, name)})
+	}
+	sort.Slice(segments, func(a, b int) bool { return segments[a].first < segments[b].first })
+	return segments, nil
+}
+
+func segmentName(first uint64) string {
+	return fmt.Sprintf("%0*d%s", segmentNameLen, first, segmentSuffix)
+}
+
+// syncDir makes the creation and removal of segment files durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,198 @@
+package journal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournal_AppendAndReplay(t *testing.T) {
+	policies := []SyncPolicy{SyncAlways, SyncInterval, SyncNever}
+	for _, policy := range policies {
+		path := filepath.Join(t.TempDir(), "journal")
+		j, err := Open(path, WithSyncPolicy(policy))
+		if err != nil {
+			t.Fatalf("Failed to open journal: %v", err)
+		}
+		records := [][]byte{[]byte("first"), {}, []byte("third")}
+		for _, record := range records {
+			if err := j.Append(record); err != nil {
+				t.Fatalf("Failed to append: %v", err)
+			}
+		}
+		if err := j.Close(); err != nil {
+			t.Fatalf("Failed to close: %v", err)
+		}
+
+		// Reopening appends after the existing records
+		j, err = Open(path, WithSyncPolicy(policy))
+		if err != nil {
+			t.Fatalf("Failed to reopen journal: %v", err)
+		}
+		j.Append([]byte("fourth"))
+		got := replayAll(t, j)
+		j.Close()
+
+		want := append(records, []byte("fourth"))
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Policy %d: expected %q, got %q", policy, want, got)
+		}
+	}
+}
+
+func TestJournal_SyncFailure(t *testing.T) {
+	j, err := Open(filepath.Join(t.TempDir(), "journal"), WithSyncPolicy(SyncAlways))
+	if err != nil {
+		t.Fatalf("Failed to open journal: %v", err)
+	}
+	defer j.Close()
+	j.Append([]byte("first"))
+
+	// Writes to a pipe succeed, but it cannot be fsynced
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	defer r.Close()
+	file := j.file
+	j.file = w
+	err = j.Append([]byte("second"))
+	j.file = file
+	w.Close()
+
+	if !errors.Is(err, ErrFailed) {
+		t.Fatalf("Expected ErrFailed, got %v", err)
+	}
+	if lsn := j.LastLSN(); lsn != 1 {
+		t.Errorf("Expected the failed record not to count, got LSN %d", lsn)
+	}
+	if err := j.Append([]byte("third")); !errors.Is(err, ErrFailed) {
+		t.Errorf("Expected appends to keep failing, got %v", err)
+	}
+	if got := replayAll(t, j); !reflect.DeepEqual(got, [][]byte{[]byte("first")}) {
+		t.Errorf("Expected only the first record, got %q", got)
+	}
+}
+
+func TestJournal_TornTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _ := Open(path)
+	j.Append([]byte("complete"))
+	j.Append([]byte("torn record"))
+	j.Close()
+
+	// Simulate a crash part way through the last write
+	segment := filepath.Join(path, segmentName(1))
+	info, _ := os.Stat(segment)
+	if err := os.Truncate(segment, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	j, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected a torn tail to be recovered, got %v", err)
+	}
+	defer j.Close()
+	if got := replayAll(t, j); len(got) != 1 || string(got[0]) != "complete" {
+		t.Errorf("Expected only the complete record, got %q", got)
+	}
+
+	j.Append([]byte("next"))
+	if got := replayAll(t, j); len(got) != 2 || string(got[1]) != "next" {
+		t.Errorf("Expected appends after the truncated tail, got %q", got)
+	}
+}
+
+func TestJournal_Corruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _ := Open(path)
+	j.Append([]byte("first"))
+	j.Append([]byte("second"))
+	j.Close()
+
+	// Flip a payload byte of the first record
+	segment := filepath.Join(path, segmentName(1))
+	data, _ := os.ReadFile(segment)
+	data[headerSize] ^= 0xff
+	os.WriteFile(segment, data, 0o644)
+
+	if _, err := Open(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestJournal_SegmentsAndCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal")
+	j, _ := Open(path, WithSegmentSize(2*(headerSize+4)))
+	for i := 1; i <= 7; i++ {
+		j.Append([]byte(fmt.Sprintf("r%03d", i)))
+	}
+	if j.LastLSN() != 7 || len(j.segments) != 4 {
+		t.Fatalf("Expected 7 records in 4 segments, got %d in %d", j.LastLSN(), len(j.segments))
+	}
+
+	// Only records after the given LSN are replayed
+	var tail []string
+	j.Replay(4, func(payload []byte) error {
+		tail = append(tail, string(payload))
+		return nil
+	})
+	if want := []string{"r005", "r006", "r007"}; !reflect.DeepEqual(tail, want) {
+		t.Errorf("Expected %v, got %v", want, tail)
+	}
+
+	// Segments entirely covered by LSN 5 go away, the rest stays readable
+	if err := j.Compact(5); err != nil {
+		t.Fatalf("Failed to compact: %v", err)
+	}
+	if j.FirstLSN() != 5 {
+		t.Errorf("Expected the oldest kept record to be 5, got %d", j.FirstLSN())
+	}
+	j.Close()
+
+	j, err := Open(path, WithSegmentSize(2*(headerSize+4)))
+	if err != nil {
+		t.Fatalf("Failed to reopen compacted journal: %v", err)
+	}
+	defer j.Close()
+	if got := replayAll(t, j); len(got) != 3 || string(got[0]) != "r005" || j.LastLSN() != 7 {
+		t.Errorf("Expected r005 to r007 after compaction, got %q (last %d)", got, j.LastLSN())
+	}
+	j.Append([]byte("r008"))
+	j.Append([]byte("r009"))
+	if j.LastLSN() != 9 {
+		t.Errorf("Expected LSN 9, got %d", j.LastLSN())
+	}
+
+	// A missing segment in the middle is corruption
+	j.Close()
+	os.Remove(filepath.Join(path, segmentName(7)))
+	if _, err := Open(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for a gap, got %v", err)
+	}
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	if policy, err := ParseSyncPolicy("interval"); err != nil || policy != SyncInterval {
+		t.Errorf("Expected SyncInterval, got %v, %v", policy, err)
+	}
+	if _, err := ParseSyncPolicy("sometimes"); err != ErrInvalidSyncPolicy {
+		t.Errorf("Expected ErrInvalidSyncPolicy, got %v", err)
+	}
+}
+
+func replayAll(t *testing.T, j *Journal) [][]byte {
+	t.Helper()
+	var records [][]byte
+	err := j.Replay(0, func(payload []byte) error {
+		records = append(records, payload)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to replay: %v", err)
+	}
+	return records
+}
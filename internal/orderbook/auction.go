@@ -0,0 +1,280 @@
+package orderbook
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidAuction = errors.New("Invalid auction")
+	ErrAuctionStatus  = errors.New("Book cannot start this auction in its status")
+	ErrNoAuction      = errors.New("Book is not in an auction")
+)
+
+// AuctionType is the kind of auction a book is in, which decides the status
+// it ends in.
+type AuctionType string
+
+const (
+	AuctionOpening   AuctionType = "OPENING"   // Opens a closed or halted book
+	AuctionClosing   AuctionType = "CLOSING"   // Closes an open book
+	AuctionReopening AuctionType = "REOPENING" // Opens the book after a circuit breaker halt
+)
+
+// Indicative is what an auction would execute if it uncrossed now: the
+// equilibrium price, the volume trading there and the amount left over on
+// the side with more, the imbalance. Price and Volume are zero while the
+// book is not crossed.
+type Indicative struct {
+	Auction       AuctionType `json:"auction,omitempty"`
+	Price         Decimal     `json:"price"`
+	Volume        Decimal     `json:"volume"`
+	Imbalance     Decimal     `json:"imbalance"`
+	ImbalanceSide Side        `json:"imbalance_side,omitempty"`
+	Until         *time.Time  `json:"until,omitempty"` // When the auction uncrosses, if it is timed
+}
+
+// StartAuction starts an auction in which orders rest without matching
+// until the book uncrosses, when all crossing orders trade at a single
+// price. An opening auction opens a closed or halted book and a closing
+// auction closes an open one. The auction ends after duration, or when
+// Uncross is called if duration is zero.
+func (ob *OrderBook) StartAuction(typ AuctionType, duration time.Duration) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = ob.now()
+	if err := ob.advance(); err != nil {
+		return err
+	}
+	return ob.startAuction(typ, duration)
+}
+
+// Uncross ends the auction now: the crossing orders trade at the equilibrium
+// price and the book moves to the status the auction was for. Returns the
+// trades, or ErrNoAuction if the book is not in an auction.
+func (ob *OrderBook) Uncross() ([]*Trade, error) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = ob.now()
+	if err := ob.advance(); err != nil {
+		return nil, err
+	}
+	return ob.endAuction()
+}
+
+// Indicative returns the price, volume and imbalance the auction would
+// uncross with now, or ErrNoAuction if the book is not in an auction.
+func (ob *OrderBook) Indicative() (Indicative, error) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if ob.status != BookAuction {
+		return Indicative{}, ErrNoAuction
+	}
+	indicative := ob.equilibrium(ob.now())
+	indicative.Auction = ob.auction
+	if !ob.until.IsZero() {
+		until := ob.until
+		indicative.Until = &until
+	}
+	return indicative, nil
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) startAuction(typ AuctionType, duration time.Duration) error {
+	switch {
+	case typ != AuctionOpening && typ != AuctionClosing, duration < 0:
+		return ErrInvalidAuction
+	case typ == AuctionOpening && ob.status != BookClosed && ob.status != BookHalted,
+		typ == AuctionClosing && ob.status != BookOpen:
+		return ErrAuctionStatus
+	}
+	if err := ob.record(Command{Type: CommandStartAuction, Auction: typ, Duration: duration}); err != nil {
+		return err
+	}
+
+	var until time.Time
+	if duration > 0 {
+		until = ob.at.Add(duration)
+	}
+	ob.setStatus(BookAuction, until)
+	ob.auction = typ
+	ob.indicative = Indicative{}
+	return nil
+}
+
+// endAuction uncrosses the book and moves it to the status the auction was
+// for.
+// Must be called with the lock held.
+func (ob *OrderBook) endAuction() ([]*Trade, error) {
+	if ob.status != BookAuction {
+		return nil, ErrNoAuction
+	}
+	if err := ob.record(Command{Type: CommandUncross}); err != nil {
+		return nil, err
+	}
+
+	trades := ob.uncross()
+	if ob.auction == AuctionClosing {
+		ob.setStatus(BookClosed, time.Time{})
+	} else {
+		ob.setStatus(BookOpen, time.Time{})
+		ob.triggerStops()
+	}
+	return trades, nil
+}
+
+// publishIndicative announces the indicative price, volume and imbalance of
+// an auction when an operation changed them.
+// Must be called with the lock held.
+func (ob *OrderBook) publishIndicative() {
+	if ob.status != BookAuction {
+		return
+	}
+	indicative := ob.equilibrium(ob.at)
+	if indicative == ob.indicative {
+		return
+	}
+	ob.indicative = indicative
+	indicative.Auction = ob.auction
+	ob.emit(Event{Type: EventIndicative, Indicative: &indicative})
+}
+
+// equilibrium returns the price a crossed book uncrosses at, the volume that
+// trades there and the imbalance left: the price executing the most volume,
+// then leaving the smallest imbalance between what is bid and offered at it,
+// then closest to the last trade price, then the lowest. The volume is zero
+// when the book is not crossed. Hidden iceberg amounts take part, orders
+// expired at the time given do not.
+// Must be called with the lock held.
+func (ob *OrderBook) equilibrium(at time.Time) Indicative {
+	bid, ask := ob.bids.best(), ob.asks.best()
+	if bid == nil || ask == nil || bid.price < ask.price {
+		return Indicative{}
+	}
+
+	// Only levels inside the crossed range can trade
+	bids := crossingAmounts(ob.bids, at, func(p Decimal) bool { return p >= ask.price })
+	asks := crossingAmounts(ob.asks, at, func(p Decimal) bool { return p <= bid.price })
+	var prices []Decimal
+	for _, level := range append(bids, asks...) {
+		prices = append(prices, level.price)
+	}
+	slices.Sort(prices)
+	prices = slices.Compact(prices)
+
+	var best Indicative
+	for _, p := range prices {
+		var demand, supply Decimal
+		for _, level := range bids {
+			if level.price >= p {
+				demand += level.amount
+			}
+		}
+		for _, level := range asks {
+			if level.price <= p {
+				supply += level.amount
+			}
+		}
+		executable, left := demand.Min(supply), abs(demand-supply)
+
+		switch {
+		case executable > best.Volume,
+			executable == best.Volume && left < best.Imbalance,
+			executable == best.Volume && left == best.Imbalance && ob.lastPrice > 0 && abs(p-ob.lastPrice) < abs(best.Price-ob.lastPrice):
+			best = Indicative{Price: p, Volume: executable, Imbalance: left}
+			switch {
+			case demand > supply:
+				best.ImbalanceSide = Buy
+			case supply > demand:
+				best.ImbalanceSide = Sell
+			}
+		}
+	}
+	return best
+}
+
+// levelAmount is the full amount of unexpired orders at a price.
+type levelAmount struct {
+	price  Decimal
+	amount Decimal
+}
+
+// crossingAmounts returns the amounts of a side at the prices, from the
+// best, that cross the best opposite price.
+func crossingAmounts(side *bookSide, at time.Time, crosses func(Decimal) bool) []levelAmount {
+	var levels []levelAmount
+	side.each(func(level *priceLevel) bool {
+		if !crosses(level.price) {
+			return false
+		}
+		total := levelAmount{price: level.price}
+		for n := level.head; n != nil; n = n.next {
+			if !n.order.expired(at) {
+				total.amount += n.order.Amount
+			}
+		}
+		levels = append(levels, total)
+		return true
+	})
+	return levels
+}
+
+// uncross executes a crossed book at its equilibrium price, pairing bids and
+// asks in price then time priority. The later order of each pair is the
+// taker. Orders of the same account do not trade together; the later one is
+// cancelled instead.
+// Must be called with the lock held.
+func (ob *OrderBook) uncross() []*Trade {
+	price := ob.equilibrium(ob.at).Price
+	if price == 0 {
+		return nil
+	}
+
+	var trades []*Trade
+	for {
+		bid, ask := ob.bestLevel(ob.bids, ob.at), ob.bestLevel(ob.asks, ob.at)
+		if bid == nil || ask == nil || bid.price < price || ask.price > price {
+			return trades
+		}
+
+		taker, maker := bid.head, ask.head
+		if taker.seq < maker.seq {
+			taker, maker = maker, taker
+		}
+		if selfTrade(&taker.order, &maker.order) {
+			ob.remove(taker)
+			ob.emitOrder(EventCancelled, taker.order, 0, taker.order.Amount)
+			continue
+		}
+
+		amount := taker.visible.Min(maker.visible)
+		trade := ob.createTrade(&taker.order, &maker.order, price, amount)
+		trades = append(trades, trade)
+		ob.lastPrice = price
+		ob.window.add(ob.at, price)
+
+		// The taker rests too, so it pays out of its reservation
+		filled := taker.order
+		filled.Amount -= amount
+		ob.rehold(&taker.order, &filled)
+		ob.settle(trade, &taker.order, &maker.order)
+		ob.fill(taker, amount)
+		ob.fill(maker, amount)
+
+		ob.emitFill(maker.order, maker.order.Amount, amount)
+		ob.emitFill(taker.order, taker.order.Amount, amount)
+	}
+}
+
+func abs(d Decimal) Decimal {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
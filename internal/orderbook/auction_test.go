@@ -0,0 +1,150 @@
+package orderbook
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCallAuctions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithClock(clock), WithJournal(j))
+	ob.Close()
+
+	errorTests := []struct {
+		name     string
+		typ      AuctionType
+		duration time.Duration
+		err      error
+	}{
+		{"Closing a closed book", AuctionClosing, 0, ErrAuctionStatus},
+		{"Unknown kind", "LUNCH", 0, ErrInvalidAuction},
+		{"Reopening", AuctionReopening, 0, ErrInvalidAuction},
+		{"Negative duration", AuctionOpening, -time.Minute, ErrInvalidAuction},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ob.StartAuction(tt.typ, tt.duration); err != tt.err {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+	if _, err := ob.Uncross(); err != ErrNoAuction {
+		t.Errorf("Expected ErrNoAuction, got %v", err)
+	}
+	if _, err := ob.Indicative(); err != ErrNoAuction {
+		t.Errorf("Expected ErrNoAuction, got %v", err)
+	}
+
+	// The opening auction collects orders until it is uncrossed
+	if err := ob.StartAuction(AuctionOpening, 0); err != nil {
+		t.Fatalf("Failed to start the opening auction: %v", err)
+	}
+	if info := ob.StatusInfo(); info.Status != BookAuction || info.Auction != AuctionOpening || info.Until != nil {
+		t.Errorf("Expected an untimed opening auction, got %+v", info)
+	}
+	sub := ob.Subscribe()
+	defer sub.Close()
+	ob.PlaceOrder(Order{ID: "b1", Price: dec(101.0), Amount: dec(2.0), Side: Buy})
+	ob.PlaceOrder(Order{ID: "a1", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "a2", Price: dec(101.0), Amount: dec(3.0), Side: Sell})
+
+	expected := Indicative{Auction: AuctionOpening, Price: dec(101.0), Volume: dec(2.0), Imbalance: dec(2.0), ImbalanceSide: Sell}
+	if indicative, err := ob.Indicative(); err != nil || indicative != expected {
+		t.Errorf("Expected %+v, got %+v, %v", expected, indicative, err)
+	}
+	events := receive(t, sub, 11)
+	if last := events[10]; last.Type != EventIndicative || last.Indicative == nil || *last.Indicative != expected {
+		t.Errorf("Expected the indicative price to be published, got %+v", last)
+	}
+
+	trades, err := ob.Uncross()
+	if err != nil || len(trades) != 2 {
+		t.Fatalf("Expected two trades, got %v, %v", trades, err)
+	}
+	for _, trade := range trades {
+		if trade.Price != dec(101.0) || trade.TakerOrderID != "a1" && trade.TakerOrderID != "a2" {
+			t.Errorf("Expected the asks to trade at 101, got %+v", trade)
+		}
+	}
+	if status := ob.Status(); status != BookOpen {
+		t.Errorf("Expected the book to open, got %s", status)
+	}
+
+	// The closing auction uncrosses when its time is up and closes the book
+	if err := ob.StartAuction(AuctionClosing, time.Minute); err != nil {
+		t.Fatalf("Failed to start the closing auction: %v", err)
+	}
+	ob.PlaceOrder(Order{ID: "b2", Price: dec(102.0), Amount: dec(1.0), Side: Buy})
+	if best, _ := ob.GetBestBid(); best.ID != "b2" {
+		t.Errorf("Expected the bid to rest crossed, got %+v", best)
+	}
+
+	var buf bytes.Buffer
+	ob.WriteSnapshot(&buf)
+	restored := NewOrderBook("TEST", WithClock(clock))
+	if _, err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if info := restored.StatusInfo(); !reflect.DeepEqual(info, ob.StatusInfo()) || info.Auction != AuctionClosing {
+		t.Errorf("Expected the closing auction to be restored, got %+v", info)
+	}
+
+	now = now.Add(time.Minute)
+	ob.Advance(now)
+	if status := ob.Status(); status != BookClosed {
+		t.Errorf("Expected the book to close, got %s", status)
+	}
+	// Both prices trade one, so the one nearest the last trade wins
+	if price := ob.LastPrice(); price != dec(101.0) {
+		t.Errorf("Expected the close at 101, got %v", price)
+	}
+
+	replayed := NewOrderBook("TEST")
+	for _, record := range j.records {
+		if err := replayed.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+	got, want := replayed.GetOrderBookSnapshot(), ob.GetOrderBookSnapshot()
+	if replayed.Status() != BookClosed || replayed.LastPrice() != ob.LastPrice() || !reflect.DeepEqual(got.Asks, want.Asks) || !reflect.DeepEqual(got.Bids, want.Bids) {
+		t.Errorf("Replayed book differs: %s at %v", replayed.Status(), replayed.LastPrice())
+	}
+}
+
+func TestCallAuctions_TriggeredStop(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "a1", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	ob.ProcessOrder(Order{ID: "b1", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	ob.Close()
+	if err := ob.StartAuction(AuctionOpening, 0); err != nil {
+		t.Fatalf("Failed to start the opening auction: %v", err)
+	}
+
+	// The last price has reached the stop, which still waits for the auction
+	result, err := ob.ProcessOrder(Order{ID: "stop", Type: Stop, StopPrice: dec(99.0), Amount: dec(1.0), Side: Buy})
+	if err != nil {
+		t.Fatalf("Failed to process the stop order: %v", err)
+	}
+	if result.Status != StatusUntriggered || len(ob.bids.orders()) != 0 {
+		t.Errorf("Expected the stop to wait untriggered outside the book, got %+v and bids %v", result, ob.bids.orders())
+	}
+
+	ob.PlaceOrder(Order{ID: "a2", Price: dec(100.0), Amount: dec(2.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "b2", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	trades, err := ob.Uncross()
+	if err != nil || len(trades) != 1 || trades[0].Price != dec(100.0) {
+		t.Fatalf("Expected one trade at 100, got %v, %v", trades, err)
+	}
+
+	// Once trading, the stop triggers and buys what the auction left
+	if _, err := ob.GetOrder("stop"); err != ErrOrderNotFound {
+		t.Errorf("Expected the stop to trigger after the auction, got %v", err)
+	}
+	if len(ob.asks.orders()) != 0 {
+		t.Errorf("Expected the triggered stop to fill the last ask, got %v", ob.asks.orders())
+	}
+}
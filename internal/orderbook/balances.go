@@ -0,0 +1,282 @@
+package orderbook
+
+import "errors"
+
+var (
+	ErrBalancesDisabled    = errors.New("Balances are not enabled for this book")
+	ErrAccountRequired     = errors.New("Order needs an account")
+	ErrNotionalRequired    = errors.New("Market buy orders need a max notional")
+	ErrInsufficientBalance = errors.New("Insufficient available balance")
+	ErrInvalidTransfer     = errors.New("Invalid transfer amount")
+)
+
+// Asset names one of the two sides of the instrument a book trades.
+type Asset string
+
+const (
+	Base  Asset = "BASE"  // What orders buy and sell, counted in amounts
+	Quote Asset = "QUOTE" // What prices are in, counted in price * amount
+)
+
+// Balance is the funds an account holds in an asset. Reserved funds back the
+// resting and stop orders of the account and cannot be withdrawn or used by
+// other orders.
+type Balance struct {
+	Available Decimal `json:"available"`
+	Reserved  Decimal `json:"reserved"`
+}
+
+// AccountBalances are the funds an account holds in a book. Each book keeps
+// its own, so funds deposited in one market only pay for orders in it.
+type AccountBalances struct {
+	Base  Balance `json:"base"`
+	Quote Balance `json:"quote"`
+}
+
+// asset returns the balance of an asset.
+func (b *AccountBalances) asset(asset Asset) *Balance {
+	if asset == Base {
+		return &b.Base
+	}
+	return &b.Quote
+}
+
+// Deposit credits the available balance of an account.
+// Returns ErrBalancesDisabled unless the book was created WithBalances.
+func (ob *OrderBook) Deposit(account string, asset Asset, amount Decimal) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.at = ob.now()
+	return ob.transfer(CommandDeposit, account, asset, amount)
+}
+
+// Withdraw debits the available balance of an account. Returns
+// ErrInsufficientBalance if less than amount is available.
+func (ob *OrderBook) Withdraw(account string, asset Asset, amount Decimal) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.at = ob.now()
+	return ob.transfer(CommandWithdraw, account, asset, amount)
+}
+
+// transfer applies a deposit or a withdrawal.
+// Must be called with the lock held.
+func (ob *OrderBook) transfer(typ CommandType, account string, asset Asset, amount Decimal) error {
+	if ob.balances == nil {
+		return ErrBalancesDisabled
+	}
+	if account == "" || (asset != Base && asset != Quote) || amount <= 0 {
+		return ErrInvalidTransfer
+	}
+	if amount.Precision() > ob.assetPrecision(asset) {
+		return ErrInvalidPrecision
+	}
+	if typ == CommandWithdraw && ob.available(account, asset) < amount {
+		return ErrInsufficientBalance
+	}
+	if err := ob.record(Command{Type: typ, Account: account, Asset: asset, Amount: amount}); err != nil {
+		return err
+	}
+
+	if typ == CommandWithdraw {
+		amount = -amount
+	}
+	ob.accountBalances(account).asset(asset).Available += amount
+	return nil
+}
+
+// assetPrecision returns how many fractional digits amounts of an asset have.
+func (ob *OrderBook) assetPrecision(asset Asset) int {
+	if asset == Base {
+		return ob.spec.AmountPrecision
+	}
+	return DecimalPlaces
+}
+
+// Balances returns the funds an account holds in the book.
+func (ob *OrderBook) Balances(account string) AccountBalances {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if balances, ok := ob.balances[account]; ok {
+		return *balances
+	}
+	return AccountBalances{}
+}
+
+// accountBalances returns the balances of an account, creating them if
+// necessary.
+// Must be called with the lock held.
+func (ob *OrderBook) accountBalances(account string) *AccountBalances {
+	balances, ok := ob.balances[account]
+	if !ok {
+		balances = &AccountBalances{}
+		ob.balances[account] = balances
+	}
+	return balances
+}
+
+// reservation returns the funds an order holds while it rests or waits for
+// its stop price: the quote value of buys, or the max notional of stop market
+// buys, with the most they may pay in fees on it, and the amount of sells.
+// Sells pay their fees out of what they receive.
+// Must be called with the lock held.
+func (ob *OrderBook) reservation(order *Order) (Asset, Decimal) {
+	if order.Side == Sell {
+		return Base, order.Amount
+	}
+	value := order.Price.Mul(order.Amount)
+	if order.atMarket() {
+		value = order.MaxNotional
+	}
+	return Quote, value + ob.fees.worstFee(order.Account, value)
+}
+
+// checkFunds rejects an order whose account cannot afford it. A new order
+// needs its full reservation available, which also covers its trades as a
+// taker; a modified order, held as old, only what it needs on top of what it
+// already holds.
+// Must be called with the lock held.
+func (ob *OrderBook) checkFunds(order *Order, old *Order) error {
+	if ob.balances == nil {
+		return nil
+	}
+	if order.Account == "" {
+		return ErrAccountRequired
+	}
+	if order.Side == Buy && order.atMarket() && order.MaxNotional == 0 {
+		return ErrNotionalRequired
+	}
+
+	asset, needed := ob.reservation(order)
+	if old != nil {
+		_, held := ob.reservation(old)
+		needed -= held
+	}
+	if needed > 0 && ob.available(order.Account, asset) < needed {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// available returns the available balance of an account in an asset without
+// creating the account, so that rejected requests leave no trace.
+// Must be called with the lock held.
+func (ob *OrderBook) available(account string, asset Asset) Decimal {
+	balances, ok := ob.balances[account]
+	if !ok {
+		return 0
+	}
+	return balances.asset(asset).Available
+}
+
+// hold reserves the funds of an order entering the book.
+// Must be called with the lock held.
+func (ob *OrderBook) hold(order *Order) {
+	if ob.balances == nil || order.Account == "" {
+		return
+	}
+	asset, amount := ob.reservation(order)
+	balance := ob.accountBalances(order.Account).asset(asset)
+	balance.Available -= amount
+	balance.Reserved += amount
+}
+
+// release frees the funds of an order leaving the book.
+// Must be called with the lock held.
+func (ob *OrderBook) release(order *Order) {
+	if ob.balances == nil || order.Account == "" {
+		return
+	}
+	asset, amount := ob.reservation(order)
+	balance := ob.accountBalances(order.Account).asset(asset)
+	balance.Available += amount
+	balance.Reserved -= amount
+}
+
+// rehold moves the reservation of an order changed in place from what it
+// held as old to what it needs now.
+// Must be called with the lock held.
+func (ob *OrderBook) rehold(old *Order, order *Order) {
+	ob.release(old)
+	ob.hold(order)
+}
+
+// releaseAll frees the funds of every resting and stop order.
+// Must be called with the lock held.
+func (ob *OrderBook) releaseAll() {
+	for _, node := range ob.orders {
+		ob.release(&node.order)
+	}
+	for _, node := range ob.stops {
+		ob.release(&node.order)
+	}
+}
+
+// holdAll reserves the funds of every resting and stop order.
+// Must be called with the lock held.
+func (ob *OrderBook) holdAll() {
+	for _, node := range ob.orders {
+		ob.hold(&node.order)
+	}
+	for _, node := range ob.stops {
+		ob.hold(&node.order)
+	}
+}
+
+// settle moves the funds of a trade between the taker, whose funds are taken
+// from its available balance, and the maker, whose funds come out of its
+// reservation; any rounding left over in the reservation is freed. Fees are
+// paid from, or rebates paid into, the available quote balance, into which
+// the reservation of a maker frees its share of fees. Only a minimum fee
+// charged on several small trades of one order can take more than the order
+// reserved, and leave the balance short until funds are deposited.
+// Must be called with the lock held, before the maker is filled.
+func (ob *OrderBook) settle(trade *Trade, taker *Order, maker *Order) {
+	if ob.balances == nil {
+		return
+	}
+	cost := trade.Price.Mul(trade.Amount)
+
+	if taker.Account != "" {
+		balances := ob.accountBalances(taker.Account)
+		if taker.Side == Buy {
+			balances.Quote.Available -= cost
+			balances.Base.Available += trade.Amount
+		} else {
+			balances.Base.Available -= trade.Amount
+			balances.Quote.Available += cost
+		}
+		balances.Quote.Available -= trade.TakerFee
+	}
+
+	if maker.Account != "" {
+		filled := *maker
+		filled.Amount -= trade.Amount
+		ob.rehold(maker, &filled)
+
+		balances := ob.accountBalances(maker.Account)
+		if maker.Side == Buy {
+			balances.Quote.Available -= cost
+			balances.Base.Available += trade.Amount
+		} else {
+			balances.Base.Available -= trade.Amount
+			balances.Quote.Available += cost
+		}
+		balances.Quote.Available -= trade.MakerFee
+	}
+}
+
+// AllBalances returns the funds of every account holding any in the book.
+func (ob *OrderBook) AllBalances() map[string]AccountBalances {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	balances := make(map[string]AccountBalances, len(ob.balances))
+	for account, b := range ob.balances {
+		balances[account] = *b
+	}
+	return balances
+}
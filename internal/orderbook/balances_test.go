@@ -0,0 +1,179 @@
+package orderbook
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBalances_ReserveAndSettle(t *testing.T) {
+	ob := NewOrderBook("TEST", WithBalances(), WithFeeSchedule(FeeSchedule{Default: FeeRate{TakerBps: 10}}))
+	ob.Deposit("alice", Quote, dec(1000.0))
+	ob.Deposit("bob", Base, dec(5.0))
+
+	assertBalances := func(step, account string, expected AccountBalances) {
+		t.Helper()
+		if balances := ob.Balances(account); balances != expected {
+			t.Errorf("%s: expected %s to hold %+v, got %+v", step, account, expected, balances)
+		}
+	}
+
+	ob.PlaceOrder(Order{ID: "ask", Price: dec(100.0), Amount: dec(2.0), Side: Sell, Account: "bob"})
+	assertBalances("Resting ask", "bob", AccountBalances{Base: Balance{Available: dec(3.0), Reserved: dec(2.0)}})
+
+	result, err := ob.ProcessOrder(Order{ID: "bid", Price: dec(100.0), Amount: dec(3.0), Side: Buy, Account: "alice"})
+	if err != nil || result.FilledAmount != dec(2.0) {
+		t.Fatalf("Expected 2.0 filled, got %+v, %v", result, err)
+	}
+	assertBalances("Taker", "alice", AccountBalances{
+		Base:  Balance{Available: dec(2.0)},
+		Quote: Balance{Available: dec(699.7), Reserved: dec(100.1)},
+	})
+	assertBalances("Maker", "bob", AccountBalances{
+		Base:  Balance{Available: dec(3.0)},
+		Quote: Balance{Available: dec(200.0)},
+	})
+
+	// The resting bid holds the fees it may pay too. Lowering the amount
+	// frees funds, raising it needs them
+	if err := ob.ModifyOrder("bid", dec(100.0), dec(0.5)); err != nil {
+		t.Fatalf("Failed to modify: %v", err)
+	}
+	assertBalances("Reduced", "alice", AccountBalances{
+		Base:  Balance{Available: dec(2.0)},
+		Quote: Balance{Available: dec(749.75), Reserved: dec(50.05)},
+	})
+	if err := ob.ModifyOrder("bid", dec(100.0), dec(8.5)); err != ErrInsufficientBalance {
+		t.Errorf("Expected ErrInsufficientBalance, got %v", err)
+	}
+	if err := ob.ModifyOrder("bid", dec(200.0), dec(4.0)); err != ErrInsufficientBalance {
+		t.Errorf("Expected ErrInsufficientBalance, got %v", err)
+	}
+	if err := ob.ModifyOrder("bid", dec(200.0), dec(3.99)); err != nil {
+		t.Fatalf("Failed to reprice: %v", err)
+	}
+	assertBalances("Repriced", "alice", AccountBalances{
+		Base:  Balance{Available: dec(2.0)},
+		Quote: Balance{Available: dec(1.002), Reserved: dec(798.798)},
+	})
+
+	ob.CancelOrder("bid")
+	assertBalances("Cancelled", "alice", AccountBalances{
+		Base:  Balance{Available: dec(2.0)},
+		Quote: Balance{Available: dec(799.8)},
+	})
+
+	// Stop orders hold their funds while they wait
+	ob.ProcessOrder(Order{ID: "stop", Type: Stop, StopPrice: dec(90.0), Amount: dec(1.0), Side: Sell, Account: "alice"})
+	assertBalances("Stop", "alice", AccountBalances{
+		Base:  Balance{Available: dec(1.0), Reserved: dec(1.0)},
+		Quote: Balance{Available: dec(799.8)},
+	})
+}
+
+func TestBalances_Fees(t *testing.T) {
+	ob := NewOrderBook("TEST", WithBalances(), WithFeeSchedule(FeeSchedule{Default: FeeRate{TakerBps: 100}}))
+	ob.Deposit("alice", Quote, dec(100.0))
+	ob.Deposit("bob", Base, dec(2.0))
+	ob.PlaceOrder(Order{ID: "ask", Price: dec(100.0), Amount: dec(2.0), Side: Sell, Account: "bob"})
+
+	// The taker fee must be covered along with the price
+	if _, err := ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy, Account: "alice"}); err != ErrInsufficientBalance {
+		t.Fatalf("Expected ErrInsufficientBalance, got %v", err)
+	}
+	ob.Deposit("alice", Quote, dec(1.0))
+	if _, err := ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy, Account: "alice"}); err != nil {
+		t.Fatalf("Failed to buy: %v", err)
+	}
+	expected := AccountBalances{Base: Balance{Available: dec(1.0)}}
+	if balances := ob.Balances("alice"); balances != expected {
+		t.Errorf("Expected alice to hold %+v, got %+v", expected, balances)
+	}
+
+	// Resting buys hold their fees at the current rates, and free them when
+	// cancelled
+	ob.Deposit("alice", Quote, dec(50.5))
+	if err := ob.PlaceOrder(Order{ID: "bid", Price: dec(50.0), Amount: dec(1.0), Side: Buy, Account: "alice"}); err != nil {
+		t.Fatalf("Failed to place: %v", err)
+	}
+	if err := ob.SetFeeSchedule(FeeSchedule{Default: FeeRate{TakerBps: 20}}); err != nil {
+		t.Fatalf("Failed to set fees: %v", err)
+	}
+	expected.Quote = Balance{Available: dec(0.4), Reserved: dec(50.1)}
+	if balances := ob.Balances("alice"); balances != expected {
+		t.Errorf("Expected alice to hold %+v after the fee change, got %+v", expected, balances)
+	}
+	ob.CancelOrder("bid")
+	expected.Quote = Balance{Available: dec(50.5)}
+	if balances := ob.Balances("alice"); balances != expected {
+		t.Errorf("Expected alice to hold %+v after the cancel, got %+v", expected, balances)
+	}
+}
+
+func TestBalances_Rejections(t *testing.T) {
+	ob := NewOrderBook("TEST", WithBalances())
+	ob.Deposit("alice", Quote, dec(100.0))
+	ob.Deposit("alice", Base, dec(1.0))
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"No account", ob.PlaceOrder(Order{Price: dec(10.0), Amount: dec(1.0), Side: Buy}), ErrAccountRequired},
+		{"Quote short", ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.1), Side: Buy, Account: "alice"}), ErrInsufficientBalance},
+		{"Base short", ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.1), Side: Sell, Account: "alice"}), ErrInsufficientBalance},
+		{"Unknown account", ob.PlaceOrder(Order{Price: dec(1.0), Amount: dec(1.0), Side: Sell, Account: "bob"}), ErrInsufficientBalance},
+		{"Market buy without cap", second(ob.ProcessOrder(Order{Type: Market, Amount: dec(1.0), Side: Buy, Account: "alice"})), ErrNotionalRequired},
+		{"Market buy over cap", second(ob.ProcessOrder(Order{Type: Market, Amount: dec(1.0), MaxNotional: dec(101.0), Side: Buy, Account: "alice"})), ErrInsufficientBalance},
+		{"Stop buy short", second(ob.ProcessOrder(Order{Type: StopLimit, StopPrice: dec(200.0), Price: dec(200.0), Amount: dec(1.0), Side: Buy, Account: "alice"})), ErrInsufficientBalance},
+		{"Withdraw too much", ob.Withdraw("alice", Quote, dec(100.01)), ErrInsufficientBalance},
+		{"Withdraw available", ob.Withdraw("alice", Base, dec(1.0)), nil},
+		{"Zero deposit", ob.Deposit("alice", Quote, 0), ErrInvalidTransfer},
+		{"Unknown asset", ob.Deposit("alice", "EUR", dec(1.0)), ErrInvalidTransfer},
+		{"Disabled", NewOrderBook("TEST").Deposit("alice", Quote, dec(1.0)), ErrBalancesDisabled},
+	}
+	for _, tt := range tests {
+		if tt.err != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, tt.err)
+		}
+	}
+}
+
+func TestBalances_Recovery(t *testing.T) {
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithBalances(), WithJournal(j))
+	ob.Deposit("alice", Quote, dec(1000.0))
+	ob.Deposit("bob", Base, dec(10.0))
+	ob.PlaceOrder(Order{Price: dec(101.0), Amount: dec(3.0), Side: Sell, Account: "bob"})
+	ob.ProcessOrder(Order{Price: dec(101.0), Amount: dec(1.0), Side: Buy, Account: "alice"})
+	ob.PlaceOrder(Order{Price: dec(99.0), Amount: dec(2.0), Side: Buy, Account: "alice"})
+	ob.Withdraw("bob", Quote, dec(50.0))
+	ob.PlaceOrder(Order{Price: dec(1.0), Amount: dec(1.0), Side: Sell, Account: "carol"}) // Rejected
+
+	replayed := NewOrderBook("TEST", WithBalances())
+	for _, record := range j.records {
+		if err := replayed.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(replayed.AllBalances(), ob.AllBalances()) {
+		t.Errorf("Replayed balances differ:\n got %+v\nwant %+v", replayed.AllBalances(), ob.AllBalances())
+	}
+
+	var buf bytes.Buffer
+	if _, err := ob.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	restored := NewOrderBook("TEST", WithBalances())
+	if _, err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(restored.AllBalances(), ob.AllBalances()) {
+		t.Errorf("Restored balances differ:\n got %+v\nwant %+v", restored.AllBalances(), ob.AllBalances())
+	}
+}
+
+func second[T any](_ T, err error) error {
+	return err
+}
@@ -0,0 +1,52 @@
+package orderbook
+
+import (
+	"hash/crc32"
+	"strings"
+)
+
+// DefaultChecksumDepth is how many levels of each side book checksums cover
+// unless WithChecksumDepth says otherwise.
+const DefaultChecksumDepth = 25
+
+// Checksum returns the CRC-32 (IEEE) of the top depth levels of each side,
+// given best price first, the way crypto exchanges publish it: the price and
+// total amount of the best bid, then of the best ask, then of the next bid
+// and ask and so on, all joined by colons, skipping a side once it runs out
+// of levels. Consumers that rebuild the book from level events compute it
+// over their own copy and resync when it differs from the book's.
+func Checksum(bids, asks []OrderBookLevel, depth int) uint32 {
+	var b strings.Builder
+	for i := 0; i < depth && (i < len(bids) || i < len(asks)); i++ {
+		for _, levels := range [][]OrderBookLevel{bids, asks} {
+			if i < len(levels) {
+				if b.Len() > 0 {
+					b.WriteByte(':')
+				}
+				b.WriteString(levels[i].Price.String())
+				b.WriteByte(':')
+				b.WriteString(levels[i].TotalAmount.String())
+			}
+		}
+	}
+	return crc32.ChecksumIEEE([]byte(b.String()))
+}
+
+// checksum returns the checksum of the book as it stands.
+// Must be called with the lock held.
+func (ob *OrderBook) checksum() uint32 {
+	return Checksum(topLevels(ob.bids, ob.checksumDepth), topLevels(ob.asks, ob.checksumDepth), ob.checksumDepth)
+}
+
+// topLevels returns the aggregates of the best depth levels of a side.
+func topLevels(side *bookSide, depth int) []OrderBookLevel {
+	levels := make([]OrderBookLevel, 0, depth)
+	side.each(func(level *priceLevel) bool {
+		if len(levels) == depth {
+			return false
+		}
+		levels = append(levels, OrderBookLevel{Price: level.price, TotalAmount: level.total, OrderCount: level.count})
+		return true
+	})
+	return levels
+}
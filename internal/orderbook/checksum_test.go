@@ -0,0 +1,82 @@
+package orderbook
+
+import (
+	"hash/crc32"
+	"slices"
+	"testing"
+)
+
+func TestChecksum(t *testing.T) {
+	bids := []OrderBookLevel{{Price: dec(100.5), TotalAmount: dec(1.0)}, {Price: dec(100.0), TotalAmount: dec(2.25)}}
+	asks := []OrderBookLevel{{Price: dec(101.0), TotalAmount: dec(3.0)}}
+
+	tests := []struct {
+		name     string
+		depth    int
+		expected string
+	}{
+		{"Both sides", 25, "100.5:1:101:3:100:2.25"},
+		{"Top level", 1, "100.5:1:101:3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sum := Checksum(bids, asks, tt.depth); sum != crc32.ChecksumIEEE([]byte(tt.expected)) {
+				t.Errorf("Expected the checksum of %q, got %d", tt.expected, sum)
+			}
+		})
+	}
+}
+
+func TestChecksum_RebuiltBook(t *testing.T) {
+	ob := NewOrderBook("TEST", WithChecksumDepth(2))
+	ob.PlaceOrder(Order{ID: "b1", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	ob.PlaceOrder(Order{ID: "a1", Price: dec(102.0), Amount: dec(2.0), Side: Sell})
+
+	// A consumer starts from the snapshot and applies the level events after it
+	sub := ob.Subscribe()
+	defer sub.Close()
+	snapshot := ob.GetOrderBookSnapshot()
+	if snapshot.Checksum != Checksum(snapshot.Bids, snapshot.Asks, 2) {
+		t.Fatalf("Expected the snapshot checksum to match its levels")
+	}
+	levels := map[Side][]OrderBookLevel{Buy: snapshot.Bids, Sell: snapshot.Asks}
+
+	ob.PlaceOrder(Order{ID: "b2", Price: dec(101.0), Amount: dec(1.0), Side: Buy})
+	ob.PlaceOrder(Order{ID: "b3", Price: dec(99.0), Amount: dec(4.0), Side: Buy})
+	ob.ProcessOrder(Order{ID: "s1", Price: dec(100.0), Amount: dec(1.5), Side: Sell})
+	ob.ModifyOrder("a1", dec(103.0), dec(1.0))
+	ob.CancelOrder("b3")
+
+	verified := 0
+	for _, event := range receive(t, sub, 24) {
+		if event.Seq <= snapshot.Seq || event.Type != EventBookLevelChanged {
+			continue
+		}
+		side := levels[event.Side]
+		i, found := slices.BinarySearchFunc(side, event.Level.Price, func(l OrderBookLevel, price Decimal) int {
+			if event.Side == Buy {
+				return int(price - l.Price)
+			}
+			return int(l.Price - price)
+		})
+		switch {
+		case event.Level.TotalAmount == 0:
+			side = slices.Delete(side, i, i+1)
+		case found:
+			side[i] = *event.Level
+		default:
+			side = slices.Insert(side, i, *event.Level)
+		}
+		levels[event.Side] = side
+
+		if event.Checksum != 0 {
+			verified++
+			if sum := Checksum(levels[Buy], levels[Sell], 2); sum != event.Checksum {
+				t.Errorf("Checksum mismatch at seq %d: %d, expected %d", event.Seq, sum, event.Checksum)
+			}
+		}
+	}
+	if verified != 5 {
+		t.Errorf("Expected a checksum per operation, verified %d", verified)
+	}
+}
@@ -0,0 +1,248 @@
+package orderbook
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// DecimalPlaces is the number of fractional digits a Decimal can hold.
+const DecimalPlaces = 8
+
+// Decimal is a fixed-point number stored as an integer count of 10^-8 units,
+// so prices and quantities add, subtract and compare exactly.
+type Decimal int64
+
+// Common Decimal values.
+const (
+	Zero Decimal = 0
+	One  Decimal = 100000000
+)
+
+var (
+	ErrInvalidDecimal  = errors.New("Invalid decimal value")
+	ErrDecimalOverflow = errors.New("Decimal value out of range")
+)
+
+// pow10 holds the powers of ten up to 10^DecimalPlaces.
+var pow10 = [DecimalPlaces + 1]int64{1, 10, 100, 1000, 10000, 100000, 1000000, 10000000, 100000000}
+
+// NewDecimal returns the Decimal value * 10^-exp, e.g. NewDecimal(15, 1) is 1.5.
+// The exponent must be between 0 and DecimalPlaces.
+func NewDecimal(value int64, exp int) Decimal {
+	return Decimal(value * pow10[DecimalPlaces-exp])
+}
+
+// DecimalFromFloat converts a float64 to the nearest Decimal.
+func DecimalFromFloat(f float64) Decimal {
+	return Decimal(math.Round(f * float64(One)))
+}
+
+// ParseDecimal parses a plain decimal string such as "-12.345".
+// More than DecimalPlaces fractional digits is an error rather than being
+// silently rounded.
+func ParseDecimal(s string) (Decimal, error) {
+	if s == "" {
+		return 0, ErrInvalidDecimal
+	}
+
+	negative := false
+	switch s[0] {
+	case '-':
+		negative = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return 0, ErrInvalidDecimal
+	}
+	if len(fracPart) > DecimalPlaces {
+		return 0, ErrInvalidDecimal
+	}
+
+	var units uint64
+	for _, c := range intPart + fracPart + strings.Repeat("0", DecimalPlaces-len(fracPart)) {
+		if c < '0' || c > '9' {
+			return 0, ErrInvalidDecimal
+		}
+		hi, lo := bits.Mul64(units, 10)
+		lo, carry := bits.Add64(lo, uint64(c-'0'), 0)
+		if hi != 0 || carry != 0 || lo > math.MaxInt64 {
+			return 0, ErrDecimalOverflow
+		}
+		units = lo
+	}
+
+	if negative {
+		return Decimal(-int64(units)), nil
+	}
+	return Decimal(units), nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on malformed input.
+// It is intended for constants and tests.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic("orderbook: invalid decimal " + strconv.Quote(s))
+	}
+	return d
+}
+
+// String formats the Decimal without trailing fractional zeros.
+func (d Decimal) String() string {
+	u := uint64(d)
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		u = -u
+	}
+
+	intPart := strconv.FormatUint(u/uint64(One), 10)
+	frac := u % uint64(One)
+	if frac == 0 {
+		return sign + intPart
+	}
+
+	fracPart := strconv.FormatUint(frac, 10)
+	fracPart = strings.Repeat("0", DecimalPlaces-len(fracPart)) + fracPart
+	return sign + intPart + "." + strings.TrimRight(fracPart, "0")
+}
+
+// Float64 returns the nearest float64 to d. Meant for display only.
+func (d Decimal) Float64() float64 {
+	return float64(d) / float64(One)
+}
+
+// Mul returns d * other, truncated toward zero to DecimalPlaces digits.
+// It panics if the result does not fit in a Decimal; use MulChecked for
+// values that have not been range checked.
+func (d Decimal) Mul(other Decimal) Decimal {
+	product, ok := d.MulChecked(other)
+	if !ok {
+		panic(ErrDecimalOverflow)
+	}
+	return product
+}
+
+// MulChecked returns d * other and whether the product fits in a Decimal.
+func (d Decimal) MulChecked(other Decimal) (Decimal, bool) {
+	neg := (d < 0) != (other < 0)
+	a, b := abs64(d), abs64(other)
+
+	hi, lo := bits.Mul64(a, b)
+	if hi >= uint64(One) {
+		return 0, false
+	}
+	q, _ := bits.Div64(hi, lo, uint64(One))
+	if q > math.MaxInt64 {
+		return 0, false
+	}
+
+	if neg {
+		return Decimal(-int64(q)), true
+	}
+	return Decimal(q), true
+}
+
+// Div returns d / other, truncated toward zero to DecimalPlaces digits.
+// It panics if other is zero or the result does not fit in a Decimal; use
+// DivChecked for values that have not been range checked.
+func (d Decimal) Div(other Decimal) Decimal {
+	if other == 0 {
+		panic("orderbook: decimal division by zero")
+	}
+	quotient, ok := d.DivChecked(other)
+	if !ok {
+		panic(ErrDecimalOverflow)
+	}
+	return quotient
+}
+
+// DivChecked returns d / other and whether the quotient fits in a Decimal.
+// Division by zero does not fit.
+func (d Decimal) DivChecked(other Decimal) (Decimal, bool) {
+	if other == 0 {
+		return 0, false
+	}
+	neg := (d < 0) != (other < 0)
+	a, b := abs64(d), abs64(other)
+
+	hi, lo := bits.Mul64(a, uint64(One))
+	if hi >= b {
+		return 0, false
+	}
+	q, _ := bits.Div64(hi, lo, b)
+	if q > math.MaxInt64 {
+		return 0, false
+	}
+
+	if neg {
+		return Decimal(-int64(q)), true
+	}
+	return Decimal(q), true
+}
+
+// Truncate drops the fractional digits of d beyond the given precision,
+// rounding toward zero.
+func (d Decimal) Truncate(precision int) Decimal {
+	if precision >= DecimalPlaces {
+		return d
+	}
+	step := Decimal(pow10[DecimalPlaces-max(precision, 0)])
+	return d - d%step
+}
+
+// Min returns the smaller of d and other.
+func (d Decimal) Min(other Decimal) Decimal {
+	if other < d {
+		return other
+	}
+	return d
+}
+
+// Precision reports the number of significant fractional digits of d.
+func (d Decimal) Precision() int {
+	for p := 0; p < DecimalPlaces; p++ {
+		if int64(d)%pow10[DecimalPlaces-p] == 0 {
+			return p
+		}
+	}
+	return DecimalPlaces
+}
+
+// MarshalJSON encodes the Decimal as an exact decimal string.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON accepts either a decimal string or a bare JSON number, and
+// parses the literal digits so no binary floating point rounding occurs.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func abs64(d Decimal) uint64 {
+	if d < 0 {
+		return uint64(-d)
+	}
+	return uint64(d)
+}
@@ -0,0 +1,98 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input     string
+		expected  Decimal
+		expectErr bool
+	}{
+		{"100", 100 * One, false},
+		{"0.1", One / 10, false},
+		{"-12.345", -12345 * One / 1000, false},
+		{".5", One / 2, false},
+		{"0.00000001", 1, false},
+		{"0.000000001", 0, true},
+		{"1e3", 0, true},
+		{"", 0, true},
+		{"-", 0, true},
+		{"99999999999999999999", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDecimal(tt.input)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseDecimal(%q) error = %v, expected error = %v", tt.input, err, tt.expectErr)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseDecimal(%q) = %v, expected %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDecimal_StringAndJSON(t *testing.T) {
+	tests := []struct {
+		value    Decimal
+		expected string
+	}{
+		{100 * One, "100"},
+		{One / 10, "0.1"},
+		{-3 * One / 2, "-1.5"},
+		{1, "0.00000001"},
+		{0, "0"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.value.String(); got != tt.expected {
+			t.Errorf("String() = %q, expected %q", got, tt.expected)
+		}
+
+		data, err := json.Marshal(tt.value)
+		if err != nil {
+			t.Fatalf("Failed to marshal: %v", err)
+		}
+		var back Decimal
+		if err := json.Unmarshal(data, &back); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", data, err)
+		}
+		if back != tt.value {
+			t.Errorf("Round trip of %v gave %v", tt.value, back)
+		}
+	}
+
+	// Bare JSON numbers are parsed from their literal digits
+	var d Decimal
+	if err := json.Unmarshal([]byte("0.30000000"), &d); err != nil || d != 3*One/10 {
+		t.Errorf("Expected 0.3 from bare number, got %v (%v)", d, err)
+	}
+}
+
+func TestDecimal_Mul(t *testing.T) {
+	if got := MustParseDecimal("1.5").Mul(MustParseDecimal("2.25")); got != MustParseDecimal("3.375") {
+		t.Errorf("Expected 3.375, got %v", got)
+	}
+	if got := MustParseDecimal("-0.00000001").Mul(MustParseDecimal("0.5")); got != 0 {
+		t.Errorf("Expected truncation to 0, got %v", got)
+	}
+	if _, ok := MustParseDecimal("90000000000").MulChecked(MustParseDecimal("90000000000")); ok {
+		t.Error("Expected overflow to be reported")
+	}
+}
+
+func TestDecimal_Div(t *testing.T) {
+	if got := MustParseDecimal("3.375").Div(MustParseDecimal("2.25")); got != MustParseDecimal("1.5") {
+		t.Errorf("Expected 1.5, got %v", got)
+	}
+	if _, ok := MustParseDecimal("1000000000").DivChecked(MustParseDecimal("0.001")); ok {
+		t.Error("Expected overflow to be reported")
+	}
+	if _, ok := One.DivChecked(0); ok {
+		t.Error("Expected division by zero to be reported")
+	}
+}
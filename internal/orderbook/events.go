@@ -0,0 +1,332 @@
+package orderbook
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	ErrEventsUnavailable = errors.New("Events are not in the history")
+	ErrSubscriberBehind  = errors.New("Subscriber fell too far behind")
+	ErrSubscriptionEnded = errors.New("Book ended its subscriptions")
+)
+
+// DefaultSubscriptionBuffer is how many events a subscriber may fall behind
+// by default.
+const DefaultSubscriptionBuffer = 65536
+
+// EventType identifies what an Event reports.
+type EventType string
+
+const (
+	EventAccepted         EventType = "ACCEPTED"           // Order entered the book or the trigger book
+	EventRejected         EventType = "REJECTED"           // Order failed validation or could not be accepted
+	EventPartiallyFilled  EventType = "PARTIALLY_FILLED"   // Order traded and still has an open amount
+	EventFilled           EventType = "FILLED"             // Order traded its whole amount
+	EventCancelled        EventType = "CANCELLED"          // Some or all of the order's amount was cancelled
+	EventModified         EventType = "MODIFIED"           // Order's price, stop price or amount changed
+	EventExpired          EventType = "EXPIRED"            // GTD order reached its expiry time
+	EventTrade            EventType = "TRADE"              // Two orders traded
+	EventBookLevelChanged EventType = "BOOK_LEVEL_CHANGED" // Displayed amount at a price changed
+	EventBookOrderChanged EventType = "BOOK_ORDER_CHANGED" // Displayed amount or queue position of a resting order changed
+	EventStatusChanged    EventType = "STATUS_CHANGED"     // Book entered a new trading phase
+	EventIndicative       EventType = "INDICATIVE"         // Auction's indicative price, volume or imbalance changed
+)
+
+// Event describes a change in the book. Seq increases by one with every event
+// the book emits, so subscribers can detect gaps and order events of a book.
+type Event struct {
+	Seq  uint64    `json:"seq"`
+	Type EventType `json:"type"`
+	Book string    `json:"book"`
+	Time time.Time `json:"time"`
+
+	// Order events carry the order as it stands after the event, with Amount
+	// the open amount left, and the amount filled, cancelled or expired.
+	Order  *Order  `json:"order,omitempty"`
+	Amount Decimal `json:"amount,omitempty"`
+	Reason string  `json:"reason,omitempty"` // Why the order was rejected
+
+	Trade *Trade `json:"trade,omitempty"`
+
+	// Level events carry the new aggregate of a price level, with a zero
+	// amount once the level is empty. The last level event of an operation
+	// carries the Checksum of the book once all of them are applied.
+	Side     Side            `json:"side,omitempty"`
+	Level    *OrderBookLevel `json:"level,omitempty"`
+	Checksum uint32          `json:"checksum,omitempty"`
+
+	// Book order events carry a resting order as the book displays it, with
+	// a zero amount once it left the book.
+	BookOrder *BookOrder `json:"book_order,omitempty"`
+
+	Status BookStatus `json:"status,omitempty"` // New status of the book
+
+	Indicative *Indicative `json:"indicative,omitempty"`
+}
+
+// Subscription delivers the events of a book, in sequence order, on C.
+// Events are queued so a slow subscriber never blocks matching, up to the
+// buffer set WithSubscriptionBuffer: a subscriber falling further behind is
+// dropped, as resuming is all it can do. C is closed once the subscription
+// is closed or dropped; Err then tells which.
+type Subscription struct {
+	C <-chan Event
+
+	ob    *OrderBook
+	mu    sync.Mutex
+	queue []Event
+	held  int // Events queued or on their way to C
+	limit int
+	err   error
+	wake  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+// Subscribe registers a subscriber for every event emitted from now on.
+func (ob *OrderBook) Subscribe() *Subscription {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	return ob.subscribe()
+}
+
+// subscribe registers a subscriber.
+// Must be called with the lock held.
+func (ob *OrderBook) subscribe() *Subscription {
+	c := make(chan Event)
+	sub := &Subscription{
+		C:     c,
+		ob:    ob,
+		limit: max(ob.subscriptionBuffer, len(ob.history)), // Room to resume
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	if ob.ended {
+		sub.stop(ErrSubscriptionEnded)
+	} else {
+		ob.subs[sub] = struct{}{}
+	}
+
+	go sub.pump(c)
+	return sub
+}
+
+// SubscribeFrom registers a subscriber that first receives the events after
+// seq from the history kept with WithEventHistory, then every event emitted
+// from now on, so that a subscriber can resume where it left off. Returns
+// ErrEventsUnavailable if the history no longer holds all of those events.
+func (ob *OrderBook) SubscribeFrom(seq uint64) (*Subscription, error) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	kept := min(ob.eventSeq, uint64(len(ob.history)))
+	if seq > ob.eventSeq || seq < ob.eventSeq-kept {
+		return nil, ErrEventsUnavailable
+	}
+	sub := ob.subscribe()
+	for next := seq + 1; next <= ob.eventSeq; next++ {
+		sub.push(ob.history[(next-1)%uint64(len(ob.history))])
+	}
+	return sub, nil
+}
+
+// Close stops delivery and discards any events not yet received.
+func (s *Subscription) Close() {
+	s.ob.mu.Lock()
+	delete(s.ob.subs, s)
+	s.ob.mu.Unlock()
+	s.stop(nil)
+}
+
+// Err returns why the book ended the subscription: ErrSubscriberBehind if
+// the subscriber fell more than its buffer behind, ErrSubscriptionEnded if
+// the book ended all of them. It is nil while the subscription runs, or once
+// it was closed with Close.
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// EndSubscriptions closes every subscription of a book that goes away, so
+// that subscribers stop waiting for its events. Later subscriptions end
+// right away.
+func (ob *OrderBook) EndSubscriptions() {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.ended = true
+	for sub := range ob.subs {
+		sub.stop(ErrSubscriptionEnded)
+	}
+	clear(ob.subs)
+}
+
+// stop ends delivery, for err unless the subscription already ended.
+func (s *Subscription) stop(err error) {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.err = err
+		s.queue = nil
+		s.mu.Unlock()
+		close(s.done)
+	})
+}
+
+// push queues an event without blocking. It reports false, having stopped
+// the subscription, if the queue is full.
+func (s *Subscription) push(event Event) bool {
+	s.mu.Lock()
+	full := s.held >= s.limit
+	if !full {
+		s.queue = append(s.queue, event)
+		s.held++
+	}
+	s.mu.Unlock()
+	if full {
+		s.stop(ErrSubscriberBehind)
+		return false
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// pump forwards queued events to the subscriber's channel.
+func (s *Subscription) pump(c chan<- Event) {
+	defer close(c)
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.wake:
+		}
+
+		s.mu.Lock()
+		batch := s.queue
+		s.queue = nil
+		s.mu.Unlock()
+
+		for _, event := range batch {
+			select {
+			case c <- event:
+			case <-s.done:
+				return
+			}
+			s.mu.Lock()
+			s.held--
+			s.mu.Unlock()
+		}
+	}
+}
+
+// levelKey identifies a price level of one side of the book.
+type levelKey struct {
+	side  Side
+	price Decimal
+}
+
+// observed reports whether anyone receives events, so that they are worth
+// building.
+// Must be called with the lock held.
+func (ob *OrderBook) observed() bool {
+	return len(ob.subs) > 0 || len(ob.history) > 0
+}
+
+// emit stamps an event, keeps it in the history and hands it to every
+// subscriber.
+// Must be called with the lock held.
+func (ob *OrderBook) emit(event Event) {
+	ob.eventSeq++
+	if !ob.observed() {
+		return
+	}
+	event.Seq = ob.eventSeq
+	event.Book = ob.Tag
+	event.Time = ob.at
+	if len(ob.history) > 0 {
+		ob.history[(event.Seq-1)%uint64(len(ob.history))] = event
+	}
+	for sub := range ob.subs {
+		if !sub.push(event) {
+			delete(ob.subs, sub)
+		}
+	}
+}
+
+// emitOrder emits an order event. The order is copied with its open amount.
+// Must be called with the lock held.
+func (ob *OrderBook) emitOrder(typ EventType, order Order, open, amount Decimal) {
+	order.Amount = open
+	ob.emit(Event{Type: typ, Order: &order, Amount: amount})
+}
+
+// emitRejected emits the rejection of an order.
+// Must be called with the lock held.
+func (ob *OrderBook) emitRejected(order Order, err error) {
+	ob.emit(Event{Type: EventRejected, Order: &order, Reason: err.Error()})
+}
+
+// emitFill emits the fill event of an order after it traded amount.
+// Must be called with the lock held.
+func (ob *OrderBook) emitFill(order Order, open, amount Decimal) {
+	typ := EventPartiallyFilled
+	if open == 0 {
+		typ = EventFilled
+	}
+	ob.emitOrder(typ, order, open, amount)
+}
+
+// touch records that a resting order, and so its price level, changed
+// during this operation. Called before a node leaves the book.
+// Must be called with the lock held.
+func (ob *OrderBook) touch(node *orderNode) {
+	key := levelKey{side: node.order.Side, price: node.order.Price}
+	if !slices.Contains(ob.touched, key) {
+		ob.touched = append(ob.touched, key)
+	}
+	if _, ok := ob.touchedOrders[node.order.ID]; !ok {
+		ob.touchedOrders[node.order.ID] = key
+		ob.touchedOrderIDs = append(ob.touchedOrderIDs, node.order.ID)
+	}
+}
+
+// publishLevels emits one BookLevelChanged event for every level touched by
+// the current operation, the last with the checksum of the book, and one
+// BookOrderChanged event for every resting order, then the indicative price
+// of an auction if it changed.
+// Must be called with the lock held.
+func (ob *OrderBook) publishLevels() {
+	for i, key := range ob.touched {
+		level := OrderBookLevel{Price: key.price}
+		if l, ok := ob.side(key.side).levels[key.price]; ok {
+			level.TotalAmount = l.total
+			level.OrderCount = l.count
+		}
+		event := Event{Type: EventBookLevelChanged, Side: key.side, Level: &level}
+		if i == len(ob.touched)-1 && ob.observed() {
+			event.Checksum = ob.checksum()
+		}
+		ob.emit(event)
+	}
+	ob.touched = ob.touched[:0]
+
+	for _, id := range ob.touchedOrderIDs {
+		order := BookOrder{ID: id, Side: ob.touchedOrders[id].side, Price: ob.touchedOrders[id].price}
+		if node, ok := ob.orders[id]; ok {
+			order = node.bookOrder()
+		}
+		ob.emit(Event{Type: EventBookOrderChanged, Side: order.Side, BookOrder: &order})
+	}
+	clear(ob.touchedOrders)
+	ob.touchedOrderIDs = ob.touchedOrderIDs[:0]
+
+	ob.publishIndicative()
+}
@@ -0,0 +1,297 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvents_Lifecycle(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	sub := ob.Subscribe()
+	defer sub.Close()
+
+	ob.PlaceOrder(Order{ID: "ask", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	ob.ProcessOrder(Order{ID: "bid", Price: dec(100.0), Amount: dec(1.5), Side: Buy})
+	ob.ModifyOrder("bid", dec(99.0), dec(0.5))
+	ob.CancelOrder("bid")
+	ob.ProcessOrder(Order{ID: "bad", Price: dec(-1.0), Amount: dec(1.0), Side: Buy})
+
+	expected := []struct {
+		typ     EventType
+		orderID string
+		open    Decimal
+		amount  Decimal
+	}{
+		{EventAccepted, "ask", dec(1.0), 0},
+		{EventBookLevelChanged, "", 0, 0},
+		{EventBookOrderChanged, "", 0, 0},
+		{EventAccepted, "bid", dec(1.5), 0},
+		{EventTrade, "", 0, 0},
+		{EventFilled, "ask", 0, dec(1.0)},
+		{EventPartiallyFilled, "bid", dec(0.5), dec(1.0)},
+		{EventBookLevelChanged, "", 0, 0},
+		{EventBookLevelChanged, "", 0, 0},
+		{EventBookOrderChanged, "", 0, 0},
+		{EventBookOrderChanged, "", 0, 0},
+		{EventModified, "bid", dec(0.5), 0},
+		{EventBookLevelChanged, "", 0, 0},
+		{EventBookLevelChanged, "", 0, 0},
+		{EventBookOrderChanged, "", 0, 0},
+		{EventCancelled, "bid", 0, dec(0.5)},
+		{EventBookLevelChanged, "", 0, 0},
+		{EventBookOrderChanged, "", 0, 0},
+		{EventRejected, "bad", 0, 0},
+	}
+
+	events := receive(t, sub, len(expected))
+	for i, want := range expected {
+		event := events[i]
+		if event.Seq != uint64(i+1) || event.Type != want.typ || event.Book != "TEST" {
+			t.Fatalf("Event %d: expected #%d %s, got %+v", i, i+1, want.typ, event)
+		}
+		if want.orderID == "" {
+			continue
+		}
+		if event.Order == nil || event.Order.ID != want.orderID {
+			t.Fatalf("Event %d: expected order %s, got %+v", i, want.orderID, event.Order)
+		}
+		if want.typ != EventRejected && (event.Order.Amount != want.open || event.Amount != want.amount) {
+			t.Errorf("Event %d: expected open %v and amount %v, got %v and %v",
+				i, want.open, want.amount, event.Order.Amount, event.Amount)
+		}
+	}
+
+	if trade := events[4].Trade; trade == nil || trade.BuyOrderID != "bid" || trade.SellOrderID != "ask" {
+		t.Errorf("Unexpected trade event: %+v", events[4])
+	}
+	levels := []struct {
+		side  Side
+		level OrderBookLevel
+	}{
+		{Sell, OrderBookLevel{Price: dec(100.0), TotalAmount: dec(1.0), OrderCount: 1}},
+		{Sell, OrderBookLevel{Price: dec(100.0)}},
+		{Buy, OrderBookLevel{Price: dec(100.0), TotalAmount: dec(0.5), OrderCount: 1}},
+	}
+	for i, idx := range []int{1, 7, 8} {
+		if events[idx].Side != levels[i].side || *events[idx].Level != levels[i].level {
+			t.Errorf("Event %d: expected %s level %+v, got %+v", idx, levels[i].side, levels[i].level, events[idx])
+		}
+	}
+	orders := []struct {
+		idx   int
+		order BookOrder
+	}{
+		{2, BookOrder{ID: "ask", Side: Sell, Price: dec(100.0), Amount: dec(1.0), Priority: 1}},
+		{9, BookOrder{ID: "ask", Side: Sell, Price: dec(100.0)}},
+		{10, BookOrder{ID: "bid", Side: Buy, Price: dec(100.0), Amount: dec(0.5), Priority: 2}},
+		{14, BookOrder{ID: "bid", Side: Buy, Price: dec(99.0), Amount: dec(0.5), Priority: 3}},
+		{17, BookOrder{ID: "bid", Side: Buy, Price: dec(99.0)}},
+	}
+	for _, want := range orders {
+		if event := events[want.idx]; event.BookOrder == nil || *event.BookOrder != want.order {
+			t.Errorf("Event %d: expected book order %+v, got %+v", want.idx, want.order, event.BookOrder)
+		}
+	}
+	if events[18].Reason != ErrInvalidOrder.Error() {
+		t.Errorf("Expected rejection reason, got %q", events[18].Reason)
+	}
+}
+
+func TestEvents_SlowSubscriberDoesNotBlock(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	slow := ob.Subscribe()
+	defer slow.Close()
+
+	// Nobody reads while the book keeps processing
+	const orders = 500
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < orders; i++ {
+			ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Matching blocked on a slow subscriber")
+	}
+
+	// Every event is still delivered, in order
+	events := receive(t, slow, 3*orders)
+	for i, event := range events {
+		if event.Seq != uint64(i+1) {
+			t.Fatalf("Expected seq %d, got %d", i+1, event.Seq)
+		}
+	}
+}
+
+func TestEvents_SubscriberBehind(t *testing.T) {
+	ob := NewOrderBook("TEST", WithSubscriptionBuffer(10))
+	slow := ob.Subscribe()
+	defer slow.Close()
+	fast := ob.Subscribe()
+	defer fast.Close()
+
+	// The slow subscriber takes one event and then stops reading
+	ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	receive(t, slow, 1)
+	for i := 0; i < 10; i++ {
+		ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+		receive(t, fast, 3)
+	}
+
+	// It is dropped once more than its buffer behind, the others go on
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received > 10 || slow.Err() != ErrSubscriberBehind {
+		t.Errorf("Expected the slow subscriber dropped within 10 events, got %d events and %v", received, slow.Err())
+	}
+	if fast.Err() != nil {
+		t.Errorf("Expected the subscriber keeping up to stay, got %v", fast.Err())
+	}
+	fast.Close()
+	if fast.Err() != nil {
+		t.Errorf("Expected no error once closed, got %v", fast.Err())
+	}
+}
+
+func TestEvents_Close(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	sub := ob.Subscribe()
+	sub.Close()
+	sub.Close()
+
+	ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	select {
+	case _, ok := <-sub.C:
+		if ok {
+			t.Error("Expected no events after Close")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the channel to be closed")
+	}
+}
+
+func TestEvents_EndSubscriptions(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	before := ob.Subscribe()
+	ob.EndSubscriptions()
+	after := ob.Subscribe()
+
+	for _, sub := range []*Subscription{before, after} {
+		select {
+		case _, ok := <-sub.C:
+			if ok || sub.Err() != ErrSubscriptionEnded {
+				t.Errorf("Expected the subscription ended, got %v", sub.Err())
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the channel to be closed")
+		}
+	}
+}
+
+func TestEvents_Expired(t *testing.T) {
+	now := time.Now()
+	ob := NewOrderBook("TEST", WithClock(func() time.Time { return now }))
+	expireAt := now.Add(time.Minute)
+	ob.PlaceOrder(Order{ID: "gtd", Price: dec(100.0), Amount: dec(1.0), Side: Buy, TimeInForce: GTD, ExpireAt: &expireAt})
+
+	sub := ob.Subscribe()
+	defer sub.Close()
+	ob.ExpireOrders(expireAt)
+
+	events := receive(t, sub, 2)
+	if events[0].Type != EventExpired || events[0].Order.ID != "gtd" || events[0].Amount != dec(1.0) {
+		t.Errorf("Expected gtd to expire, got %+v", events[0])
+	}
+	if events[1].Type != EventBookLevelChanged || events[1].Level.TotalAmount != 0 {
+		t.Errorf("Expected the level to empty, got %+v", events[1])
+	}
+}
+
+func TestEvents_SubscribeView(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "b1", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	ob.PlaceOrder(Order{ID: "b2", Price: dec(100.0), Amount: dec(2.0), Side: Buy})
+	ob.PlaceOrder(Order{ID: "a1", Price: dec(101.0), Amount: dec(3.0), Side: Sell})
+
+	sub, view := ob.SubscribeView()
+	defer sub.Close()
+	if len(view.Bids) != 2 || view.Bids[0].ID != "b1" || view.Bids[1].Priority <= view.Bids[0].Priority || len(view.Asks) != 1 {
+		t.Errorf("Expected b1 ahead of b2 and a1, got %+v", view)
+	}
+
+	// The events follow on from the view
+	ob.CancelOrder("b1")
+	events := receive(t, sub, 3)
+	if events[0].Seq != view.Seq+1 {
+		t.Errorf("Expected seq %d, got %d", view.Seq+1, events[0].Seq)
+	}
+	if last := events[2]; last.Type != EventBookOrderChanged || last.BookOrder.ID != "b1" || last.BookOrder.Amount != 0 {
+		t.Errorf("Expected b1 to leave the book, got %+v", last)
+	}
+}
+
+func TestEvents_SubscribeFrom(t *testing.T) {
+	ob := NewOrderBook("TEST", WithEventHistory(4))
+	for _, id := range []string{"b1", "b2", "b3"} {
+		ob.PlaceOrder(Order{ID: id, Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	}
+	// Each order emitted ACCEPTED, BOOK_LEVEL_CHANGED and BOOK_ORDER_CHANGED
+
+	tests := []struct {
+		name  string
+		seq   uint64
+		first uint64
+		err   error
+	}{
+		{"Resume within the history", 6, 7, nil},
+		{"Oldest kept", 5, 6, nil},
+		{"Older than the history", 4, 0, ErrEventsUnavailable},
+		{"Ahead of the book", 10, 0, ErrEventsUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := ob.SubscribeFrom(tt.seq)
+			if err != tt.err {
+				t.Fatalf("Expected %v, got %v", tt.err, err)
+			}
+			if err != nil {
+				return
+			}
+			defer sub.Close()
+
+			if event := receive(t, sub, 1)[0]; event.Seq != tt.first {
+				t.Errorf("Expected seq %d first, got %+v", tt.first, event)
+			}
+		})
+	}
+
+	// Up to date subscribers only receive what comes next
+	sub, err := ob.SubscribeFrom(9)
+	if err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	defer sub.Close()
+	ob.CancelOrder("b1")
+	if event := receive(t, sub, 1)[0]; event.Seq != 10 || event.Type != EventCancelled {
+		t.Errorf("Expected the cancellation next, got %+v", event)
+	}
+}
+
+func receive(t *testing.T, sub *Subscription, n int) []Event {
+	t.Helper()
+	events := make([]Event, 0, n)
+	for len(events) < n {
+		select {
+		case event := <-sub.C:
+			events = append(events, event)
+		case <-time.After(time.Second):
+			t.Fatalf("Expected %d events, got %d", n, len(events))
+		}
+	}
+	return events
+}
@@ -0,0 +1,108 @@
+package orderbook
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// expiryEntry schedules the expiry check of a GTD order.
+type expiryEntry struct {
+	orderID  string
+	expireAt time.Time
+}
+
+// expiryQueue is a min-heap of GTD orders ordered by expiry time. Entries are
+// not removed on cancel or fill; they are discarded lazily when popped.
+type expiryQueue []expiryEntry
+
+func (q expiryQueue) Len() int            { return len(q) }
+func (q expiryQueue) Less(i, j int) bool  { return q[i].expireAt.Before(q[j].expireAt) }
+func (q expiryQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *expiryQueue) Push(x interface{}) { *q = append(*q, x.(expiryEntry)) }
+func (q *expiryQueue) Pop() interface{} {
+	old := *q
+	entry := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return entry
+}
+
+// ExpireOrders removes every resting or untriggered GTD order whose expiry
+// time is at or before now, and returns the removed orders. Status changes
+// due by now are applied first.
+func (ob *OrderBook) ExpireOrders(now time.Time) []Order {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = now
+	if err := ob.advance(); err != nil {
+		return nil // Retried on the next sweep
+	}
+	if !ob.expiryDue(now) {
+		return nil
+	}
+	if err := ob.record(Command{Type: CommandExpire}); err != nil {
+		return nil // Retried on the next sweep
+	}
+	return ob.expireOrders(now)
+}
+
+// RunExpirySweeper expires GTD orders every interval until ctx is done.
+func (ob *OrderBook) RunExpirySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ob.ExpireOrders(ob.now())
+		}
+	}
+}
+
+// expiryDue reports whether the expiry queue has entries due at now.
+func (ob *OrderBook) expiryDue(now time.Time) bool {
+	return ob.expiries.Len() > 0 && !now.Before(ob.expiries[0].expireAt)
+}
+
+// expireOrders pops due entries off the expiry queue.
+// Must be called with the lock held.
+func (ob *OrderBook) expireOrders(now time.Time) []Order {
+	var expired []Order
+	for ob.expiryDue(now) {
+		entry := heap.Pop(&ob.expiries).(expiryEntry)
+
+		if order, ok := ob.expireStop(entry.orderID, now); ok {
+			expired = append(expired, order)
+			continue
+		}
+
+		// Skip orders that were filled, cancelled or re-dated in the meantime
+		node, ok := ob.orders[entry.orderID]
+		if !ok || !node.order.expired(now) {
+			continue
+		}
+
+		ob.expire(node)
+		expired = append(expired, node.order)
+	}
+	return expired
+}
+
+// expire removes a resting order that reached its expiry time.
+// Must be called with the lock held.
+func (ob *OrderBook) expire(node *orderNode) {
+	ob.remove(node)
+	ob.emitOrder(EventExpired, node.order, 0, node.order.Amount)
+}
+
+// scheduleExpiry registers a resting GTD order with the expiry queue.
+// Must be called with the lock held.
+func (ob *OrderBook) scheduleExpiry(order *Order) {
+	if order.TimeInForce == GTD && order.ExpireAt != nil {
+		heap.Push(&ob.expiries, expiryEntry{orderID: order.ID, expireAt: *order.ExpireAt})
+	}
+}
@@ -0,0 +1,176 @@
+package orderbook
+
+import (
+	"errors"
+	"maps"
+)
+
+var ErrInvalidFeeSchedule = errors.New("Invalid fee schedule")
+
+// maxFeeBps caps fee rates at 100% of the traded value.
+const maxFeeBps = 10000
+
+// FeeRate is what an account pays on the value of its trades, in basis
+// points. A negative maker rate is a rebate.
+type FeeRate struct {
+	MakerBps int64   `json:"maker_bps"`
+	TakerBps int64   `json:"taker_bps"`
+	MinFee   Decimal `json:"min_fee,omitempty"` // Smallest fee charged on a trade, rebates excepted
+}
+
+// FeeSchedule sets the fees of a market. Accounts assigned to a tier pay its
+// rates, every other order pays the default rates. Fees are charged in
+// Currency on the price times amount of each trade.
+type FeeSchedule struct {
+	Currency string             `json:"currency,omitempty"`
+	Default  FeeRate            `json:"default"`
+	Tiers    map[string]FeeRate `json:"tiers,omitempty"`    // Rates by tier name
+	Accounts map[string]string  `json:"accounts,omitempty"` // Tier name by account
+}
+
+// AccountFees are the fees an account paid in a market, net of rebates.
+type AccountFees struct {
+	MakerFees Decimal `json:"maker_fees"`
+	TakerFees Decimal `json:"taker_fees"`
+	Trades    uint64  `json:"trades"`
+}
+
+// Validate checks that every rate is within 100% of the traded value, that
+// only makers get rebates and that every account has a known tier. Returns
+// ErrInvalidFeeSchedule if not.
+func (s FeeSchedule) Validate() error {
+	if !s.Default.valid() {
+		return ErrInvalidFeeSchedule
+	}
+	for _, rate := range s.Tiers {
+		if !rate.valid() {
+			return ErrInvalidFeeSchedule
+		}
+	}
+	for _, tier := range s.Accounts {
+		if _, ok := s.Tiers[tier]; !ok {
+			return ErrInvalidFeeSchedule
+		}
+	}
+	return nil
+}
+
+// clone returns a copy of the schedule that shares no maps with it.
+func (s FeeSchedule) clone() FeeSchedule {
+	s.Tiers = maps.Clone(s.Tiers)
+	s.Accounts = maps.Clone(s.Accounts)
+	return s
+}
+
+func (r FeeRate) valid() bool {
+	return r.MakerBps >= -maxFeeBps && r.MakerBps <= maxFeeBps &&
+		r.TakerBps >= 0 && r.TakerBps <= maxFeeBps && r.MinFee >= 0
+}
+
+// fee returns what an account pays on a trade of the given value, negative
+// for a rebate.
+func (s *FeeSchedule) fee(account string, maker bool, notional Decimal) Decimal {
+	rate := s.Default
+	if tier, ok := s.Accounts[account]; ok && account != "" {
+		rate = s.Tiers[tier]
+	}
+
+	bps := rate.TakerBps
+	if maker {
+		bps = rate.MakerBps
+	}
+	fee := notional.Mul(NewDecimal(bps, 4))
+	if bps > 0 && fee < rate.MinFee {
+		fee = rate.MinFee
+	}
+	return fee
+}
+
+// worstFee returns the most an account pays on trades of the given value,
+// whether its order makes or takes them.
+func (s *FeeSchedule) worstFee(account string, notional Decimal) Decimal {
+	return max(s.fee(account, false, notional), s.fee(account, true, notional), 0)
+}
+
+// chargeFees works out the fees of a trade between a taker and a maker
+// order, attaches them to the trade and adds them to the totals of both
+// accounts. Orders without an account are charged but not totalled.
+// Must be called with the lock held.
+func (ob *OrderBook) chargeFees(trade *Trade, taker, maker *Order) {
+	notional := trade.Price.Mul(trade.Amount)
+	trade.TakerFee = ob.fees.fee(taker.Account, false, notional)
+	trade.MakerFee = ob.fees.fee(maker.Account, true, notional)
+	trade.FeeCurrency = ob.fees.Currency
+
+	if taker.Account != "" {
+		totals := ob.accountFees(taker.Account)
+		totals.TakerFees += trade.TakerFee
+		totals.Trades++
+	}
+	if maker.Account != "" {
+		totals := ob.accountFees(maker.Account)
+		totals.MakerFees += trade.MakerFee
+		totals.Trades++
+	}
+}
+
+// accountFees returns the running fee totals of an account.
+// Must be called with the lock held.
+func (ob *OrderBook) accountFees(account string) *AccountFees {
+	totals, ok := ob.feeTotals[account]
+	if !ok {
+		totals = &AccountFees{}
+		ob.feeTotals[account] = totals
+	}
+	return totals
+}
+
+// FeeSchedule returns the fee schedule of the book.
+func (ob *OrderBook) FeeSchedule() FeeSchedule {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.fees.clone()
+}
+
+// SetFeeSchedule replaces the fee schedule. It applies to trades from now on;
+// fees already charged are not revisited. With balances, the fees reserved by
+// open buys follow the new rates, which may leave an account short when they
+// rise.
+// Returns ErrInvalidFeeSchedule if the schedule is invalid.
+func (ob *OrderBook) SetFeeSchedule(schedule FeeSchedule) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.at = ob.now()
+	return ob.setFeeSchedule(schedule)
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) setFeeSchedule(schedule FeeSchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if err := ob.record(Command{Type: CommandSetFees, Fees: &schedule}); err != nil {
+		return err
+	}
+
+	// Open buys hold their fees at the rates they may pay, so they move to
+	// the new rates along with the schedule
+	ob.releaseAll()
+	ob.fees = schedule.clone()
+	ob.holdAll()
+	return nil
+}
+
+// AccountFees returns the fees paid by every account with trades in the book.
+func (ob *OrderBook) AccountFees() map[string]AccountFees {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	fees := make(map[string]AccountFees, len(ob.feeTotals))
+	for account, totals := range ob.feeTotals {
+		fees[account] = *totals
+	}
+	return fees
+}
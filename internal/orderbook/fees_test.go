@@ -0,0 +1,127 @@
+package orderbook
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func testFeeSchedule() FeeSchedule {
+	return FeeSchedule{
+		Currency: "USD",
+		Default:  FeeRate{MakerBps: -1, TakerBps: 5, MinFee: dec(0.01)},
+		Tiers:    map[string]FeeRate{"vip": {MakerBps: -2, TakerBps: 2}},
+		Accounts: map[string]string{"alice": "vip"},
+	}
+}
+
+func TestFees_ChargedOnTrades(t *testing.T) {
+	ob := NewOrderBook("TEST", WithFeeSchedule(testFeeSchedule()))
+	ob.PlaceOrder(Order{ID: "bob-ask", Price: dec(100.0), Amount: dec(1.0), Side: Sell, Account: "bob"})
+	ob.PlaceOrder(Order{ID: "carol-ask", Price: dec(100.0), Amount: dec(0.01), Side: Sell, Account: "carol"})
+	ob.PlaceOrder(Order{ID: "anonymous-ask", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+
+	tests := []struct {
+		name             string
+		order            Order
+		expectedTakerFee Decimal
+		expectedMakerFee Decimal
+	}{
+		{"Tier rates", Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy, Account: "alice"}, dec(0.02), dec(-0.01)},
+		{"Minimum fee", Order{Price: dec(100.0), Amount: dec(0.01), Side: Buy, Account: "dave"}, dec(0.01), dec(-0.0001)},
+		{"No account", Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy}, dec(0.05), dec(-0.01)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ob.ProcessOrder(tt.order)
+			if err != nil || len(result.Trades) != 1 {
+				t.Fatalf("Expected one trade, got %+v, %v", result, err)
+			}
+			trade := result.Trades[0]
+			if trade.TakerFee != tt.expectedTakerFee || trade.MakerFee != tt.expectedMakerFee || trade.FeeCurrency != "USD" {
+				t.Errorf("Expected fees %v/%v USD, got %v/%v %s",
+					tt.expectedTakerFee, tt.expectedMakerFee, trade.TakerFee, trade.MakerFee, trade.FeeCurrency)
+			}
+		})
+	}
+
+	expected := map[string]AccountFees{
+		"alice": {TakerFees: dec(0.02), Trades: 1},
+		"bob":   {MakerFees: dec(-0.01), Trades: 1},
+		"carol": {MakerFees: dec(-0.0001), Trades: 1},
+		"dave":  {TakerFees: dec(0.01), Trades: 1},
+	}
+	if fees := ob.AccountFees(); !reflect.DeepEqual(fees, expected) {
+		t.Errorf("Expected totals %+v, got %+v", expected, fees)
+	}
+}
+
+func TestFees_Schedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule FeeSchedule
+		err      error
+	}{
+		{"No fees", FeeSchedule{}, nil},
+		{"Tiers", testFeeSchedule(), nil},
+		{"Taker rebate", FeeSchedule{Default: FeeRate{TakerBps: -1}}, ErrInvalidFeeSchedule},
+		{"Over 100%", FeeSchedule{Default: FeeRate{MakerBps: 10001}}, ErrInvalidFeeSchedule},
+		{"Negative minimum", FeeSchedule{Tiers: map[string]FeeRate{"vip": {MinFee: dec(-1.0)}}}, ErrInvalidFeeSchedule},
+		{"Unknown tier", FeeSchedule{Accounts: map[string]string{"alice": "gold"}}, ErrInvalidFeeSchedule},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook("TEST")
+			if err := ob.SetFeeSchedule(tt.schedule); err != tt.err {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	// The book keeps its own copy
+	schedule := testFeeSchedule()
+	ob := NewOrderBook("TEST")
+	ob.SetFeeSchedule(schedule)
+	schedule.Accounts["bob"] = "vip"
+	if _, ok := ob.FeeSchedule().Accounts["bob"]; ok {
+		t.Error("Expected the schedule to be copied")
+	}
+}
+
+func TestFees_Recovery(t *testing.T) {
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithJournal(j))
+	ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Sell, Account: "bob"})
+	ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(0.5), Side: Buy, Account: "alice"}) // Free
+	ob.SetFeeSchedule(testFeeSchedule())
+	ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(0.5), Side: Buy, Account: "alice"})
+
+	// Through the journal alone
+	replayed := NewOrderBook("TEST")
+	for _, record := range j.records {
+		if err := replayed.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+	if !reflect.DeepEqual(replayed.AccountFees(), ob.AccountFees()) || !reflect.DeepEqual(replayed.FeeSchedule(), ob.FeeSchedule()) {
+		t.Errorf("Replayed fees differ: got %+v, want %+v", replayed.AccountFees(), ob.AccountFees())
+	}
+
+	// Through a snapshot
+	var buf bytes.Buffer
+	if _, err := ob.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	restored := NewOrderBook("TEST")
+	if _, err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if !reflect.DeepEqual(restored.AccountFees(), ob.AccountFees()) || !reflect.DeepEqual(restored.FeeSchedule(), ob.FeeSchedule()) {
+		t.Errorf("Restored fees differ: got %+v, want %+v", restored.AccountFees(), ob.AccountFees())
+	}
+	if fees := ob.AccountFees()["alice"]; fees.TakerFees != dec(0.01) || fees.Trades != 2 {
+		t.Errorf("Expected alice to pay only for the second trade, got %+v", fees)
+	}
+}
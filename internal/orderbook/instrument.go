@@ -0,0 +1,112 @@
+package orderbook
+
+import "errors"
+
+var (
+	ErrInvalidInstrument = errors.New("Invalid instrument specification")
+	ErrInvalidTickSize   = errors.New("Price is not a multiple of the tick size")
+	ErrInvalidLotSize    = errors.New("Amount is not a multiple of the lot size")
+	ErrBelowMinQuantity  = errors.New("Amount is below the minimum quantity")
+	ErrAboveMaxQuantity  = errors.New("Amount is above the maximum quantity")
+	ErrBelowMinNotional  = errors.New("Order value is below the minimum notional")
+)
+
+// InstrumentSpec describes what orders a book accepts. Zero sizes and limits
+// are not enforced.
+type InstrumentSpec struct {
+	// Fractional digits accepted for prices and amounts, at most DecimalPlaces
+	PricePrecision  int `json:"price_precision"`
+	AmountPrecision int `json:"amount_precision"`
+
+	TickSize    Decimal `json:"tick_size,omitempty"`    // Prices must be a multiple of it
+	LotSize     Decimal `json:"lot_size,omitempty"`     // Amounts must be a multiple of it
+	MinQuantity Decimal `json:"min_quantity,omitempty"` // Smallest amount of an order
+	MaxQuantity Decimal `json:"max_quantity,omitempty"` // Largest amount of an order
+	MinNotional Decimal `json:"min_notional,omitempty"` // Smallest price * amount of a priced order
+
+	Matching *MatchingSpec `json:"matching,omitempty"` // How levels share incoming orders, FIFO if nil
+}
+
+// DefaultInstrumentSpec returns the spec of a book created without one: full
+// precision and no other restriction.
+func DefaultInstrumentSpec() InstrumentSpec {
+	return InstrumentSpec{PricePrecision: DecimalPlaces, AmountPrecision: DecimalPlaces}
+}
+
+// Validate checks that the spec is consistent, returning ErrInvalidInstrument
+// if it is not.
+func (s InstrumentSpec) Validate() error {
+	if s.PricePrecision < 0 || s.PricePrecision > DecimalPlaces || s.AmountPrecision < 0 || s.AmountPrecision > DecimalPlaces {
+		return ErrInvalidInstrument
+	}
+	if s.TickSize < 0 || s.LotSize < 0 || s.MinQuantity < 0 || s.MaxQuantity < 0 || s.MinNotional < 0 {
+		return ErrInvalidInstrument
+	}
+	if s.TickSize.Precision() > s.PricePrecision || s.LotSize.Precision() > s.AmountPrecision {
+		return ErrInvalidInstrument // Increments finer than the precision allows
+	}
+	if s.MaxQuantity > 0 && s.MaxQuantity < s.MinQuantity {
+		return ErrInvalidInstrument
+	}
+	if s.Matching != nil {
+		return s.Matching.Validate()
+	}
+	return nil
+}
+
+// tick returns the smallest price increment of the instrument.
+func (s *InstrumentSpec) tick() Decimal {
+	if s.TickSize > 0 {
+		return s.TickSize
+	}
+	return Decimal(pow10[DecimalPlaces-s.PricePrecision])
+}
+
+// lot returns the smallest amount increment of the instrument.
+func (s *InstrumentSpec) lot() Decimal {
+	if s.LotSize > 0 {
+		return s.LotSize
+	}
+	return Decimal(pow10[DecimalPlaces-s.AmountPrecision])
+}
+
+// roundLot rounds an amount down to a whole number of lots.
+func (s *InstrumentSpec) roundLot(amount Decimal) Decimal {
+	return amount - amount%s.lot()
+}
+
+// checkIncrements rejects prices and amounts finer than the precision, tick
+// size or lot size of the instrument. Zero values are not checked.
+func (s *InstrumentSpec) checkIncrements(price, amount Decimal) error {
+	if price.Precision() > s.PricePrecision || amount.Precision() > s.AmountPrecision {
+		return ErrInvalidPrecision
+	}
+	if price%s.tick() != 0 {
+		return ErrInvalidTickSize
+	}
+	if amount%s.lot() != 0 {
+		return ErrInvalidLotSize
+	}
+	return nil
+}
+
+// checkSize rejects amounts outside the quantity limits of the instrument, and
+// priced orders worth less than its minimum notional. A zero price is not
+// checked against the minimum notional.
+func (s *InstrumentSpec) checkSize(price, amount Decimal) error {
+	if amount < s.MinQuantity {
+		return ErrBelowMinQuantity
+	}
+	if s.MaxQuantity > 0 && amount > s.MaxQuantity {
+		return ErrAboveMaxQuantity
+	}
+	if price > 0 && price.Mul(amount) < s.MinNotional {
+		return ErrBelowMinNotional
+	}
+	return nil
+}
+
+// Instrument returns the spec the book validates orders against.
+func (ob *OrderBook) Instrument() InstrumentSpec {
+	return ob.spec
+}
@@ -0,0 +1,138 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrJournal = errors.New("Failed to write journal")
+
+// Journal durably records the commands accepted by a book, in order, so the
+// book can be rebuilt by replaying them.
+type Journal interface {
+	Append(record []byte) error
+}
+
+// CommandType identifies the book operation a journaled command replays.
+type CommandType string
+
+const (
+	CommandPlace         CommandType = "PLACE"          // PlaceOrder
+	CommandProcess       CommandType = "PROCESS"        // ProcessOrder
+	CommandCancel        CommandType = "CANCEL"         // CancelOrder
+	CommandModify        CommandType = "MODIFY"         // ModifyOrder
+	CommandModifyStop    CommandType = "MODIFY_STOP"    // ModifyStopOrder
+	CommandExpire        CommandType = "EXPIRE"         // ExpireOrders
+	CommandHalt          CommandType = "HALT"           // Halt
+	CommandResume        CommandType = "RESUME"         // Resume
+	CommandClose         CommandType = "CLOSE"          // Close
+	CommandAuction       CommandType = "AUCTION"        // End of a circuit breaker cooldown
+	CommandStartAuction  CommandType = "START_AUCTION"  // StartAuction
+	CommandUncross       CommandType = "UNCROSS"        // Uncross, or the end of a timed auction
+	CommandSetFees       CommandType = "SET_FEES"       // SetFeeSchedule
+	CommandDeposit       CommandType = "DEPOSIT"        // Deposit
+	CommandWithdraw      CommandType = "WITHDRAW"       // Withdraw
+	CommandSetProtection CommandType = "SET_PROTECTION" // SetPriceProtection
+)
+
+// Command is a journaled book operation. Orders are recorded after
+// validation, with their assigned ID, and Time is the book's clock when the
+// command was applied so that expiry behaves the same on replay.
+type Command struct {
+	Type       CommandType      `json:"type"`
+	Time       time.Time        `json:"time"`
+	Order      *Order           `json:"order,omitempty"`
+	OrderID    string           `json:"order_id,omitempty"`
+	Price      Decimal          `json:"price,omitempty"`
+	Amount     Decimal          `json:"amount,omitempty"`
+	StopPrice  Decimal          `json:"stop_price,omitempty"`
+	Fees       *FeeSchedule     `json:"fees,omitempty"`
+	Account    string           `json:"account,omitempty"`
+	Asset      Asset            `json:"asset,omitempty"`
+	Protection *PriceProtection `json:"protection,omitempty"`
+	Auction    AuctionType      `json:"auction,omitempty"`
+	Duration   time.Duration    `json:"duration,omitempty"`
+}
+
+// Replay applies a command read back from the journal without recording it
+// again, counting it as applied for the next snapshot. Commands replay with
+// the outcome they originally had, so an error from the command itself is
+// not reported; only a record that cannot be decoded is.
+func (ob *OrderBook) Replay(record []byte) error {
+	var cmd Command
+	if err := json.Unmarshal(record, &cmd); err != nil {
+		return fmt.Errorf("Invalid journal record: %w", err)
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = cmd.Time
+	ob.lsn++
+	ob.replaying = true
+	defer func() { ob.replaying = false }()
+
+	switch cmd.Type {
+	case CommandPlace, CommandProcess:
+		if cmd.Order == nil {
+			return fmt.Errorf("Journal record %s has no order", cmd.Type)
+		}
+		if cmd.Type == CommandPlace {
+			ob.placeOrder(*cmd.Order)
+		} else {
+			ob.processOrder(*cmd.Order)
+		}
+	case CommandCancel:
+		ob.cancelOrder(cmd.OrderID)
+	case CommandModify:
+		ob.modifyOrder(cmd.OrderID, cmd.Price, cmd.Amount)
+	case CommandModifyStop:
+		ob.modifyStopOrder(cmd.OrderID, cmd.StopPrice, cmd.Price, cmd.Amount)
+	case CommandExpire:
+		ob.expireOrders(cmd.Time)
+	case CommandHalt, CommandResume, CommandClose, CommandAuction:
+		ob.applyStatus(cmd.Type)
+	case CommandStartAuction:
+		ob.startAuction(cmd.Auction, cmd.Duration)
+	case CommandUncross:
+		ob.endAuction()
+	case CommandSetFees:
+		if cmd.Fees == nil {
+			return fmt.Errorf("Journal record %s has no fee schedule", cmd.Type)
+		}
+		ob.setFeeSchedule(*cmd.Fees)
+	case CommandSetProtection:
+		if cmd.Protection == nil {
+			return fmt.Errorf("Journal record %s has no price protection", cmd.Type)
+		}
+		ob.setPriceProtection(*cmd.Protection)
+	case CommandDeposit, CommandWithdraw:
+		ob.transfer(cmd.Type, cmd.Account, cmd.Asset, cmd.Amount)
+	default:
+		return fmt.Errorf("Unknown journal command %q", cmd.Type)
+	}
+	return nil
+}
+
+// record appends a command to the journal before it changes the book. If the
+// write fails the command must not be applied.
+// Must be called with the lock held.
+func (ob *OrderBook) record(cmd Command) error {
+	if ob.journal == nil || ob.replaying {
+		return nil
+	}
+	cmd.Time = ob.at
+
+	record, err := json.Marshal(cmd)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrJournal, err)
+	}
+	if err := ob.journal.Append(record); err != nil {
+		return fmt.Errorf("%w: %v", ErrJournal, err)
+	}
+	ob.lsn++
+	return nil
+}
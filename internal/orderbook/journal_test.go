@@ -0,0 +1,110 @@
+package orderbook
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type memJournal struct {
+	records [][]byte
+	err     error
+}
+
+func (j *memJournal) Append(record []byte) error {
+	if j.err != nil {
+		return j.err
+	}
+	j.records = append(j.records, record)
+	return nil
+}
+
+func TestJournal_ReplayRebuildsBook(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithClock(clock), WithJournal(j))
+
+	expireAt := now.Add(time.Minute)
+	ob.PlaceOrder(Order{ID: "ask-1", Price: dec(101.0), Amount: dec(2.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "ask-2", Price: dec(101.0), Amount: dec(5.0), PeakAmount: dec(1.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "gtd", Price: dec(99.0), Amount: dec(1.0), Side: Buy, TimeInForce: GTD, ExpireAt: &expireAt})
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(98.0), Amount: dec(3.0), Side: Buy})
+	ob.ProcessOrder(Order{ID: "stop", Type: StopLimit, StopPrice: dec(102.0), Price: dec(103.0), Amount: dec(1.0), Side: Buy})
+	ob.ProcessOrder(Order{Price: dec(101.0), Amount: dec(2.5), Side: Buy}) // ID assigned by the book
+	ob.ModifyOrder("bid", dec(97.0), dec(4.0))
+	ob.ModifyStopOrder("stop", dec(101.5), dec(103.0), dec(1.0))
+	ob.ProcessOrder(Order{ID: "rejected", Price: dec(1.0), Amount: dec(-1.0), Side: Buy})
+	now = now.Add(2 * time.Minute)
+	ob.ExpireOrders(now)
+	ob.PlaceOrder(Order{ID: "ask-3", Price: dec(105.0), Amount: dec(1.0), Side: Sell})
+	ob.CancelOrder("ask-3")
+
+	// Rebuild a fresh book, running much later, from the journal alone
+	later := now.Add(time.Hour)
+	replayed := NewOrderBook("TEST", WithClock(func() time.Time { return later }))
+	replayed.ID = ob.ID
+	for _, record := range j.records {
+		if err := replayed.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+
+	if !reflect.DeepEqual(bookState(replayed), bookState(ob)) {
+		t.Errorf("Replayed book differs:\n got %+v\nwant %+v", bookState(replayed), bookState(ob))
+	}
+	if replayed.lastPrice != ob.lastPrice || replayed.arrivals != ob.arrivals {
+		t.Errorf("Expected last price %v and arrivals %d, got %v and %d",
+			ob.lastPrice, ob.arrivals, replayed.lastPrice, replayed.arrivals)
+	}
+	if _, waiting := ob.orders["gtd"]; waiting {
+		t.Error("Expected the GTD order to have expired")
+	}
+
+	// Trades after recovery carry on the same numbering
+	next := Order{ID: "next", Price: dec(101.0), Amount: dec(0.5), Side: Buy}
+	want, _ := ob.ProcessOrder(next)
+	got, _ := replayed.ProcessOrder(next)
+	if got.Trades[0].ID != want.Trades[0].ID || got.Trades[0].Seq != want.Trades[0].Seq {
+		t.Errorf("Expected trade %s #%d, got %s #%d", want.Trades[0].ID, want.Trades[0].Seq, got.Trades[0].ID, got.Trades[0].Seq)
+	}
+}
+
+func TestJournal_WriteFailure(t *testing.T) {
+	j := &memJournal{err: errors.New("disk full")}
+	ob := NewOrderBook("TEST", WithJournal(j))
+
+	if err := ob.PlaceOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy}); !errors.Is(err, ErrJournal) {
+		t.Errorf("Expected ErrJournal, got %v", err)
+	}
+	if len(ob.bids.orders()) != 0 {
+		t.Error("Expected the order not to be applied")
+	}
+	if err := ob.PlaceOrder(Order{Price: dec(-1.0), Amount: dec(1.0), Side: Buy}); err != ErrInvalidOrder {
+		t.Errorf("Expected invalid orders to be rejected before journaling, got %v", err)
+	}
+	if err := ob.Replay([]byte("not json")); err == nil {
+		t.Error("Expected an error for an undecodable record")
+	}
+}
+
+type nodeState struct {
+	Order   Order
+	Seq     uint64
+	Visible Decimal
+}
+
+func bookState(ob *OrderBook) map[string][]nodeState {
+	state := make(map[string][]nodeState)
+	sides := map[string]*bookSide{"bids": ob.bids, "asks": ob.asks, "buyStops": ob.buyStops, "sellStops": ob.sellStops}
+	for name, side := range sides {
+		side.each(func(level *priceLevel) bool {
+			for n := level.head; n != nil; n = n.next {
+				state[name] = append(state[name], nodeState{Order: n.order, Seq: n.seq, Visible: n.visible})
+			}
+			return true
+		})
+	}
+	return state
+}
@@ -0,0 +1,237 @@
+package orderbook
+
+import (
+	"math/rand"
+)
+
+// maxSkipHeight bounds the number of express lanes in a bookSide skiplist.
+// 2^16 levels comfortably covers any realistic number of distinct prices.
+const maxSkipHeight = 16
+
+// orderNode is a resting order linked into the FIFO queue of its price level.
+type orderNode struct {
+	order   Order
+	seq     uint64  // Arrival sequence, breaks ties deterministically
+	visible Decimal // Displayed part of the amount, less than it for icebergs
+	level   *priceLevel
+	prev    *orderNode
+	next    *orderNode
+}
+
+func newOrderNode(order Order, seq uint64) *orderNode {
+	return &orderNode{order: order, seq: seq, visible: order.DisplayAmount()}
+}
+
+// resize changes the remaining amount of a queued order in place, keeping
+// the level total in line with the displayed amount.
+func (n *orderNode) resize(amount Decimal) {
+	visible := amount
+	if n.order.PeakAmount > 0 {
+		visible = n.visible.Min(amount)
+	}
+	n.level.total += visible - n.visible
+	n.visible = visible
+	n.order.Amount = amount
+}
+
+// public returns the order as other participants see it, without the
+// hidden reserve of an iceberg.
+func (n *orderNode) public() Order {
+	order := n.order
+	order.Amount = n.visible
+	order.PeakAmount = 0
+	return order
+}
+
+// priceLevel is a FIFO queue of resting orders sharing the same price.
+// Its total only counts displayed amounts.
+type priceLevel struct {
+	price Decimal
+	total Decimal
+	count int
+	head  *orderNode
+	tail  *orderNode
+}
+
+// pushBack appends a node at the end of the queue (lowest time priority).
+func (l *priceLevel) pushBack(n *orderNode) {
+	n.level = l
+	n.prev = l.tail
+	n.next = nil
+	if l.tail != nil {
+		l.tail.next = n
+	} else {
+		l.head = n
+	}
+	l.tail = n
+	l.total += n.visible
+	l.count++
+}
+
+// remove unlinks a node from the queue.
+func (l *priceLevel) remove(n *orderNode) {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else {
+		l.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else {
+		l.tail = n.prev
+	}
+	n.prev, n.next = nil, nil
+	l.total -= n.visible
+	l.count--
+}
+
+// skipNode is a tower in the bookSide skiplist.
+type skipNode struct {
+	level *priceLevel
+	next  []*skipNode
+}
+
+// bookSide holds the price levels of one side of the book, kept sorted from
+// best to worst price in a skiplist, with a map for direct level lookup.
+// The trigger book reuses it keyed by stop price instead of limit price.
+type bookSide struct {
+	ascending bool // true for asks, false for bids
+	byStop    bool // true for the trigger book
+	head      *skipNode
+	height    int
+	levels    map[Decimal]*priceLevel
+	rnd       *rand.Rand
+}
+
+func newBookSide(ascending bool) *bookSide {
+	return &bookSide{
+		ascending: ascending,
+		head:      &skipNode{next: make([]*skipNode, maxSkipHeight)},
+		height:    1,
+		levels:    make(map[Decimal]*priceLevel),
+		rnd:       rand.New(rand.NewSource(rand.Int63())),
+	}
+}
+
+// newTriggerSide returns a bookSide ordering stop orders by stop price.
+func newTriggerSide(ascending bool) *bookSide {
+	s := newBookSide(ascending)
+	s.byStop = true
+	return s
+}
+
+// key returns the price an order is sorted by on this side.
+func (s *bookSide) key(o *Order) Decimal {
+	if s.byStop {
+		return o.StopPrice
+	}
+	return o.Price
+}
+
+// before reports whether price a has priority over price b on this side.
+func (s *bookSide) before(a, b Decimal) bool {
+	if s.ascending {
+		return a < b
+	}
+	return a > b
+}
+
+// best returns the level with the best price, or nil if the side is empty.
+func (s *bookSide) best() *priceLevel {
+	if first := s.head.next[0]; first != nil {
+		return first.level
+	}
+	return nil
+}
+
+// depth returns the number of price levels on this side.
+func (s *bookSide) depth() int {
+	return len(s.levels)
+}
+
+// each calls fn for every level from best to worst price until fn returns false.
+func (s *bookSide) each(fn func(*priceLevel) bool) {
+	for n := s.head.next[0]; n != nil; n = n.next[0] {
+		if !fn(n.level) {
+			return
+		}
+	}
+}
+
+// add appends an order node to the level at its price, creating the level
+// if necessary.
+func (s *bookSide) add(n *orderNode) {
+	price := s.key(&n.order)
+	level, ok := s.levels[price]
+	if !ok {
+		level = &priceLevel{price: price}
+		s.insertLevel(level)
+	}
+	level.pushBack(n)
+}
+
+// unlink removes an order node from its level, dropping the level once empty.
+func (s *bookSide) unlink(n *orderNode) {
+	level := n.level
+	level.remove(n)
+	if level.count == 0 {
+		s.removeLevel(level.price)
+	}
+}
+
+func (s *bookSide) randomHeight() int {
+	h := 1
+	for h < maxSkipHeight && s.rnd.Intn(4) == 0 {
+		h++
+	}
+	return h
+}
+
+func (s *bookSide) insertLevel(level *priceLevel) {
+	var update [maxSkipHeight]*skipNode
+	x := s.head
+	for i := s.height - 1; i >= 0; i-- {
+		for x.next[i] != nil && s.before(x.next[i].level.price, level.price) {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	h := s.randomHeight()
+	if h > s.height {
+		for i := s.height; i < h; i++ {
+			update[i] = s.head
+		}
+		s.height = h
+	}
+
+	node := &skipNode{level: level, next: make([]*skipNode, h)}
+	for i := 0; i < h; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	s.levels[level.price] = level
+}
+
+func (s *bookSide) removeLevel(price Decimal) {
+	var update [maxSkipHeight]*skipNode
+	x := s.head
+	for i := s.height - 1; i >= 0; i-- {
+		for x.next[i] != nil && s.before(x.next[i].level.price, price) {
+			x = x.next[i]
+		}
+		update[i] = x
+	}
+
+	target := x.next[0]
+	if target == nil || target.level.price != price {
+		return
+	}
+	for i := 0; i < len(target.next); i++ {
+		update[i].next[i] = target.next[i]
+	}
+	for s.height > 1 && s.head.next[s.height-1] == nil {
+		s.height--
+	}
+	delete(s.levels, price)
+}
@@ -0,0 +1,244 @@
+package orderbook
+
+import (
+	"cmp"
+	"errors"
+	"math/bits"
+	"slices"
+)
+
+var ErrInvalidMatching = errors.New("Invalid matching specification")
+
+// MatchingPolicy decides how the orders resting at a price level share an
+// incoming order. Policies must be deterministic, since replaying the
+// journal matches every order again.
+type MatchingPolicy interface {
+	// Allocate splits amount, at most the sum of the orders' amounts, among
+	// orders given in time priority and returns how much each one trades.
+	// Allocations should be whole lots no larger than the order's amount;
+	// whatever is left unallocated goes to the orders in time priority.
+	Allocate(amount Decimal, orders []RestingOrder, lot Decimal) []Decimal
+}
+
+// RestingOrder is what a matching policy sees of a resting order.
+type RestingOrder struct {
+	ID      string
+	Account string
+	Amount  Decimal // Displayed amount, all that can trade now
+}
+
+// FIFO fills the orders of a level one after the other in time priority.
+// It is the default policy, and the book walks the level in place for it
+// rather than allocating the whole level.
+type FIFO struct{}
+
+func (FIFO) Allocate(amount Decimal, orders []RestingOrder, lot Decimal) []Decimal {
+	allocations := make([]Decimal, len(orders))
+	fillInOrder(amount, orders, allocations, indexes(len(orders)))
+	return allocations
+}
+
+// Remainder decides who gets what a pro-rata allocation leaves over.
+type Remainder string
+
+const (
+	RemainderTime    Remainder = "TIME"    // Orders in time priority, the default
+	RemainderLargest Remainder = "LARGEST" // Largest orders first, then in time priority
+)
+
+// ProRata shares an incoming order among the orders of a level in proportion
+// to their displayed amounts, rounded down to whole lots. Shares smaller
+// than MinAllocation are not given, and what rounding and those shares
+// leave over goes to the orders in the Remainder order.
+type ProRata struct {
+	MinAllocation Decimal
+	Remainder     Remainder
+}
+
+func (p ProRata) Allocate(amount Decimal, orders []RestingOrder, lot Decimal) []Decimal {
+	allocations := make([]Decimal, len(orders))
+	p.share(amount, orders, lot, allocations, indexes(len(orders)))
+	return allocations
+}
+
+// share adds a pro-rata share of amount to the allocations of the orders at
+// the indexes, in proportion to what each can still trade, and returns how
+// much it allocated.
+func (p ProRata) share(amount Decimal, orders []RestingOrder, lot Decimal, allocations []Decimal, at []int) Decimal {
+	var total Decimal
+	for _, i := range at {
+		total += orders[i].Amount - allocations[i]
+	}
+	if total <= amount {
+		for _, i := range at {
+			allocations[i] = orders[i].Amount
+		}
+		return total
+	}
+
+	left := amount
+	for _, i := range at {
+		share := roundDown(mulDiv(amount, orders[i].Amount-allocations[i], total), lot)
+		if share > 0 && share >= p.MinAllocation {
+			allocations[i] += share
+			left -= share
+		}
+	}
+
+	if p.Remainder == RemainderLargest {
+		at = slices.Clone(at)
+		slices.SortStableFunc(at, func(a, b int) int {
+			return cmp.Compare(orders[b].Amount, orders[a].Amount)
+		})
+	}
+	fillInOrder(left, orders, allocations, at)
+	return amount
+}
+
+// Hybrid gives the oldest order of a level, the top order, and the accounts
+// of lead market makers priority over a pro-rata share of the rest, as
+// futures exchanges do.
+type Hybrid struct {
+	TopOrder    bool    // The top order fills first
+	TopOrderMax Decimal // Most the top order fills first, zero for no limit
+
+	// Basis points of what is left after the top order that the orders of
+	// market makers share pro-rata before everyone else
+	MarketMakers   []string
+	MarketMakerBps int64
+
+	ProRata ProRata // How the rest is shared
+}
+
+func (h Hybrid) Allocate(amount Decimal, orders []RestingOrder, lot Decimal) []Decimal {
+	allocations := make([]Decimal, len(orders))
+	if len(orders) == 0 {
+		return allocations
+	}
+
+	if h.TopOrder {
+		top := amount.Min(orders[0].Amount)
+		if h.TopOrderMax > 0 {
+			top = top.Min(h.TopOrderMax)
+		}
+		allocations[0] = top
+		amount -= top
+	}
+
+	if h.MarketMakerBps > 0 {
+		var makers []int
+		for i, order := range orders {
+			if slices.Contains(h.MarketMakers, order.Account) {
+				makers = append(makers, i)
+			}
+		}
+		reserved := roundDown(mulDiv(amount, Decimal(h.MarketMakerBps), 10000), lot)
+		amount -= h.ProRata.share(reserved, orders, lot, allocations, makers)
+	}
+
+	h.ProRata.share(amount, orders, lot, allocations, indexes(len(orders)))
+	return allocations
+}
+
+// fillInOrder allocates amount to the orders at the indexes one after the
+// other, each up to what it can still trade, and returns what is left.
+func fillInOrder(amount Decimal, orders []RestingOrder, allocations []Decimal, at []int) Decimal {
+	for _, i := range at {
+		if amount == 0 {
+			break
+		}
+		take := amount.Min(orders[i].Amount - allocations[i])
+		allocations[i] += take
+		amount -= take
+	}
+	return amount
+}
+
+func indexes(n int) []int {
+	at := make([]int, n)
+	for i := range at {
+		at[i] = i
+	}
+	return at
+}
+
+// roundDown rounds an amount down to a whole number of lots.
+func roundDown(amount, lot Decimal) Decimal {
+	if lot <= 0 {
+		return amount
+	}
+	return amount - amount%lot
+}
+
+// mulDiv returns a * b / c rounded down, without overflowing in between.
+// The result must fit, which it does whenever a or b is at most c.
+func mulDiv(a, b, c Decimal) Decimal {
+	hi, lo := bits.Mul64(uint64(a), uint64(b))
+	q, _ := bits.Div64(hi, lo, uint64(c))
+	return Decimal(q)
+}
+
+// MatchingAlgorithm names a matching policy of an instrument.
+type MatchingAlgorithm string
+
+const (
+	MatchingFIFO    MatchingAlgorithm = "FIFO"
+	MatchingProRata MatchingAlgorithm = "PRO_RATA"
+	MatchingHybrid  MatchingAlgorithm = "HYBRID"
+)
+
+// MatchingSpec describes the matching policy of an instrument. Fields that
+// do not apply to the algorithm must be left zero.
+type MatchingSpec struct {
+	Algorithm MatchingAlgorithm `json:"algorithm"`
+
+	// Pro-rata and hybrid
+	MinAllocation Decimal   `json:"min_allocation,omitempty"`
+	Remainder     Remainder `json:"remainder,omitempty"`
+
+	// Hybrid only
+	TopOrder       bool     `json:"top_order,omitempty"`
+	TopOrderMax    Decimal  `json:"top_order_max,omitempty"`
+	MarketMakers   []string `json:"market_makers,omitempty"`
+	MarketMakerBps int64    `json:"market_maker_bps,omitempty"`
+}
+
+// Validate checks that the spec names a known algorithm with settings that
+// apply to it, returning ErrInvalidMatching if it does not.
+func (s *MatchingSpec) Validate() error {
+	hybrid := s.TopOrder || s.TopOrderMax != 0 || len(s.MarketMakers) > 0 || s.MarketMakerBps != 0
+	switch {
+	case s.Algorithm == MatchingFIFO && (s.MinAllocation != 0 || s.Remainder != "" || hybrid),
+		s.Algorithm == MatchingProRata && hybrid,
+		s.Algorithm != MatchingFIFO && s.Algorithm != MatchingProRata && s.Algorithm != MatchingHybrid:
+		return ErrInvalidMatching
+	}
+	if s.MinAllocation < 0 || s.TopOrderMax < 0 || (s.TopOrderMax > 0 && !s.TopOrder) {
+		return ErrInvalidMatching
+	}
+	if s.Remainder != "" && s.Remainder != RemainderTime && s.Remainder != RemainderLargest {
+		return ErrInvalidMatching
+	}
+	if s.MarketMakerBps < 0 || s.MarketMakerBps > 10000 || (s.MarketMakerBps > 0) != (len(s.MarketMakers) > 0) {
+		return ErrInvalidMatching
+	}
+	return nil
+}
+
+// Policy returns the matching policy the spec describes.
+func (s *MatchingSpec) Policy() MatchingPolicy {
+	prorata := ProRata{MinAllocation: s.MinAllocation, Remainder: s.Remainder}
+	switch s.Algorithm {
+	case MatchingProRata:
+		return prorata
+	case MatchingHybrid:
+		return Hybrid{
+			TopOrder:       s.TopOrder,
+			TopOrderMax:    s.TopOrderMax,
+			MarketMakers:   slices.Clone(s.MarketMakers),
+			MarketMakerBps: s.MarketMakerBps,
+			ProRata:        prorata,
+		}
+	}
+	return FIFO{}
+}
@@ -0,0 +1,119 @@
+package orderbook
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchingPolicies_Allocate(t *testing.T) {
+	resting := func(amounts ...float64) []RestingOrder {
+		orders := make([]RestingOrder, len(amounts))
+		for i, amount := range amounts {
+			orders[i] = RestingOrder{Account: "a", Amount: dec(amount)}
+		}
+		return orders
+	}
+	decs := func(amounts ...float64) []Decimal {
+		out := make([]Decimal, len(amounts))
+		for i, amount := range amounts {
+			out[i] = dec(amount)
+		}
+		return out
+	}
+	makers := resting(5, 5, 10)
+	makers[1].Account = "mm"
+
+	tests := []struct {
+		name     string
+		policy   MatchingPolicy
+		amount   float64
+		orders   []RestingOrder
+		expected []Decimal
+	}{
+		{"FIFO", FIFO{}, 4, resting(3, 2, 5), decs(3, 1, 0)},
+		{"Pro-rata", ProRata{}, 5, resting(1, 2, 7), decs(1, 1, 3)},
+		{"Pro-rata remainder to the largest", ProRata{Remainder: RemainderLargest}, 5, resting(1, 2, 7), decs(0, 1, 4)},
+		{"Pro-rata min allocation", ProRata{MinAllocation: dec(2.0)}, 5, resting(2, 4, 14), decs(2, 0, 3)},
+		{"Pro-rata whole level", ProRata{}, 10, resting(3, 2), decs(3, 2)},
+		{"Hybrid top order", Hybrid{TopOrder: true, TopOrderMax: dec(2.0)}, 6, resting(5, 5, 10), decs(3, 1, 2)},
+		{"Hybrid market makers", Hybrid{MarketMakers: []string{"mm"}, MarketMakerBps: 4000}, 10, makers, decs(3, 4, 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocations := tt.policy.Allocate(dec(tt.amount), tt.orders, dec(1.0))
+			if !reflect.DeepEqual(allocations, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, allocations)
+			}
+		})
+	}
+}
+
+func TestProRataMatching(t *testing.T) {
+	spec := InstrumentSpec{LotSize: dec(1.0), Matching: &MatchingSpec{Algorithm: MatchingProRata}}
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithInstrument(spec), WithJournal(j))
+	for _, o := range []struct {
+		id, account string
+		amount      float64
+	}{{"a", "a", 1}, {"b", "b", 2}, {"c", "c", 7}, {"own", "x", 5}, {"d", "d", 10}} {
+		ob.PlaceOrder(Order{ID: o.id, Account: o.account, Price: dec(100.0), Amount: dec(o.amount), Side: Buy})
+	}
+
+	// Only the orders ahead of the seller's own order share it
+	result, _ := ob.ProcessOrder(Order{Account: "x", Price: dec(100.0), Amount: dec(5.0), Side: Sell})
+	fills := map[string]Decimal{}
+	for _, trade := range result.Trades {
+		fills[trade.MakerOrderID] = trade.Amount
+	}
+	if expected := map[string]Decimal{"a": dec(1.0), "b": dec(1.0), "c": dec(3.0)}; !reflect.DeepEqual(fills, expected) {
+		t.Errorf("Expected fills %v, got %v", expected, fills)
+	}
+
+	// Reaching the own order cancels the rest of the seller's order
+	result, _ = ob.ProcessOrder(Order{Account: "x", Price: dec(100.0), Amount: dec(20.0), Side: Sell})
+	if result.FilledAmount != dec(5.0) || result.CancelledAmount != dec(15.0) {
+		t.Errorf("Expected 5 filled and 15 cancelled, got %+v", result)
+	}
+	if best, _ := ob.GetBestBid(); best.ID != "own" {
+		t.Errorf("Expected the own order at the front, got %+v", best)
+	}
+
+	replayed := NewOrderBook("TEST", WithInstrument(spec))
+	for _, record := range j.records {
+		if err := replayed.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+	if got, want := replayed.GetOrderBookSnapshot(), ob.GetOrderBookSnapshot(); !reflect.DeepEqual(got.Bids, want.Bids) {
+		t.Errorf("Expected replay to match the same way, got %+v, want %+v", got.Bids, want.Bids)
+	}
+}
+
+func TestMatchingSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec MatchingSpec
+		err  error
+	}{
+		{"FIFO", MatchingSpec{Algorithm: MatchingFIFO}, nil},
+		{"Pro-rata", MatchingSpec{Algorithm: MatchingProRata, MinAllocation: dec(2.0), Remainder: RemainderLargest}, nil},
+		{"Hybrid", MatchingSpec{Algorithm: MatchingHybrid, TopOrder: true, MarketMakers: []string{"mm"}, MarketMakerBps: 4000}, nil},
+		{"Unknown algorithm", MatchingSpec{Algorithm: "LIFO"}, ErrInvalidMatching},
+		{"FIFO with min allocation", MatchingSpec{Algorithm: MatchingFIFO, MinAllocation: dec(1.0)}, ErrInvalidMatching},
+		{"Pro-rata with top order", MatchingSpec{Algorithm: MatchingProRata, TopOrder: true}, ErrInvalidMatching},
+		{"Negative min allocation", MatchingSpec{Algorithm: MatchingProRata, MinAllocation: dec(-1.0)}, ErrInvalidMatching},
+		{"Unknown remainder", MatchingSpec{Algorithm: MatchingProRata, Remainder: "RANDOM"}, ErrInvalidMatching},
+		{"Top order max without top order", MatchingSpec{Algorithm: MatchingHybrid, TopOrderMax: dec(1.0)}, ErrInvalidMatching},
+		{"Market makers without a share", MatchingSpec{Algorithm: MatchingHybrid, MarketMakers: []string{"mm"}}, ErrInvalidMatching},
+		{"Market maker share above 100%", MatchingSpec{Algorithm: MatchingHybrid, MarketMakers: []string{"mm"}, MarketMakerBps: 10001}, ErrInvalidMatching},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(); err != tt.err {
+				t.Errorf("Expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package orderbook
+
+import (
+	"slices"
+	"time"
+)
+
+// Option configures an OrderBook at construction time.
+type Option func(*OrderBook)
+
+// WithPrecision sets how many fractional digits the book accepts for prices
+// and amounts. Orders with finer values are rejected with ErrInvalidPrecision.
+// Both default to DecimalPlaces.
+func WithPrecision(priceDecimals, amountDecimals int) Option {
+	return func(ob *OrderBook) {
+		ob.spec.PricePrecision = clampPrecision(priceDecimals)
+		ob.spec.AmountPrecision = clampPrecision(amountDecimals)
+	}
+}
+
+// WithInstrument sets the spec orders are validated against, replacing any
+// precision set before it. Orders violating it are rejected with the error
+// of the rule they break, and a matching spec in it replaces the matching
+// policy. The spec should pass InstrumentSpec.Validate.
+func WithInstrument(spec InstrumentSpec) Option {
+	return func(ob *OrderBook) {
+		spec.PricePrecision = clampPrecision(spec.PricePrecision)
+		spec.AmountPrecision = clampPrecision(spec.AmountPrecision)
+		if spec.Matching != nil {
+			matching := *spec.Matching
+			matching.MarketMakers = slices.Clone(matching.MarketMakers)
+			spec.Matching = &matching
+			ob.matching = matching.Policy()
+		}
+		ob.spec = spec
+	}
+}
+
+// WithMatchingPolicy sets how the orders resting at a price level share an
+// incoming order. Defaults to FIFO.
+func WithMatchingPolicy(policy MatchingPolicy) Option {
+	return func(ob *OrderBook) {
+		ob.matching = policy
+	}
+}
+
+func clampPrecision(p int) int {
+	return max(0, min(p, DecimalPlaces))
+}
+
+// WithClock replaces the clock the book uses to evaluate GTD expiry and
+// when circuit breaker halts and auctions end.
+func WithClock(now func() time.Time) Option {
+	return func(ob *OrderBook) {
+		ob.now = now
+	}
+}
+
+// WithSelfTradePrevention sets the mode applied when orders of the same
+// account would match. Defaults to CancelNewest.
+func WithSelfTradePrevention(mode SelfTradePrevention) Option {
+	return func(ob *OrderBook) {
+		ob.stp = mode
+	}
+}
+
+// WithJournal records every command that changes the book to j before it is
+// applied. Commands whose record cannot be written fail with ErrJournal.
+func WithJournal(j Journal) Option {
+	return func(ob *OrderBook) {
+		ob.journal = j
+	}
+}
+
+// WithPreTradeCheck vets every new order, and every order as it would be
+// after a modification, with check before the book takes it.
+func WithPreTradeCheck(check PreTradeCheck) Option {
+	return func(ob *OrderBook) {
+		ob.preTradeCheck = check
+	}
+}
+
+// WithFeeSchedule sets the fees charged on trades. The schedule should pass
+// FeeSchedule.Validate. Defaults to no fees.
+func WithFeeSchedule(schedule FeeSchedule) Option {
+	return func(ob *OrderBook) {
+		ob.fees = schedule.clone()
+	}
+}
+
+// WithBalances makes the book hold funds for each account. Orders then need
+// an account that can afford them, funds are reserved while orders rest and
+// trades settle between the balances of both accounts.
+func WithBalances() Option {
+	return func(ob *OrderBook) {
+		ob.balances = make(map[string]*AccountBalances)
+	}
+}
+
+// WithChecksumDepth sets how many levels of each side the checksums of the
+// book cover, at least one. Defaults to DefaultChecksumDepth.
+func WithChecksumDepth(depth int) Option {
+	return func(ob *OrderBook) {
+		ob.checksumDepth = max(depth, 1)
+	}
+}
+
+// WithEventHistory keeps the last n events, for SubscribeFrom to resume
+// subscriptions from. Defaults to none.
+func WithEventHistory(n int) Option {
+	return func(ob *OrderBook) {
+		ob.history = make([]Event, max(n, 0))
+	}
+}
+
+// WithSubscriptionBuffer sets how many events a subscriber may fall behind
+// before it is dropped, at least the event history. Defaults to
+// DefaultSubscriptionBuffer.
+func WithSubscriptionBuffer(n int) Option {
+	return func(ob *OrderBook) {
+		ob.subscriptionBuffer = max(n, 1)
+	}
+}
+
+// WithPriceProtection sets the price bands and circuit breaker of the book.
+// The settings should pass PriceProtection.Validate. Defaults to none.
+func WithPriceProtection(protection PriceProtection) Option {
+	return func(ob *OrderBook) {
+		ob.protection = protection
+	}
+}
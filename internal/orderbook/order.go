@@ -3,6 +3,7 @@ package orderbook
 import (
 	"fmt"
 	"github.com/google/uuid"
+	"time"
 
 )
 
@@ -13,14 +14,114 @@ const (
 	Sell Side = "SELL"
 )
 
+type OrderType string
+
+const (
+	Limit     OrderType = "LIMIT"      // Rests at its price until filled or cancelled
+	Market    OrderType = "MARKET"     // Sweeps the opposite side and never rests
+	Stop      OrderType = "STOP"       // Becomes a market order once StopPrice trades
+	StopLimit OrderType = "STOP_LIMIT" // Becomes a limit order once StopPrice trades
+)
+
+type TimeInForce string
+
+const (
+	GTC TimeInForce = "GTC" // Good till cancelled
+	IOC TimeInForce = "IOC" // Immediate or cancel: fill what is possible, cancel the rest
+	FOK TimeInForce = "FOK" // Fill or kill: fill completely or not at all
+	GTD TimeInForce = "GTD" // Good till date: rests until ExpireAt
+)
+
+// SelfTradePrevention decides what happens when an incoming order would
+// match a resting order of the same account.
+type SelfTradePrevention string
+
+const (
+	CancelNewest       SelfTradePrevention = "CANCEL_NEWEST"        // Cancel the rest of the incoming order
+	CancelOldest       SelfTradePrevention = "CANCEL_OLDEST"        // Cancel the resting order and keep matching
+	CancelBoth         SelfTradePrevention = "CANCEL_BOTH"          // Cancel both orders
+	DecrementAndCancel SelfTradePrevention = "DECREMENT_AND_CANCEL" // Reduce both by the smaller amount
+)
+
 type Order struct {
-	ID     string  `json:"id"`
-	Price  float64 `json:"price"`
-	Amount float64 `json:"amount"`
-	Side   Side    `json:"side"`
+	ID     string    `json:"id"`
+	Type   OrderType `json:"type,omitempty"` // Empty means LIMIT
+	Price  Decimal   `json:"price"`
+	Amount Decimal   `json:"amount"`
+	Side   Side      `json:"side"`
+
+	// Orders of the same non-empty account never trade with each other. The
+	// book's self-trade prevention mode applies unless the order sets its own.
+	Account             string              `json:"account,omitempty"`
+	SelfTradePrevention SelfTradePrevention `json:"self_trade_prevention,omitempty"`
+
+	// Trigger for STOP and STOP_LIMIT orders: buy stops activate when the last
+	// trade price rises to StopPrice, sell stops when it falls to StopPrice.
+	StopPrice Decimal `json:"stop_price,omitempty"`
+
+	TimeInForce TimeInForce `json:"time_in_force,omitempty"` // Empty means GTC
+	ExpireAt    *time.Time  `json:"expire_at,omitempty"`     // Required for GTD
+
+	// Post-only orders never take liquidity. If they would cross the book
+	// they are rejected, or with PostOnlySlide repriced one tick behind the
+	// best opposite price.
+	PostOnly      bool `json:"post_only,omitempty"`
+	PostOnlySlide bool `json:"post_only_slide,omitempty"`
+
+	// Iceberg orders only display PeakAmount at a time. Once the displayed
+	// part is filled it is replenished from the hidden reserve and requeued
+	// behind the other orders at its price.
+	PeakAmount Decimal `json:"peak_amount,omitempty"`
+
+	// Optional protections for market orders: the worst acceptable price as
+	// basis points away from the best opposite price on arrival, and a cap on
+	// the total quote value traded.
+	MaxSlippageBps int64   `json:"max_slippage_bps,omitempty"`
+	MaxNotional    Decimal `json:"max_notional,omitempty"`
+}
+
+// maxSlippageBps caps the slippage of market orders at 100% of the best
+// price.
+const maxSlippageBps = 10000
+
+// expired reports whether a GTD order has reached its expiry time.
+func (o *Order) expired(now time.Time) bool {
+	return o.TimeInForce == GTD && o.ExpireAt != nil && !now.Before(*o.ExpireAt)
+}
+
+// IsMarket reports whether the order is a market order.
+func (o *Order) IsMarket() bool {
+	return o.Type == Market
+}
+
+// DisplayAmount returns how much of the order is shown in the book.
+func (o *Order) DisplayAmount() Decimal {
+	if o.PeakAmount > 0 {
+		return o.PeakAmount.Min(o.Amount)
+	}
+	return o.Amount
+}
+
+// atMarket reports whether the order trades at any price once active.
+func (o *Order) atMarket() bool {
+	return o.Type == Market || o.Type == Stop
+}
+
+// IsStop reports whether the order waits in the trigger book for its stop price.
+func (o *Order) IsStop() bool {
+	return o.Type == Stop || o.Type == StopLimit
+}
+
+// Rests reports whether the order can wait in the book: what is left of it
+// after matching rests at its limit price, or it waits for its stop price.
+func (o *Order) Rests() bool {
+	if o.IsStop() {
+		return true
+	}
+	return !o.IsMarket() && o.TimeInForce != IOC && o.TimeInForce != FOK
 }
 
-func NewOrder(price float64, amount float64, side Side) (*Order, error) {
+func NewOrder(price Decimal, amount Decimal, side Side) (*Order, error) {
 
   if price <= 0 {
     return nil, fmt.Errorf("Price must be greater than 0.")
@@ -2,8 +2,7 @@ package orderbook
 
 import (
 	"errors"
-	"math"
-	"sort"
+	"strconv"
 	"sync"
 	"time"
 
@@ -15,208 +14,739 @@ var (
 	ErrOrderNotFound       = errors.New("Order not found")
 	ErrInvalidModification = errors.New("Invalid modification parameters")
 	ErrInvalidOrder        = errors.New("Invalid order's values")
+	ErrDuplicateOrder      = errors.New("Order ID already exists")
+	ErrInvalidPrecision    = errors.New("Too many decimal places for this book")
+	ErrInvalidOrderType    = errors.New("Invalid order type")
+	ErrInvalidTimeInForce  = errors.New("Invalid time in force")
+	ErrInvalidExpiry       = errors.New("Expiry time must be in the future")
+	ErrPostOnlyWouldCross  = errors.New("Post-only order would take liquidity")
+	ErrBookHalted          = errors.New("Book is halted")
 )
 
 // OrderBook represents a collection of buy (bids) and sell (asks) orders.
+// Each side is a sorted set of price levels holding FIFO queues of orders,
+// and every resting order is indexed by ID for constant time lookup.
 type OrderBook struct {
-	Tag  string `json:"Tag"`
-	ID   string `json:"ID"`
-	mu   sync.RWMutex
-	asks []Order // Sell Orders ordered by increasing price
-	bids []Order // Bids Orders ordered by decreasing price
+	Tag    string `json:"Tag"`
+	ID     string `json:"ID"`
+	mu     sync.RWMutex
+	asks   *bookSide             // Sell orders ordered by increasing price
+	bids   *bookSide             // Buy orders ordered by decreasing price
+	orders map[string]*orderNode // Resting orders indexed by ID
+
+	buyStops  *bookSide             // Buy stops ordered by increasing stop price
+	sellStops *bookSide             // Sell stops ordered by decreasing stop price
+	stops     map[string]*orderNode // Untriggered stop orders indexed by ID
+	lastPrice Decimal               // Price of the most recent trade
+	trades    uint64                // Number of trades executed
+	status    BookStatus            // Trading phase
+	until     time.Time             // When a breaker halt or auction ends, zero if not scheduled
+	arrivals  uint64                // Arrival sequence of orders entering the book
+
+	expiries expiryQueue      // Resting GTD orders by expiry time
+	now      func() time.Time // Clock used for expiry, replaceable in tests
+	at       time.Time        // Time of the command being applied
+
+	journal       Journal       // Records every accepted command, if set
+	preTradeCheck PreTradeCheck // Vets orders before the book takes them, if set
+	replaying     bool          // Set while commands are replayed from the journal
+	lsn           uint64        // Number of journal records applied to the book
+
+	spec     InstrumentSpec // What orders the book accepts
+	matching MatchingPolicy // How the orders of a level share an incoming order
+
+	fees      FeeSchedule             // Fees charged on trades
+	feeTotals map[string]*AccountFees // Fees paid by each account
+
+	balances map[string]*AccountBalances // Funds of each account, nil unless enforced
+
+	positions  map[string]Decimal             // Net amount each account has bought
+	openOrders map[string]map[string]struct{} // IDs of the resting and stop orders of each account
+
+	protection PriceProtection // Price bands and circuit breaker
+	window     priceWindow     // Trade prices within the circuit breaker window
+
+	auction    AuctionType // Kind of auction the book is in, if any
+	indicative Indicative  // Last indicative price published by the auction
+
+	stp SelfTradePrevention // Default self-trade prevention mode
+
+	eventSeq uint64                     // Sequence number of the last event
+	subs     map[*Subscription]struct{} // Event subscribers
+	ended    bool                       // Whether EndSubscriptions was called
+	history  []Event                    // Last events, by Seq modulo its length
+	touched  []levelKey                 // Levels changed by the current operation
+
+	subscriptionBuffer int // Events a subscriber may fall behind
+
+	checksumDepth int // Levels per side that checksums cover
+
+	touchedOrders   map[string]levelKey // Resting orders changed by the current operation, where they were
+	touchedOrderIDs []string            // The same orders, in the order they changed
 }
 
 // Trade represents a completed transaction between a buy and a sell order.
+// The maker is the resting order and the taker, whose side is the aggressor
+// side, the incoming one.
 type Trade struct {
-	BuyOrderID  string  `json:"buy_order_id"`
-	SellOrderID string  `json:"sell_order_id"`
-	Price       float64 `json:"price"`
-	Amount      float64 `json:"amount"`
+	ID            string    `json:"id"`     // Unique, derived from the book ID and Seq
+	Seq           uint64    `json:"seq"`    // Sequence number of the trade in its book, from 1
+	Symbol        string    `json:"symbol"` // Tag of the book
+	Timestamp     time.Time `json:"timestamp"`
+	BuyOrderID    string    `json:"buy_order_id"`
+	SellOrderID   string    `json:"sell_order_id"`
+	MakerOrderID  string    `json:"maker_order_id"`
+	TakerOrderID  string    `json:"taker_order_id"`
+	AggressorSide Side      `json:"aggressor_side"`
+	Price         Decimal   `json:"price"`
+	Amount        Decimal   `json:"amount"`
+
+	// Fees charged to each side in FeeCurrency, negative for a rebate
+	MakerFee    Decimal `json:"maker_fee"`
+	TakerFee    Decimal `json:"taker_fee"`
+	FeeCurrency string  `json:"fee_currency,omitempty"`
+}
+
+// OrderStatus describes the state of an order after it has been processed.
+type OrderStatus string
+
+const (
+	StatusNew             OrderStatus = "NEW"              // Resting with no fills
+	StatusPartiallyFilled OrderStatus = "PARTIALLY_FILLED" // Partially filled, remainder resting
+	StatusFilled          OrderStatus = "FILLED"           // Completely filled
+	StatusCancelled       OrderStatus = "CANCELLED"        // Remainder cancelled without resting
+	StatusExpired         OrderStatus = "EXPIRED"          // Removed after its GTD expiry time
+	StatusUntriggered     OrderStatus = "UNTRIGGERED"      // Stop order waiting for its stop price
+	StatusRejected        OrderStatus = "REJECTED"         // Triggered stop order that could not be accepted
+)
+
+// ProcessResult reports the outcome of ProcessOrder: the trades created and
+// how the incoming amount was split between filled, resting and cancelled.
+type ProcessResult struct {
+	OrderID         string      `json:"order_id"`
+	Status          OrderStatus `json:"status"`
+	TimeInForce     TimeInForce `json:"time_in_force"`
+	Price           Decimal     `json:"price,omitempty"` // Limit price, after any post-only slide
+	Trades          []*Trade    `json:"trades"`
+	FilledAmount    Decimal     `json:"filled_amount"`
+	RestingAmount   Decimal     `json:"resting_amount"`
+	CancelledAmount Decimal     `json:"cancelled_amount"`
+
+	// Self-trade prevention: the part of CancelledAmount removed because it
+	// would have matched the same account, and the resting orders cancelled
+	// or reduced for the same reason.
+	PreventedAmount Decimal           `json:"prevented_amount,omitempty"`
+	Prevented       []*PreventedMatch `json:"prevented,omitempty"`
+
+	// Stop orders activated by the trades of this order, in activation order
+	Triggered []*ProcessResult `json:"triggered,omitempty"`
+}
+
+// PreventedMatch reports a resting order reduced by self-trade prevention.
+type PreventedMatch struct {
+	RestingOrderID string  `json:"resting_order_id"`
+	Amount         Decimal `json:"amount"` // Amount removed from the resting order
 }
 
 // OrderBookLevel represents an aggregated price level in the orderbook.
 type OrderBookLevel struct {
-	Price       float64
-	TotalAmount float64
+	Price       Decimal
+	TotalAmount Decimal
 	OrderCount  int
 }
 
 // OrderBookSnapshot represents a snapshot of the orderbook at a specific time.
+// Seq is the last event it includes and Checksum that of its top levels, so
+// that level events after Seq can keep a copy of it up to date.
 type OrderBookSnapshot struct {
-	Asks []OrderBookLevel
-	Bids []OrderBookLevel
-	Time time.Time
+	Asks     []OrderBookLevel
+	Bids     []OrderBookLevel
+	Time     time.Time
+	Seq      uint64
+	Checksum uint32
 }
 
 // NewOrderBook creates and returns a new, empty orderbook.
-func NewOrderBook(tag string) *OrderBook {
-	return &OrderBook{
-		Tag:  tag,
-		ID:   uuid.New().String(),
-		asks: make([]Order, 0),
-		bids: make([]Order, 0),
+func NewOrderBook(tag string, opts ...Option) *OrderBook {
+	ob := &OrderBook{
+		Tag:        tag,
+		ID:         uuid.New().String(),
+		asks:       newBookSide(true),
+		bids:       newBookSide(false),
+		orders:     make(map[string]*orderNode),
+		buyStops:   newTriggerSide(true),
+		sellStops:  newTriggerSide(false),
+		stops:      make(map[string]*orderNode),
+		now:        time.Now,
+		spec:       DefaultInstrumentSpec(),
+		matching:   FIFO{},
+		feeTotals:  make(map[string]*AccountFees),
+		positions:  make(map[string]Decimal),
+		openOrders: make(map[string]map[string]struct{}),
+		status:     BookOpen,
+		stp:        CancelNewest,
+		subs:       make(map[*Subscription]struct{}),
+
+		touchedOrders:      make(map[string]levelKey),
+		checksumDepth:      DefaultChecksumDepth,
+		subscriptionBuffer: DefaultSubscriptionBuffer,
+	}
+	for _, opt := range opts {
+		opt(ob)
 	}
+	return ob
 }
 
-// CancelOrder removes an order from the orderbook.
+// CancelOrder removes an order from the orderbook, or an untriggered stop
+// order from the trigger book.
 // Returns ErrOrderNotFound if the order doesn't exist.
 func (ob *OrderBook) CancelOrder(orderID string) error {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
+	defer ob.publishLevels()
 
-	// Check bids
-	for i, order := range ob.bids {
-		if order.ID == orderID {
-			ob.bids = append(ob.bids[:i], ob.bids[i+1:]...)
-			return nil
-		}
+	ob.at = ob.now()
+	if err := ob.advance(); err != nil {
+		return err
 	}
+	return ob.cancelOrder(orderID)
+}
 
-	// Check asks
-	for i, order := range ob.asks {
-		if order.ID == orderID {
-			ob.asks = append(ob.asks[:i], ob.asks[i+1:]...)
-			return nil
-		}
+// Must be called with the lock held.
+func (ob *OrderBook) cancelOrder(orderID string) error {
+	if !ob.exists(orderID) {
+		return ErrOrderNotFound
+	}
+	if err := ob.record(Command{Type: CommandCancel, OrderID: orderID}); err != nil {
+		return err
+	}
+
+	if node, ok := ob.stops[orderID]; ok {
+		ob.removeStop(node)
+		ob.emitOrder(EventCancelled, node.order, 0, node.order.Amount)
+		return nil
 	}
 
-	return ErrOrderNotFound
+	node := ob.orders[orderID]
+	ob.remove(node)
+	ob.emitOrder(EventCancelled, node.order, 0, node.order.Amount)
+	return nil
 }
 
 // ModifyOrder modifies an existing order in the book.
-// If the price changes, the order is repositioned to maintain correct sorting.
+// If the price changes, the order is repositioned to maintain correct sorting
+// and loses its time priority. For an untriggered stop limit order the limit
+// price and amount are updated in place; use ModifyStopOrder to move the stop.
 // Returns ErrOrderNotFound if the order doesn't exist or ErrInvalidModification
 // if the new values are invalid.
-func (ob *OrderBook) ModifyOrder(orderID string, newPrice float64, newAmount float64) error {
+func (ob *OrderBook) ModifyOrder(orderID string, newPrice Decimal, newAmount Decimal) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = ob.now()
+	if err := ob.advance(); err != nil {
+		return err
+	}
+	return ob.modifyOrder(orderID, newPrice, newAmount)
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) modifyOrder(orderID string, newPrice Decimal, newAmount Decimal) error {
+	if err := ob.stopped(); err != nil {
+		return err
+	}
+
 	// Input validation
 	if newPrice <= 0 || newAmount <= 0 {
 		return ErrInvalidModification
 	}
+	if _, ok := newPrice.MulChecked(newAmount); !ok {
+		return ErrInvalidModification
+	}
+	if err := ob.spec.checkIncrements(newPrice, newAmount); err != nil {
+		return err
+	}
+	if err := ob.spec.checkSize(newPrice, newAmount); err != nil {
+		return err
+	}
 
-	ob.mu.Lock()
-	defer ob.mu.Unlock()
-
-	// Look for the order in bids first
-	for i, order := range ob.bids {
-		if order.ID == orderID {
-			// If only quantity changes, update in place
-			if newPrice == order.Price {
-				ob.bids[i].Amount = newAmount
-				return nil
-			}
-
-			// If price changes, remove and reinsert the order
-			order.Price = newPrice
-			order.Amount = newAmount
-			ob.bids = append(ob.bids[:i], ob.bids[i+1:]...)
-			ob.bids = insertSorted(ob.bids, order, false) // false for descending order
-			return nil
-		}
+	stop, waiting := ob.stops[orderID]
+	if waiting && stop.order.Type != StopLimit {
+		return ErrInvalidModification // Stop market orders have no price
+	}
+	if !ob.exists(orderID) {
+		return ErrOrderNotFound
+	}
+	node := stop
+	if !waiting {
+		node = ob.orders[orderID]
+	}
+	old := node.order
+	modified := old
+	modified.Price = newPrice
+	modified.Amount = newAmount
+	if err := ob.checkFunds(&modified, &old); err != nil {
+		return err
+	}
+	if err := ob.preTrade(&modified, &old); err != nil {
+		return err
+	}
+	if err := ob.record(Command{Type: CommandModify, OrderID: orderID, Price: newPrice, Amount: newAmount}); err != nil {
+		return err
 	}
 
-	// Look for the order in asks
-	for i, order := range ob.asks {
-		if order.ID == orderID {
-			// If only quantity changes, update in place
-			if newPrice == order.Price {
-				ob.asks[i].Amount = newAmount
-				return nil
-			}
+	if waiting {
+		stop.order.Price = newPrice
+		stop.resize(newAmount)
+		ob.rehold(&old, &stop.order)
+		ob.emitOrder(EventModified, stop.order, newAmount, 0)
+		return nil
+	}
 
-			// If price changes, remove and reinsert the order
-			order.Price = newPrice
-			order.Amount = newAmount
-			ob.asks = append(ob.asks[:i], ob.asks[i+1:]...)
-			ob.asks = insertSorted(ob.asks, order, true) // true for ascending order
-			return nil
-		}
+	// If only quantity changes, update in place
+	if newPrice == node.order.Price {
+		node.resize(newAmount)
+		ob.rehold(&old, &node.order)
+		ob.touch(node)
+		ob.emitOrder(EventModified, node.order, newAmount, 0)
+		return nil
 	}
 
-	return ErrOrderNotFound
+	// If price changes, remove and reinsert the order
+	ob.remove(node)
+	ob.insert(modified)
+	ob.emitOrder(EventModified, modified, newAmount, 0)
+	return nil
 }
 
 // PlaceOrder adds a new order to the orderbook.
 // Orders are sorted by price: descending for bids and ascending for asks.
+// Orders without an ID are assigned a new one. Market and stop orders cannot
+// be placed directly and are rejected with ErrInvalidOrderType, and only GTC
+// and GTD orders are accepted since placing never matches.
 func (ob *OrderBook) PlaceOrder(order Order) error {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
+	defer ob.publishLevels()
 
-	if order.Price <= 0 || order.Amount <= 0 {
-		return ErrInvalidOrder
+	ob.at = ob.now()
+	if err := ob.advance(); err != nil {
+		return err
 	}
+	return ob.placeOrder(order)
+}
 
-	switch order.Side {
-	case Buy:
-		ob.bids = insertSorted(ob.bids, order, false) // decreasing price
-	case Sell:
-		ob.asks = insertSorted(ob.asks, order, true)
+// Must be called with the lock held.
+func (ob *OrderBook) placeOrder(order Order) error {
+	err := ob.validate(&order)
+	if err == nil {
+		err = ob.checkStatus(&order)
+	}
+	if err == nil && (order.IsMarket() || order.IsStop()) {
+		err = ErrInvalidOrderType
 	}
+	if err == nil && order.TimeInForce != GTC && order.TimeInForce != GTD {
+		err = ErrInvalidTimeInForce
+	}
+	if err == nil {
+		err = ob.checkFunds(&order, nil)
+	}
+	if err != nil {
+		ob.emitRejected(order, err)
+		return err
+	}
+	if err := ob.preTrade(&order, nil); err != nil {
+		return err
+	}
+	if err := ob.record(Command{Type: CommandPlace, Order: &order}); err != nil {
+		return err
+	}
+
+	ob.insert(order)
+	ob.emitOrder(EventAccepted, order, order.Amount, 0)
 	return nil
 }
 
 // ProcessOrder matches an incoming order against existing orders in the book.
 // It creates trades for fully or partially matched orders. Any unmatched portion
-// of the incoming order is added to the orderbook.
-func (ob *OrderBook) ProcessOrder(order Order) ([]*Trade, error) {
+// of a GTC or GTD limit order is added to the orderbook, while the unmatched
+// portion of a market or IOC order is cancelled. A FOK order that cannot be
+// filled completely is cancelled before any trade is created.
+// Stop orders wait in the trigger book until the last trade price reaches
+// their stop price. Stops activated by this order's trades are processed
+// right after it and reported in the result.
+func (ob *OrderBook) ProcessOrder(order Order) (*ProcessResult, error) {
 	ob.mu.Lock()
 	defer ob.mu.Unlock()
+	defer ob.publishLevels()
 
-	var err error
-	var trades []*Trade
+	ob.at = ob.now()
+	if err := ob.advance(); err != nil {
+		return nil, err
+	}
+	return ob.processOrder(order)
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) processOrder(order Order) (*ProcessResult, error) {
+	err := ob.validate(&order)
+	if err == nil {
+		err = ob.checkStatus(&order)
+	}
+	if err == nil {
+		err = ob.checkFunds(&order, nil)
+	}
+	if err != nil {
+		ob.emitRejected(order, err)
+		return nil, err
+	}
+	if err := ob.preTrade(&order, nil); err != nil {
+		return nil, err
+	}
+	if err := ob.record(Command{Type: CommandProcess, Order: &order}); err != nil {
+		return nil, err
+	}
+
+	// Stops only trigger while the book trades, so during an auction they
+	// wait in the trigger book for it to uncross
+	if order.IsStop() {
+		if ob.status != BookOpen || !ob.stopTriggered(&order) {
+			ob.insertStop(order)
+			ob.emitOrder(EventAccepted, order, order.Amount, 0)
+			return &ProcessResult{
+				OrderID:     order.ID,
+				Status:      StatusUntriggered,
+				TimeInForce: order.TimeInForce,
+				Price:       order.Price,
+			}, nil
+		}
+		order = activate(order)
+	}
+
+	// Auctions collect orders without matching them
+	if ob.status == BookAuction {
+		ob.insert(order)
+		ob.emitOrder(EventAccepted, order, order.Amount, 0)
+		result := &ProcessResult{
+			OrderID:       order.ID,
+			TimeInForce:   order.TimeInForce,
+			Price:         order.Price,
+			RestingAmount: order.Amount,
+		}
+		result.Status = orderStatus(result)
+		return result, nil
+	}
+
+	result, err := ob.process(order)
+	if err != nil {
+		ob.emitRejected(order, err)
+		return nil, err
+	}
+	result.Triggered = ob.triggerStops()
+	return result, nil
+}
+
+// process runs the matching loop for a validated order, emitting its
+// acceptance, trades and fills.
+// Must be called with the lock held.
+func (ob *OrderBook) process(order Order) (*ProcessResult, error) {
+	result := &ProcessResult{OrderID: order.ID, TimeInForce: order.TimeInForce}
 	remainingAmount := order.Amount
+	now := ob.at
 
 	// Determine which side of the book to match against
-	var matchingSide *[]Order
-	switch order.Side {
-	case Buy:
-		matchingSide = &ob.asks // Match against asks (sell orders)
-	case Sell:
-		matchingSide = &ob.bids // Match against bids (buy orders)
-	default:
-		return nil, ErrInvalidOrder // Invalid order side, return empty trades
+	matchingSide := ob.asks // Match against asks (sell orders)
+	if order.Side == Sell {
+		matchingSide = ob.bids // Match against bids (buy orders)
+	}
+
+	// Post-only orders must rest without trading
+	if order.PostOnly {
+		if err := ob.applyPostOnly(&order, matchingSide, now); err != nil {
+			return nil, err
+		}
+	}
+	ob.emitOrder(EventAccepted, order, order.Amount, 0)
+
+	limit, bounded := ob.priceLimit(&order, matchingSide)
+	band, banded, breaker, breakable := ob.priceBounds(order.Side, matchingSide)
+	var notional Decimal
+	var cut bool // Set when protection stops the order short of its price
+
+	// Fill or kill is all-or-nothing, so check the liquidity up front
+	reach, reachable := limit, bounded
+	for _, bound := range []struct {
+		price Decimal
+		ok    bool
+	}{{band, banded}, {breaker, breakable}} {
+		if bound.ok && (!reachable || isPriceMatching(order.Side, reach, bound.price)) {
+			reach, reachable = bound.price, true
+		}
 	}
+	if order.TimeInForce == FOK && ob.fillable(&order, matchingSide, reach, reachable, now) < order.Amount {
+		result.CancelledAmount = order.Amount
+		result.Status = orderStatus(result)
+		ob.emitOrder(EventCancelled, order, 0, order.Amount)
+		return result, nil
+	}
+
+	var headNode [1]*orderNode // What FIFO trades against, without allocating
+	var headAllocation [1]Decimal
 
-	// Iterate through the matching side to find matches
-	for len(*matchingSide) > 0 && remainingAmount > 0 {
-		bestOrder := &(*matchingSide)[0] // Get the best order (first in the list)
+	// Walk the price levels from the best price until no more matches
+	for remainingAmount > 0 {
+		level := matchingSide.best()
+		if level == nil {
+			break
+		}
 
 		// Check if the prices match
-		if !isPriceMatching(&order, bestOrder) {
+		if bounded && !isPriceMatching(order.Side, limit, level.price) {
 			break // No more matches possible
 		}
 
-		// Calculate the amount to execute
-		executedAmount := math.Min(remainingAmount, bestOrder.Amount)
+		// Never trade outside the price band, and halt rather than let
+		// the price run past the circuit breaker
+		if banded && !isPriceMatching(order.Side, band, level.price) {
+			cut = true
+			break
+		}
+		if breakable && !isPriceMatching(order.Side, breaker, level.price) {
+			ob.tripBreaker()
+			cut = true
+			break
+		}
 
-		// Create a trade
-		trade := createTrade(&order, bestOrder, executedAmount)
-		trades = append(trades, trade)
+		// Orders of the same account never trade with each other, and
+		// resting orders that expired since the last sweep are dropped
+		head := level.head
+		if head.order.expired(now) {
+			ob.expire(head)
+			continue
+		}
+		if selfTrade(&order, &head.order) {
+			remainingAmount -= ob.preventSelfTrade(&order, head, remainingAmount, result)
+			continue
+		}
 
-		// Update remaining amounts
-		remainingAmount -= executedAmount
-		bestOrder.Amount -= executedAmount
+		amount := remainingAmount
+		if order.MaxNotional > 0 {
+			// A cap too large to divide by the price caps nothing
+			if affordable, ok := (order.MaxNotional - notional).DivChecked(level.price); ok {
+				amount = amount.Min(ob.spec.roundLot(affordable))
+				if amount <= 0 {
+					break // Notional cap reached
+				}
+			}
+		}
 
-		// Remove the best order if it's fully executed
-		if bestOrder.Amount == 0 {
-			*matchingSide = (*matchingSide)[1:] // Remove the first order
+		// Share the amount among the orders ahead of the next order of the
+		// same account as the matching policy says, or walk them in time
+		// priority for FIFO
+		var nodes []*orderNode
+		var allocations []Decimal
+		if _, fifo := ob.matching.(FIFO); fifo {
+			headNode[0], headAllocation[0] = head, amount.Min(head.visible)
+			nodes, allocations = headNode[:], headAllocation[:]
+		} else {
+			nodes, allocations = ob.allocate(&order, level, amount, now)
+		}
+
+		for i, node := range nodes {
+			executedAmount := allocations[i]
+			if executedAmount == 0 {
+				continue
+			}
+
+			// Create a trade
+			trade := ob.createTrade(&order, &node.order, level.price, executedAmount)
+			result.Trades = append(result.Trades, trade)
+			if order.MaxNotional > 0 {
+				notional += trade.Price.Mul(executedAmount) // At most the cap
+			}
+			ob.lastPrice = trade.Price
+			if breakable {
+				ob.window.add(now, trade.Price)
+			}
+
+			// Update remaining amounts and balances
+			remainingAmount -= executedAmount
+			ob.settle(trade, &order, &node.order)
+			ob.fill(node, executedAmount)
+
+			ob.emitFill(node.order, node.order.Amount, executedAmount)
+			ob.emitFill(order, remainingAmount, executedAmount)
 		}
 	}
 
-	// If there's any remaining amount, add it to the order book
+	result.FilledAmount = order.Amount - remainingAmount - result.PreventedAmount
+	result.CancelledAmount = result.PreventedAmount
+	result.Price = order.Price
+
+	// If there's any remaining amount, rest it or cancel it
 	if remainingAmount > 0 {
-		newOrder := Order{
-			ID:     order.ID,
-			Price:  order.Price,
-			Amount: remainingAmount,
-			Side:   order.Side,
+		if !order.Rests() || cut {
+			result.CancelledAmount += remainingAmount
+		} else {
+			order.Amount = remainingAmount
+			result.RestingAmount = remainingAmount
+			ob.insert(order)
 		}
+	}
+	if result.CancelledAmount > 0 {
+		ob.emitOrder(EventCancelled, order, result.RestingAmount, result.CancelledAmount)
+	}
 
-		ob.mu.Unlock()
-		err = ob.PlaceOrder(newOrder)
-		ob.mu.Lock()
+	result.Status = orderStatus(result)
+	return result, nil
+}
+
+// allocate collects the orders of a level up to the next order of the same
+// account as the incoming one, dropping those that expired, and asks the
+// matching policy how they share up to amount. Whatever the policy leaves
+// unallocated goes to them in time priority.
+// Must be called with the lock held.
+func (ob *OrderBook) allocate(order *Order, level *priceLevel, amount Decimal, now time.Time) ([]*orderNode, []Decimal) {
+	var nodes []*orderNode
+	var resting []RestingOrder
+	var total Decimal
+	for n := level.head; n != nil; {
+		next := n.next
+		switch {
+		case n.order.expired(now):
+			ob.expire(n)
+		case selfTrade(order, &n.order):
+			next = nil
+		default:
+			nodes = append(nodes, n)
+			resting = append(resting, RestingOrder{ID: n.order.ID, Account: n.order.Account, Amount: n.visible})
+			total += n.visible
+		}
+		n = next
 	}
+	amount = amount.Min(total)
 
-	return trades, err
+	allocations := ob.matching.Allocate(amount, resting, ob.spec.lot())
+	if len(allocations) != len(resting) {
+		allocations = make([]Decimal, len(resting))
+	}
+	var allocated Decimal
+	for i := range allocations {
+		allocations[i] = max(0, allocations[i].Min(resting[i].Amount).Min(amount-allocated))
+		allocated += allocations[i]
+	}
+	fillInOrder(amount-allocated, resting, allocations, indexes(len(resting)))
+	return nodes, allocations
+}
+
+// applyPostOnly rejects a post-only order that would cross the best opposite
+// price, or slides it one tick behind that price when PostOnlySlide is set.
+func (ob *OrderBook) applyPostOnly(order *Order, matchingSide *bookSide, now time.Time) error {
+	best := ob.bestLevel(matchingSide, now)
+	if best == nil || !isPriceMatching(order.Side, order.Price, best.price) {
+		return nil
+	}
+	if !order.PostOnlySlide {
+		return ErrPostOnlyWouldCross
+	}
+
+	tick := ob.spec.tick()
+	if order.Side == Buy {
+		order.Price = best.price - tick
+	} else {
+		order.Price = best.price + tick
+	}
+	if order.Price <= 0 {
+		return ErrPostOnlyWouldCross
+	}
+	return nil
+}
+
+// bestLevel returns the best level of a side after dropping any expired
+// orders from its top.
+func (ob *OrderBook) bestLevel(side *bookSide, now time.Time) *priceLevel {
+	for level := side.best(); level != nil; level = side.best() {
+		if !level.head.order.expired(now) {
+			return level
+		}
+		ob.expire(level.head)
+	}
+	return nil
+}
+
+// fill reduces a resting order by an executed amount. A fully executed order
+// is removed, and an iceberg whose displayed part is used up shows its next
+// peak from the back of the queue.
+// Must be called with the lock held.
+func (ob *OrderBook) fill(node *orderNode, amount Decimal) {
+	ob.touch(node)
+	node.order.Amount -= amount
+	node.visible -= amount
+	node.level.total -= amount
+
+	switch {
+	case node.order.Amount == 0:
+		ob.remove(node)
+	case node.visible == 0:
+		level := node.level
+		level.remove(node)
+		node.visible = node.order.DisplayAmount()
+		node.seq = ob.nextArrival()
+		level.pushBack(node)
+	}
+}
+
+// fillable returns how much of an order could execute against the book right
+// now, up to the order's amount, without modifying anything.
+func (ob *OrderBook) fillable(order *Order, matchingSide *bookSide, limit Decimal, bounded bool, now time.Time) Decimal {
+	var total, notional Decimal
+	matchingSide.each(func(level *priceLevel) bool {
+		if bounded && !isPriceMatching(order.Side, limit, level.price) {
+			return false
+		}
+		for n := level.head; n != nil && total < order.Amount; n = n.next {
+			if n.order.expired(now) {
+				continue
+			}
+			if selfTrade(order, &n.order) {
+				if ob.stpMode(order) == CancelOldest {
+					continue // The resting order goes away, matching carries on
+				}
+				return false // The incoming order is cut short here
+			}
+			amount := (order.Amount - total).Min(n.order.Amount)
+			if order.MaxNotional > 0 {
+				if affordable, ok := (order.MaxNotional - notional).DivChecked(level.price); ok {
+					amount = amount.Min(ob.spec.roundLot(affordable))
+					if amount <= 0 {
+						return false
+					}
+					notional += level.price.Mul(amount)
+				}
+			}
+			total += amount
+		}
+		return total < order.Amount
+	})
+	return total
+}
+
+// priceLimit returns the worst price an order may trade at, and false when
+// the order may trade at any price.
+func (ob *OrderBook) priceLimit(order *Order, matchingSide *bookSide) (Decimal, bool) {
+	if !order.IsMarket() {
+		return order.Price, true
+	}
+	if order.MaxSlippageBps <= 0 {
+		return 0, false
+	}
+
+	best := matchingSide.best()
+	if best == nil {
+		return 0, false
+	}
+	return moveFrom(best.price, order.Side, order.MaxSlippageBps)
 }
 
 // GetBestBid returns the highest bid order.
@@ -225,11 +755,7 @@ func (ob *OrderBook) GetBestBid() (Order, error) {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
-	if len(ob.bids) == 0 {
-		return Order{}, ErrNoOrders
-	}
-
-	return ob.bids[0], nil
+	return ob.bestOrder(ob.bids)
 }
 
 // GetBestAsk returns the lowest ask order in the orderbook.
@@ -238,11 +764,32 @@ func (ob *OrderBook) GetBestAsk() (Order, error) {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
-	if len(ob.asks) == 0 {
+	return ob.bestOrder(ob.asks)
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) bestOrder(side *bookSide) (Order, error) {
+	level := side.best()
+	if level == nil {
 		return Order{}, ErrNoOrders
 	}
 
-	return ob.asks[0], nil
+	return level.head.public(), nil
+}
+
+// GetOrder returns a resting or untriggered stop order by ID, with the full
+// amount left of iceberg orders. Returns ErrOrderNotFound if the book holds no such order.
+func (ob *OrderBook) GetOrder(orderID string) (Order, error) {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	if node, ok := ob.orders[orderID]; ok {
+		return node.order, nil
+	}
+	if node, ok := ob.stops[orderID]; ok {
+		return node.order, nil
+	}
+	return Order{}, ErrOrderNotFound
 }
 
 // GetOrderBookSnapshot returns the current state of the orderbook
@@ -251,72 +798,203 @@ func (ob *OrderBook) GetOrderBookSnapshot() OrderBookSnapshot {
 	ob.mu.RLock()
 	defer ob.mu.RUnlock()
 
-	snapshot := OrderBookSnapshot{
-		Time: time.Now(),
+	return OrderBookSnapshot{
+		Asks:     aggregateLevels(ob.asks),
+		Bids:     aggregateLevels(ob.bids),
+		Time:     time.Now(),
+		Seq:      ob.eventSeq,
+		Checksum: ob.checksum(),
 	}
+}
 
-	// Aggregate asks by price level
-	askLevels := make(map[float64]*OrderBookLevel)
-	for _, order := range ob.asks {
-		level, exists := askLevels[order.Price]
-		if !exists {
-			level = &OrderBookLevel{Price: order.Price}
-			askLevels[order.Price] = level
-		}
-		level.TotalAmount += order.Amount
-		level.OrderCount++
+// validate checks an incoming order and assigns it an ID if it has none.
+// Must be called with the lock held.
+func (ob *OrderBook) validate(order *Order) error {
+	switch order.Type {
+	case "":
+		order.Type = Limit
+	case Limit, Market, Stop, StopLimit:
+	default:
+		return ErrInvalidOrderType
 	}
 
-	// Convert ask levels to sorted slice
-	for _, level := range askLevels {
-		snapshot.Asks = append(snapshot.Asks, *level)
+	if order.Amount <= 0 || order.Price < 0 || (order.Price == 0 && !order.atMarket()) {
+		return ErrInvalidOrder
+	}
+	if order.Side != Buy && order.Side != Sell {
+		return ErrInvalidOrder
+	}
+	if order.IsStop() != (order.StopPrice > 0) || order.StopPrice < 0 {
+		return ErrInvalidOrder // Stop orders, and only those, need a stop price
+	}
+	if order.MaxSlippageBps < 0 || order.MaxSlippageBps > maxSlippageBps || order.MaxNotional < 0 {
+		return ErrInvalidOrder
+	}
+	if !order.atMarket() && (order.MaxSlippageBps != 0 || order.MaxNotional != 0) {
+		return ErrInvalidOrder // Protections only apply to market orders
+	}
+	if order.PostOnlySlide && !order.PostOnly {
+		return ErrInvalidOrder
+	}
+	if order.PeakAmount < 0 || (order.PeakAmount > 0 && order.atMarket()) {
+		return ErrInvalidOrder // Only orders that can rest may hide size
+	}
+	switch order.SelfTradePrevention {
+	case "", CancelNewest, CancelOldest, CancelBoth, DecrementAndCancel:
+	default:
+		return ErrInvalidOrder
 	}
-	// Sort asks by increasing price
-	sort.Slice(snapshot.Asks, func(i, j int) bool {
-		return snapshot.Asks[i].Price < snapshot.Asks[j].Price
-	})
 
-	// Aggregate bids by price level
-	bidLevels := make(map[float64]*OrderBookLevel)
-	for _, order := range ob.bids {
-		level, exists := bidLevels[order.Price]
-		if !exists {
-			level = &OrderBookLevel{Price: order.Price}
-			bidLevels[order.Price] = level
+	switch order.TimeInForce {
+	case "":
+		order.TimeInForce = GTC
+		if order.atMarket() {
+			order.TimeInForce = IOC // Market orders never rest
+		}
+	case GTC, IOC, FOK:
+		if order.atMarket() && order.TimeInForce == GTC {
+			return ErrInvalidTimeInForce
+		}
+	case GTD:
+		if order.atMarket() || order.ExpireAt == nil {
+			return ErrInvalidTimeInForce
+		}
+		if !order.ExpireAt.After(ob.at) {
+			return ErrInvalidExpiry
 		}
-		level.TotalAmount += order.Amount
-		level.OrderCount++
+	default:
+		return ErrInvalidTimeInForce
+	}
+	if order.TimeInForce != GTD {
+		order.ExpireAt = nil
+	}
+	if order.PostOnly && (order.atMarket() || order.TimeInForce == IOC || order.TimeInForce == FOK) {
+		return ErrInvalidTimeInForce // Post-only orders must be able to rest
+	}
+	if _, ok := order.Price.MulChecked(order.Amount); !ok {
+		return ErrInvalidOrder
+	}
+	if err := ob.spec.checkIncrements(order.Price, order.Amount); err != nil {
+		return err
+	}
+	if err := ob.spec.checkIncrements(order.StopPrice, order.PeakAmount); err != nil {
+		return err
+	}
+	if err := ob.spec.checkSize(order.Price, order.Amount); err != nil {
+		return err
 	}
 
-	// Convert bid levels to sorted slice
-	for _, level := range bidLevels {
-		snapshot.Bids = append(snapshot.Bids, *level)
+	if order.ID == "" {
+		order.ID = uuid.New().String()
 	}
-	// Sort bids by decreasing price
-	sort.Slice(snapshot.Bids, func(i, j int) bool {
-		return snapshot.Bids[i].Price > snapshot.Bids[j].Price
-	})
+	if ob.exists(order.ID) {
+		return ErrDuplicateOrder
+	}
+	return nil
+}
 
-	return snapshot
+// insert rests an order at the back of its price level queue, reserving
+// its funds.
+// Must be called with the lock held.
+func (ob *OrderBook) insert(order Order) {
+	ob.hold(&order)
+	ob.trackOpen(&order, true)
+	node := newOrderNode(order, ob.nextArrival())
+	ob.side(order.Side).add(node)
+	ob.orders[order.ID] = node
+	ob.scheduleExpiry(&node.order)
+	ob.touch(node)
 }
 
-// Helper function to check if the price of two orders match.
-func isPriceMatching(order *Order, matchOrder *Order) bool {
-	switch order.Side {
+// remove takes a resting order out of the book, freeing what is left of
+// its funds.
+// Must be called with the lock held.
+func (ob *OrderBook) remove(node *orderNode) {
+	ob.release(&node.order)
+	ob.trackOpen(&node.order, false)
+	ob.side(node.order.Side).unlink(node)
+	delete(ob.orders, node.order.ID)
+	ob.touch(node)
+}
+
+// exists reports whether an order ID is resting or waiting in the trigger book.
+func (ob *OrderBook) exists(orderID string) bool {
+	_, resting := ob.orders[orderID]
+	_, waiting := ob.stops[orderID]
+	return resting || waiting
+}
+
+// nextArrival returns the next arrival sequence number.
+func (ob *OrderBook) nextArrival() uint64 {
+	ob.arrivals++
+	return ob.arrivals
+}
+
+// side returns the half of the book where orders of the given side rest.
+func (ob *OrderBook) side(side Side) *bookSide {
+	if side == Sell {
+		return ob.asks
+	}
+	return ob.bids
+}
+
+// Helper function to aggregate the levels of one side from best to worst price.
+func aggregateLevels(side *bookSide) []OrderBookLevel {
+	var levels []OrderBookLevel
+	side.each(func(level *priceLevel) bool {
+		levels = append(levels, OrderBookLevel{
+			Price:       level.price,
+			TotalAmount: level.total,
+			OrderCount:  level.count,
+		})
+		return true
+	})
+	return levels
+}
+
+// Helper function to check if a resting price is within an order's limit.
+func isPriceMatching(side Side, limit Decimal, price Decimal) bool {
+	switch side {
 	case Buy:
-		return matchOrder.Price <= order.Price
+		return price <= limit
 	case Sell:
-		return matchOrder.Price >= order.Price
+		return price >= limit
 	}
 	return false
 }
 
-// Helper function to create a trade from two orders and the executed amount.
-func createTrade(order *Order, matchOrder *Order, executedAmount float64) *Trade {
+// Helper function to derive the status of a processed order.
+func orderStatus(result *ProcessResult) OrderStatus {
+	switch {
+	case result.CancelledAmount > 0:
+		return StatusCancelled
+	case result.RestingAmount == 0:
+		return StatusFilled
+	case result.FilledAmount > 0:
+		return StatusPartiallyFilled
+	default:
+		return StatusNew
+	}
+}
+
+// Helper function to create a trade from two orders, the price and the
+// executed amount, emitting its TRADE event.
+// Must be called with the lock held.
+func (ob *OrderBook) createTrade(order *Order, matchOrder *Order, price Decimal, executedAmount Decimal) *Trade {
+	ob.trades++
 	trade := &Trade{
-		Price:  matchOrder.Price,
-		Amount: executedAmount,
+		ID:            tradeID(ob.ID, ob.trades),
+		Seq:           ob.trades,
+		Symbol:        ob.Tag,
+		Timestamp:     ob.at,
+		MakerOrderID:  matchOrder.ID,
+		TakerOrderID:  order.ID,
+		AggressorSide: order.Side,
+		Price:         price,
+		Amount:        executedAmount,
 	}
+	ob.chargeFees(trade, order, matchOrder)
+	ob.move(trade, order, matchOrder)
 	switch order.Side {
 	case Buy:
 		trade.BuyOrderID = order.ID
@@ -325,19 +1003,15 @@ func createTrade(order *Order, matchOrder *Order, executedAmount float64) *Trade
 		trade.BuyOrderID = matchOrder.ID
 		trade.SellOrderID = order.ID
 	}
+	ob.emit(Event{Type: EventTrade, Trade: trade})
 	return trade
 }
 
-// Helper function to insert an order into a sorted slice.
-func insertSorted(orders []Order, order Order, ascending bool) []Order {
-	i := sort.Search(len(orders), func(i int) bool {
-		if ascending {
-			return orders[i].Price > order.Price
-		}
-		return orders[i].Price < order.Price
-	})
-	orders = append(orders, Order{}) // Add a dummy element to extend the slice
-	copy(orders[i+1:], orders[i:])   // Shift elements to the right
-	orders[i] = order                // Insert the new order
-	return orders
+// tradeNamespace scopes the name-based UUIDs of trades.
+var tradeNamespace = uuid.MustParse("6f1c3c1e-4f0e-4c47-9d3a-3c1f2b8e7a10")
+
+// tradeID returns the ID of the trade with sequence number seq in a book. It
+// is derived rather than random so that replaying the journal reproduces it.
+func tradeID(bookID string, seq uint64) string {
+	return uuid.NewSHA1(tradeNamespace, []byte(bookID+"/"+strconv.FormatUint(seq, 10))).String()
 }
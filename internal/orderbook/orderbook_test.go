@@ -1,6 +1,9 @@
 package orderbook
 
 import (
+	"bytes"
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -8,18 +11,18 @@ import (
 func TestNewOrder(t *testing.T) {
 	tests := []struct {
 		name      string
-		price     float64
-		amount    float64
+		price     Decimal
+		amount    Decimal
 		side      Side
 		expectErr bool
 	}{
-		{"Valid Buy Order", 100.0, 10.0, Buy, false},
-		{"Negative Price", -100.0, 10.0, Buy, true},
-		{"Zero Quantity", 100.0, 0.0, Buy, true},
-		{"Negative Quantity", 100.0, -10.0, Buy, true},
+		{"Valid Buy Order", dec(100.0), dec(10.0), Buy, false},
+		{"Negative Price", dec(-100.0), dec(10.0), Buy, true},
+		{"Zero Quantity", dec(100.0), dec(0.0), Buy, true},
+		{"Negative Quantity", dec(100.0), dec(-10.0), Buy, true},
 	}
 
-	assertOrderFields := func(t *testing.T, got *Order, price float64, amount float64, side Side) {
+	assertOrderFields := func(t *testing.T, got *Order, price Decimal, amount Decimal, side Side) {
 		if got.Price != price {
 			t.Errorf("Expected price %v, got %v", price, got.Price)
 		}
@@ -63,47 +66,47 @@ func TestCancelOrder(t *testing.T) {
 		{
 			name: "Cancel bid order successfully",
 			ordersToAdd: []Order{
-				{ID: "bid-1", Price: 100.0, Amount: 1.0, Side: Buy},
-				{ID: "bid-2", Price: 101.0, Amount: 2.0, Side: Buy},
+				{ID: "bid-1", Price: dec(100.0), Amount: dec(1.0), Side: Buy},
+				{ID: "bid-2", Price: dec(101.0), Amount: dec(2.0), Side: Buy},
 			},
 			orderIDToCancel: "bid-1",
 			expectedError:   false,
 			checkBookFunc: func(t *testing.T, ob *OrderBook) {
-				if len(ob.bids) != 1 {
-					t.Errorf("Expected 1 bid order, got %d", len(ob.bids))
+				if len(ob.bids.orders()) != 1 {
+					t.Errorf("Expected 1 bid order, got %d", len(ob.bids.orders()))
 				}
-				if ob.bids[0].ID != "bid-2" {
-					t.Errorf("Expected remaining order bid-2, got %s", ob.bids[0].ID)
+				if ob.bids.orders()[0].ID != "bid-2" {
+					t.Errorf("Expected remaining order bid-2, got %s", ob.bids.orders()[0].ID)
 				}
 			},
 		},
 		{
 			name: "Cancel ask order successfully",
 			ordersToAdd: []Order{
-				{ID: "ask-1", Price: 100.0, Amount: 1.0, Side: Sell},
-				{ID: "ask-2", Price: 101.0, Amount: 2.0, Side: Sell},
+				{ID: "ask-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{ID: "ask-2", Price: dec(101.0), Amount: dec(2.0), Side: Sell},
 			},
 			orderIDToCancel: "ask-1",
 			expectedError:   false,
 			checkBookFunc: func(t *testing.T, ob *OrderBook) {
-				if len(ob.asks) != 1 {
-					t.Errorf("Expected 1 ask order, got %d", len(ob.asks))
+				if len(ob.asks.orders()) != 1 {
+					t.Errorf("Expected 1 ask order, got %d", len(ob.asks.orders()))
 				}
-				if ob.asks[0].ID != "ask-2" {
-					t.Errorf("Expected remaining order ask-2, got %s", ob.asks[0].ID)
+				if ob.asks.orders()[0].ID != "ask-2" {
+					t.Errorf("Expected remaining order ask-2, got %s", ob.asks.orders()[0].ID)
 				}
 			},
 		},
 		{
 			name: "Cancel last order in book",
 			ordersToAdd: []Order{
-				{ID: "ask-1", Price: 100.0, Amount: 1.0, Side: Sell},
+				{ID: "ask-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
 			},
 			orderIDToCancel: "ask-1",
 			expectedError:   false,
 			checkBookFunc: func(t *testing.T, ob *OrderBook) {
-				if len(ob.asks) != 0 {
-					t.Errorf("Expected empty asks, got %d orders", len(ob.asks))
+				if len(ob.asks.orders()) != 0 {
+					t.Errorf("Expected empty asks, got %d orders", len(ob.asks.orders()))
 				}
 			},
 		},
@@ -142,8 +145,8 @@ func TestModifyOrder(t *testing.T) {
 		name          string
 		ordersToAdd   []Order
 		orderToModify string
-		newPrice      float64
-		newAmount     float64
+		newPrice      Decimal
+		newAmount     Decimal
 		expectedError bool
 		checkBookFunc func(*testing.T, *OrderBook)
 	}{
@@ -151,82 +154,82 @@ func TestModifyOrder(t *testing.T) {
 			name:          "Modify non-existent order",
 			ordersToAdd:   []Order{},
 			orderToModify: "non-existent",
-			newPrice:      100.0,
-			newAmount:     1.0,
+			newPrice:      dec(100.0),
+			newAmount:     dec(1.0),
 			expectedError: true,
 			checkBookFunc: nil,
 		},
 		{
 			name: "Modify bid order price",
 			ordersToAdd: []Order{
-				{ID: "bid-1", Price: 100.0, Amount: 1.0, Side: Buy},
-				{ID: "bid-2", Price: 101.0, Amount: 2.0, Side: Buy},
+				{ID: "bid-1", Price: dec(100.0), Amount: dec(1.0), Side: Buy},
+				{ID: "bid-2", Price: dec(101.0), Amount: dec(2.0), Side: Buy},
 			},
 			orderToModify: "bid-1",
-			newPrice:      102.0,
-			newAmount:     1.0,
+			newPrice:      dec(102.0),
+			newAmount:     dec(1.0),
 			expectedError: false,
 			checkBookFunc: func(t *testing.T, ob *OrderBook) {
-				if len(ob.bids) != 2 {
-					t.Errorf("Expected 2 bid orders, got %d", len(ob.bids))
+				if len(ob.bids.orders()) != 2 {
+					t.Errorf("Expected 2 bid orders, got %d", len(ob.bids.orders()))
 				}
 				// Should be first due to higher price
-				if ob.bids[0].ID != "bid-1" || ob.bids[0].Price != 102.0 {
-					t.Errorf("Expected modified order at top with price 102.0, got order %s with price %f",
-						ob.bids[0].ID, ob.bids[0].Price)
+				if ob.bids.orders()[0].ID != "bid-1" || ob.bids.orders()[0].Price != dec(102.0) {
+					t.Errorf("Expected modified order at top with price dec(102.0), got order %s with price %v",
+						ob.bids.orders()[0].ID, ob.bids.orders()[0].Price)
 				}
 			},
 		},
 		{
 			name: "Modify ask order amount",
 			ordersToAdd: []Order{
-				{ID: "ask-1", Price: 100.0, Amount: 1.0, Side: Sell},
-				{ID: "ask-2", Price: 101.0, Amount: 2.0, Side: Sell},
+				{ID: "ask-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{ID: "ask-2", Price: dec(101.0), Amount: dec(2.0), Side: Sell},
 			},
 			orderToModify: "ask-1",
-			newPrice:      100.0,
-			newAmount:     3.0,
+			newPrice:      dec(100.0),
+			newAmount:     dec(3.0),
 			expectedError: false,
 			checkBookFunc: func(t *testing.T, ob *OrderBook) {
-				if len(ob.asks) != 2 {
-					t.Errorf("Expected 2 ask orders, got %d", len(ob.asks))
+				if len(ob.asks.orders()) != 2 {
+					t.Errorf("Expected 2 ask orders, got %d", len(ob.asks.orders()))
 				}
 				// Should maintain position due to same price
-				if ob.asks[0].ID != "ask-1" || ob.asks[0].Amount != 3.0 {
-					t.Errorf("Expected modified order with amount 3.0, got amount %f",
-						ob.asks[0].Amount)
+				if ob.asks.orders()[0].ID != "ask-1" || ob.asks.orders()[0].Amount != dec(3.0) {
+					t.Errorf("Expected modified order with amount dec(3.0), got amount %v",
+						ob.asks.orders()[0].Amount)
 				}
 			},
 		},
 		{
 			name: "Invalid modification - zero price",
 			ordersToAdd: []Order{
-				{ID: "bid-1", Price: 100.0, Amount: 1.0, Side: Buy},
+				{ID: "bid-1", Price: dec(100.0), Amount: dec(1.0), Side: Buy},
 			},
 			orderToModify: "bid-1",
-			newPrice:      0.0,
-			newAmount:     1.0,
+			newPrice:      dec(0.0),
+			newAmount:     dec(1.0),
 			expectedError: true,
 			checkBookFunc: func(t *testing.T, ob *OrderBook) {
 				// Order should remain unchanged
-				if ob.bids[0].Price != 100.0 {
-					t.Errorf("Expected order price to remain 100.0, got %f", ob.bids[0].Price)
+				if ob.bids.orders()[0].Price != dec(100.0) {
+					t.Errorf("Expected order price to remain dec(100.0), got %v", ob.bids.orders()[0].Price)
 				}
 			},
 		},
 		{
 			name: "Invalid modification - zero amount",
 			ordersToAdd: []Order{
-				{ID: "ask-1", Price: 100.0, Amount: 1.0, Side: Sell},
+				{ID: "ask-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
 			},
 			orderToModify: "ask-1",
-			newPrice:      100.0,
-			newAmount:     0.0,
+			newPrice:      dec(100.0),
+			newAmount:     dec(0.0),
 			expectedError: true,
 			checkBookFunc: func(t *testing.T, ob *OrderBook) {
 				// Order should remain unchanged
-				if ob.asks[0].Amount != 1.0 {
-					t.Errorf("Expected order amount to remain 1.0, got %f", ob.asks[0].Amount)
+				if ob.asks.orders()[0].Amount != dec(1.0) {
+					t.Errorf("Expected order amount to remain dec(1.0), got %v", ob.asks.orders()[0].Amount)
 				}
 			},
 		},
@@ -264,30 +267,30 @@ func TestPlaceOrder(t *testing.T) {
 	tests := []struct {
 		name              string
 		orders            []Order
-		expectedBidsOrder []float64
-		expectedAsksOrder []float64
+		expectedBidsOrder []Decimal
+		expectedBookOrder []Decimal
 	}{
 		{"Increasing Order Bids",
 			[]Order{
-				{Price: 100.0, Amount: 1.0, Side: Buy},
-				{Price: 102.0, Amount: 1.0, Side: Buy},
-				{Price: 101.0, Amount: 1.0, Side: Buy},
+				{Price: dec(100.0), Amount: dec(1.0), Side: Buy},
+				{Price: dec(102.0), Amount: dec(1.0), Side: Buy},
+				{Price: dec(101.0), Amount: dec(1.0), Side: Buy},
 			},
-			[]float64{102.0, 101.0, 100.0},
-			[]float64{},
+			[]Decimal{dec(102.0), dec(101.0), dec(100.0)},
+			[]Decimal{},
 		},
 		{"Decreasing Order Bids",
 			[]Order{
-				{Price: 100.0, Amount: 1.0, Side: Sell},
-				{Price: 102.0, Amount: 1.0, Side: Sell},
-				{Price: 101.0, Amount: 1.0, Side: Sell},
+				{Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{Price: dec(102.0), Amount: dec(1.0), Side: Sell},
+				{Price: dec(101.0), Amount: dec(1.0), Side: Sell},
 			},
-			[]float64{},
-			[]float64{100.0, 101.0, 102.0},
+			[]Decimal{},
+			[]Decimal{dec(100.0), dec(101.0), dec(102.0)},
 		},
 	}
 
-	assertPriceOrder := func(t *testing.T, got []Order, expected []float64, orderType string) {
+	assertPriceOrder := func(t *testing.T, got []Order, expected []Decimal, orderType string) {
 		if len(got) != len(expected) {
 			t.Errorf("Expected %d %s orders, got %d", len(expected), orderType, len(got))
 			return
@@ -307,8 +310,8 @@ func TestPlaceOrder(t *testing.T) {
 					t.Fatalf("Failed to place order: %v", err)
 				}
 			}
-			assertPriceOrder(t, ob.bids, tt.expectedBidsOrder, "BID")
-			assertPriceOrder(t, ob.asks, tt.expectedAsksOrder, "ASK")
+			assertPriceOrder(t, ob.bids.orders(), tt.expectedBidsOrder, "BID")
+			assertPriceOrder(t, ob.asks.orders(), tt.expectedBookOrder, "ASK")
 		})
 	}
 }
@@ -323,22 +326,22 @@ func TestProcessOrder_CompleteMatch(t *testing.T) {
 		{
 			"Complete Match: Buy and Sell",
 			Order{
-				Price:  100.0,
-				Amount: 1.0,
+				Price:  dec(100.0),
+				Amount: dec(1.0),
 				Side:   Sell,
 				ID:     "sell-1",
 			},
 			Order{
-				Price:  100.0,
-				Amount: 1.0,
+				Price:  dec(100.0),
+				Amount: dec(1.0),
 				Side:   Buy,
 				ID:     "buy-1",
 			},
 			&Trade{
 				BuyOrderID:  "buy-1",
 				SellOrderID: "sell-1",
-				Price:       100.0,
-				Amount:      1.0,
+				Price:       dec(100.0),
+				Amount:      dec(1.0),
 			},
 		},
 	}
@@ -350,10 +353,11 @@ func TestProcessOrder_CompleteMatch(t *testing.T) {
 				t.Fatalf("Failed to place existing order: %v", err)
 			}
 
-			trades, err := ob.ProcessOrder(tt.newOrder)
+			result, err := ob.ProcessOrder(tt.newOrder)
 			if err != nil {
 				t.Fatalf("Failed to process order: %v", err)
 			}
+			trades := result.Trades
 			assertTradeCount(t, trades, 1)
 			assertTradeDetails(t, trades[0], tt.expectedTrade)
 			assertEmptyOrderBook(t, ob)
@@ -372,26 +376,26 @@ func TestProcessOrder_PartialMatch(t *testing.T) {
 		{
 			"Partial March: Buy greater than Sell",
 			Order{
-				Price:  100.0,
-				Amount: 1.0,
+				Price:  dec(100.0),
+				Amount: dec(1.0),
 				Side:   Sell,
 				ID:     "sell-1",
 			},
 			Order{
-				Price:  100.0,
-				Amount: 2.0,
+				Price:  dec(100.0),
+				Amount: dec(2.0),
 				Side:   Buy,
 				ID:     "buy-1",
 			},
 			&Trade{
 				BuyOrderID:  "buy-1",
 				SellOrderID: "sell-1",
-				Price:       100.0,
-				Amount:      1.0,
+				Price:       dec(100.0),
+				Amount:      dec(1.0),
 			},
 			Order{
-				Price:  100.0,
-				Amount: 1.0,
+				Price:  dec(100.0),
+				Amount: dec(1.0),
 				Side:   Buy,
 				ID:     "buy-1",
 			},
@@ -405,10 +409,11 @@ func TestProcessOrder_PartialMatch(t *testing.T) {
 				t.Fatalf("Failed to place existing order: %v", err)
 			}
 
-			trades, err := ob.ProcessOrder(tt.newOrder)
+			result, err := ob.ProcessOrder(tt.newOrder)
 			if err != nil {
 				t.Fatalf("Failed to process order: %v", err)
 			}
+			trades := result.Trades
 			assertTradeCount(t, trades, 1)
 			assertTradeDetails(t, trades[0], tt.expectedTrade)
 			assertRemainingOrder(t, ob, tt.remainingOrder)
@@ -426,44 +431,44 @@ func TestProcessOrder_PriceTimePriority(t *testing.T) {
 		{
 			name: "Buy order matches best ask first",
 			existingOrders: []Order{
-				{ID: "sell-1", Price: 102.0, Amount: 1.0, Side: Sell},
-				{ID: "sell-2", Price: 100.0, Amount: 1.0, Side: Sell}, // Should match first
-				{ID: "sell-3", Price: 101.0, Amount: 1.0, Side: Sell},
+				{ID: "sell-1", Price: dec(102.0), Amount: dec(1.0), Side: Sell},
+				{ID: "sell-2", Price: dec(100.0), Amount: dec(1.0), Side: Sell}, // Should match first
+				{ID: "sell-3", Price: dec(101.0), Amount: dec(1.0), Side: Sell},
 			},
 			newOrder: Order{
 				ID:     "buy-1",
-				Price:  102.0,
-				Amount: 1.0,
+				Price:  dec(102.0),
+				Amount: dec(1.0),
 				Side:   Buy,
 			},
 			expectedTrades: []*Trade{
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-2",
-					Price:       100.0,
-					Amount:      1.0,
+					Price:       dec(100.0),
+					Amount:      dec(1.0),
 				},
 			},
 		},
 		{
 			name: "Sell order matches best bid first",
 			existingOrders: []Order{
-				{ID: "buy-1", Price: 98.0, Amount: 1.0, Side: Buy},
-				{ID: "buy-2", Price: 100.0, Amount: 1.0, Side: Buy}, // Should match first
-				{ID: "buy-3", Price: 99.0, Amount: 1.0, Side: Buy},
+				{ID: "buy-1", Price: dec(98.0), Amount: dec(1.0), Side: Buy},
+				{ID: "buy-2", Price: dec(100.0), Amount: dec(1.0), Side: Buy}, // Should match first
+				{ID: "buy-3", Price: dec(99.0), Amount: dec(1.0), Side: Buy},
 			},
 			newOrder: Order{
 				ID:     "sell-1",
-				Price:  98.0,
-				Amount: 1.0,
+				Price:  dec(98.0),
+				Amount: dec(1.0),
 				Side:   Sell,
 			},
 			expectedTrades: []*Trade{
 				{
 					BuyOrderID:  "buy-2",
 					SellOrderID: "sell-1",
-					Price:       100.0,
-					Amount:      1.0,
+					Price:       dec(100.0),
+					Amount:      dec(1.0),
 				},
 			},
 		},
@@ -481,10 +486,11 @@ func TestProcessOrder_PriceTimePriority(t *testing.T) {
 			}
 
 			// Process new order
-			trades, err := ob.ProcessOrder(tt.newOrder)
+			result, err := ob.ProcessOrder(tt.newOrder)
 			if err != nil {
 				t.Fatalf("Failed to process order: %v", err)
 			}
+			trades := result.Trades
 
 			// Verify number of trades
 			if len(trades) != len(tt.expectedTrades) {
@@ -511,24 +517,24 @@ func TestProcessOrder_EdgeCases(t *testing.T) {
 		{
 			name: "Zero remaining amount after partial fill",
 			existingOrders: []Order{
-				{ID: "sell-1", Price: 100.0, Amount: 1.5, Side: Sell},
+				{ID: "sell-1", Price: dec(100.0), Amount: dec(1.5), Side: Sell},
 			},
 			newOrder: Order{
 				ID:     "buy-1",
-				Price:  100.0,
-				Amount: 1.5,
+				Price:  dec(100.0),
+				Amount: dec(1.5),
 				Side:   Buy,
 			},
 			expectedTrades: []*Trade{
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-1",
-					Price:       100.0,
-					Amount:      1.5,
+					Price:       dec(100.0),
+					Amount:      dec(1.5),
 				},
 			},
 			checkBook: func(t *testing.T, ob *OrderBook) {
-				if len(ob.asks) != 0 || len(ob.bids) != 0 {
+				if len(ob.asks.orders()) != 0 || len(ob.bids.orders()) != 0 {
 					t.Error("Expected empty orderbook after exact match")
 				}
 			},
@@ -536,103 +542,103 @@ func TestProcessOrder_EdgeCases(t *testing.T) {
 		{
 			name: "Multiple orders same price level",
 			existingOrders: []Order{
-				{ID: "sell-1", Price: 100.0, Amount: 1.0, Side: Sell},
-				{ID: "sell-2", Price: 100.0, Amount: 1.0, Side: Sell},
+				{ID: "sell-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{ID: "sell-2", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
 			},
 			newOrder: Order{
 				ID:     "buy-1",
-				Price:  100.0,
-				Amount: 1.5,
+				Price:  dec(100.0),
+				Amount: dec(1.5),
 				Side:   Buy,
 			},
 			expectedTrades: []*Trade{
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-1",
-					Price:       100.0,
-					Amount:      1.0,
+					Price:       dec(100.0),
+					Amount:      dec(1.0),
 				},
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-2",
-					Price:       100.0,
-					Amount:      0.5,
+					Price:       dec(100.0),
+					Amount:      dec(0.5),
 				},
 			},
 			checkBook: func(t *testing.T, ob *OrderBook) {
-				if len(ob.asks) != 1 {
-					t.Errorf("Expected 1 ask remaining, got %d", len(ob.asks))
+				if len(ob.asks.orders()) != 1 {
+					t.Errorf("Expected 1 ask remaining, got %d", len(ob.asks.orders()))
 				}
-				if ob.asks[0].Amount != 0.5 {
-					t.Errorf("Expected remaining amount 0.5, got %f", ob.asks[0].Amount)
+				if ob.asks.orders()[0].Amount != dec(0.5) {
+					t.Errorf("Expected remaining amount dec(0.5), got %v", ob.asks.orders()[0].Amount)
 				}
 			},
 		},
 		{
 			name: "Minimum price increment handling",
 			existingOrders: []Order{
-				{ID: "sell-1", Price: 100.001, Amount: 1.0, Side: Sell},
-				{ID: "sell-2", Price: 100.002, Amount: 1.0, Side: Sell},
+				{ID: "sell-1", Price: dec(100.001), Amount: dec(1.0), Side: Sell},
+				{ID: "sell-2", Price: dec(100.002), Amount: dec(1.0), Side: Sell},
 			},
 			newOrder: Order{
 				ID:     "buy-1",
-				Price:  100.002,
-				Amount: 1.0,
+				Price:  dec(100.002),
+				Amount: dec(1.0),
 				Side:   Buy,
 			},
 			expectedTrades: []*Trade{
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-1",
-					Price:       100.001,
-					Amount:      1.0,
+					Price:       dec(100.001),
+					Amount:      dec(1.0),
 				},
 			},
 			checkBook: func(t *testing.T, ob *OrderBook) {
-				if len(ob.asks) != 1 {
-					t.Errorf("Expected 1 ask remaining, got %d", len(ob.asks))
+				if len(ob.asks.orders()) != 1 {
+					t.Errorf("Expected 1 ask remaining, got %d", len(ob.asks.orders()))
 				}
 			},
 		},
 		{
 			name: "Large order matching multiple price levels",
 			existingOrders: []Order{
-				{ID: "sell-1", Price: 100.0, Amount: 1.0, Side: Sell},
-				{ID: "sell-2", Price: 101.0, Amount: 2.0, Side: Sell},
-				{ID: "sell-3", Price: 102.0, Amount: 3.0, Side: Sell},
+				{ID: "sell-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{ID: "sell-2", Price: dec(101.0), Amount: dec(2.0), Side: Sell},
+				{ID: "sell-3", Price: dec(102.0), Amount: dec(3.0), Side: Sell},
 			},
 			newOrder: Order{
 				ID:     "buy-1",
-				Price:  102.0,
-				Amount: 4.0,
+				Price:  dec(102.0),
+				Amount: dec(4.0),
 				Side:   Buy,
 			},
 			expectedTrades: []*Trade{
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-1",
-					Price:       100.0,
-					Amount:      1.0,
+					Price:       dec(100.0),
+					Amount:      dec(1.0),
 				},
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-2",
-					Price:       101.0,
-					Amount:      2.0,
+					Price:       dec(101.0),
+					Amount:      dec(2.0),
 				},
 				{
 					BuyOrderID:  "buy-1",
 					SellOrderID: "sell-3",
-					Price:       102.0,
-					Amount:      1.0,
+					Price:       dec(102.0),
+					Amount:      dec(1.0),
 				},
 			},
 			checkBook: func(t *testing.T, ob *OrderBook) {
-				if len(ob.asks) != 1 {
-					t.Errorf("Expected 1 ask remaining, got %d", len(ob.asks))
+				if len(ob.asks.orders()) != 1 {
+					t.Errorf("Expected 1 ask remaining, got %d", len(ob.asks.orders()))
 				}
-				if ob.asks[0].Amount != 2.0 {
-					t.Errorf("Expected remaining amount 2.0, got %f", ob.asks[0].Amount)
+				if ob.asks.orders()[0].Amount != dec(2.0) {
+					t.Errorf("Expected remaining amount dec(2.0), got %v", ob.asks.orders()[0].Amount)
 				}
 			},
 		},
@@ -650,10 +656,11 @@ func TestProcessOrder_EdgeCases(t *testing.T) {
 			}
 
 			// Process new order
-			trades, err := ob.ProcessOrder(tt.newOrder)
+			result, err := ob.ProcessOrder(tt.newOrder)
 			if err != nil {
 				t.Fatalf("Failed to process order: %v", err)
 			}
+			trades := result.Trades
 
 			// Verify number of trades
 			if len(trades) != len(tt.expectedTrades) {
@@ -678,7 +685,7 @@ func TestGetBestBid(t *testing.T) {
 	tests := []struct {
 		name          string
 		ordersToAdd   []Order
-		expectedPrice float64
+		expectedPrice Decimal
 		expectError   bool
 	}{
 		{
@@ -690,25 +697,25 @@ func TestGetBestBid(t *testing.T) {
 		{
 			"Single bid order",
 			[]Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Buy},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Buy},
 			},
-			100.0,
+			dec(100.0),
 			false,
 		},
 		{
 			"Multiple bid orders",
 			[]Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Buy},
-				{ID: "2", Price: 101.0, Amount: 1.0, Side: Buy},
-				{ID: "3", Price: 99.0, Amount: 1.0, Side: Buy},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Buy},
+				{ID: "2", Price: dec(101.0), Amount: dec(1.0), Side: Buy},
+				{ID: "3", Price: dec(99.0), Amount: dec(1.0), Side: Buy},
 			},
-			101.0,
+			dec(101.0),
 			false,
 		},
 		{
 			"Only ask orders",
 			[]Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Sell},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
 			},
 			0,
 			true,
@@ -756,7 +763,7 @@ func TestGetBestAsk(t *testing.T) {
 	tests := []struct {
 		name          string
 		ordersToAdd   []Order
-		expectedPrice float64
+		expectedPrice Decimal
 		expectError   bool
 	}{
 		{
@@ -767,25 +774,25 @@ func TestGetBestAsk(t *testing.T) {
 		{
 			name: "Single ask order",
 			ordersToAdd: []Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Sell},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
 			},
-			expectedPrice: 100.0,
+			expectedPrice: dec(100.0),
 			expectError:   false,
 		},
 		{
 			name: "Multiple ask orders",
 			ordersToAdd: []Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Sell},
-				{ID: "2", Price: 98.0, Amount: 1.0, Side: Sell},
-				{ID: "3", Price: 99.0, Amount: 1.0, Side: Sell},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{ID: "2", Price: dec(98.0), Amount: dec(1.0), Side: Sell},
+				{ID: "3", Price: dec(99.0), Amount: dec(1.0), Side: Sell},
 			},
-			expectedPrice: 98.0,
+			expectedPrice: dec(98.0),
 			expectError:   false,
 		},
 		{
 			name: "Only bid orders",
 			ordersToAdd: []Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Buy},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Buy},
 			},
 			expectError: true,
 		},
@@ -832,45 +839,45 @@ func TestGetOrderBookSnapshot(t *testing.T) {
 	tests := []struct {
 		name         string
 		ordersToAdd  []Order
-		expectedAsks []OrderBookLevel
+		expectedBook []OrderBookLevel
 		expectedBids []OrderBookLevel
 	}{
 		{
 			name:         "Empty orderbook",
 			ordersToAdd:  []Order{},
-			expectedAsks: []OrderBookLevel{},
+			expectedBook: []OrderBookLevel{},
 			expectedBids: []OrderBookLevel{},
 		},
 		{
 			name: "Single price level",
 			ordersToAdd: []Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Sell},
-				{ID: "2", Price: 100.0, Amount: 2.0, Side: Sell},
-				{ID: "3", Price: 90.0, Amount: 3.0, Side: Buy},
-				{ID: "4", Price: 90.0, Amount: 1.0, Side: Buy},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{ID: "2", Price: dec(100.0), Amount: dec(2.0), Side: Sell},
+				{ID: "3", Price: dec(90.0), Amount: dec(3.0), Side: Buy},
+				{ID: "4", Price: dec(90.0), Amount: dec(1.0), Side: Buy},
 			},
-			expectedAsks: []OrderBookLevel{
-				{Price: 100.0, TotalAmount: 3.0, OrderCount: 2},
+			expectedBook: []OrderBookLevel{
+				{Price: dec(100.0), TotalAmount: dec(3.0), OrderCount: 2},
 			},
 			expectedBids: []OrderBookLevel{
-				{Price: 90.0, TotalAmount: 4.0, OrderCount: 2},
+				{Price: dec(90.0), TotalAmount: dec(4.0), OrderCount: 2},
 			},
 		},
 		{
 			name: "Multiple price levels",
 			ordersToAdd: []Order{
-				{ID: "1", Price: 100.0, Amount: 1.0, Side: Sell},
-				{ID: "2", Price: 101.0, Amount: 2.0, Side: Sell},
-				{ID: "3", Price: 99.0, Amount: 3.0, Side: Buy},
-				{ID: "4", Price: 98.0, Amount: 1.0, Side: Buy},
+				{ID: "1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+				{ID: "2", Price: dec(101.0), Amount: dec(2.0), Side: Sell},
+				{ID: "3", Price: dec(99.0), Amount: dec(3.0), Side: Buy},
+				{ID: "4", Price: dec(98.0), Amount: dec(1.0), Side: Buy},
 			},
-			expectedAsks: []OrderBookLevel{
-				{Price: 100.0, TotalAmount: 1.0, OrderCount: 1},
-				{Price: 101.0, TotalAmount: 2.0, OrderCount: 1},
+			expectedBook: []OrderBookLevel{
+				{Price: dec(100.0), TotalAmount: dec(1.0), OrderCount: 1},
+				{Price: dec(101.0), TotalAmount: dec(2.0), OrderCount: 1},
 			},
 			expectedBids: []OrderBookLevel{
-				{Price: 99.0, TotalAmount: 3.0, OrderCount: 1},
-				{Price: 98.0, TotalAmount: 1.0, OrderCount: 1},
+				{Price: dec(99.0), TotalAmount: dec(3.0), OrderCount: 1},
+				{Price: dec(98.0), TotalAmount: dec(1.0), OrderCount: 1},
 			},
 		},
 	}
@@ -891,13 +898,13 @@ func TestGetOrderBookSnapshot(t *testing.T) {
 			snapshot := ob.GetOrderBookSnapshot()
 
 			// Verify asks
-			if len(snapshot.Asks) != len(tt.expectedAsks) {
+			if len(snapshot.Asks) != len(tt.expectedBook) {
 				t.Errorf("Expected %d ask levels, got %d",
-					len(tt.expectedAsks), len(snapshot.Asks))
+					len(tt.expectedBook), len(snapshot.Asks))
 			}
 
 			for i, ask := range snapshot.Asks {
-				expected := tt.expectedAsks[i]
+				expected := tt.expectedBook[i]
 				if !compareOrderBookLevel(ask, expected) {
 					t.Errorf("Ask level %d mismatch: expected %+v, got %+v",
 						i, expected, ask)
@@ -926,6 +933,950 @@ func TestGetOrderBookSnapshot(t *testing.T) {
 	}
 }
 
+func TestModifyOrder_PriceChangeLosesPriority(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	for _, order := range []Order{
+		{ID: "ask-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+		{ID: "ask-2", Price: dec(101.0), Amount: dec(1.0), Side: Sell},
+	} {
+		if err := ob.PlaceOrder(order); err != nil {
+			t.Fatalf("Failed to place order: %v", err)
+		}
+	}
+
+	// Moving ask-1 to 101 must queue it behind ask-2
+	if err := ob.ModifyOrder("ask-1", dec(101.0), dec(1.0)); err != nil {
+		t.Fatalf("Failed to modify order: %v", err)
+	}
+
+	asks := ob.asks.orders()
+	if len(asks) != 2 || asks[0].ID != "ask-2" || asks[1].ID != "ask-1" {
+		t.Errorf("Expected [ask-2 ask-1] at 101, got %+v", asks)
+	}
+	if ob.asks.depth() != 1 {
+		t.Errorf("Expected the empty 100 level to be dropped, got %d levels", ob.asks.depth())
+	}
+}
+
+func TestPlaceOrder_DuplicateID(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	if err := ob.PlaceOrder(Order{ID: "dup", Price: dec(100.0), Amount: dec(1.0), Side: Buy}); err != nil {
+		t.Fatalf("Failed to place order: %v", err)
+	}
+	if err := ob.PlaceOrder(Order{ID: "dup", Price: dec(99.0), Amount: dec(1.0), Side: Sell}); err != ErrDuplicateOrder {
+		t.Errorf("Expected ErrDuplicateOrder, got %v", err)
+	}
+	if _, err := ob.ProcessOrder(Order{ID: "dup", Price: dec(100.0), Amount: dec(1.0), Side: Sell}); err != ErrDuplicateOrder {
+		t.Errorf("Expected ErrDuplicateOrder, got %v", err)
+	}
+}
+
+func TestBookSide_ManyLevels(t *testing.T) {
+	ob := NewOrderBook("TEST")
+
+	// Insert bids in a scrambled order and cancel every third one
+	const n = 1000
+	for i := 0; i < n; i++ {
+		price := Decimal((i*7919)%n+1) * One
+		order := Order{ID: fmt.Sprintf("bid-%d", i), Price: price, Amount: dec(1.0), Side: Buy}
+		if err := ob.PlaceOrder(order); err != nil {
+			t.Fatalf("Failed to place order: %v", err)
+		}
+	}
+	for i := 0; i < n; i += 3 {
+		if err := ob.CancelOrder(fmt.Sprintf("bid-%d", i)); err != nil {
+			t.Fatalf("Failed to cancel order: %v", err)
+		}
+	}
+
+	bids := ob.bids.orders()
+	if len(bids) != n-(n+2)/3 {
+		t.Fatalf("Expected %d bids, got %d", n-(n+2)/3, len(bids))
+	}
+	for i := 1; i < len(bids); i++ {
+		if bids[i-1].Price <= bids[i].Price {
+			t.Fatalf("Bids not sorted at %d: %v then %v", i, bids[i-1].Price, bids[i].Price)
+		}
+	}
+	if len(ob.orders) != len(bids) {
+		t.Errorf("Index holds %d orders, book holds %d", len(ob.orders), len(bids))
+	}
+}
+
+func TestProcessOrder_NoDust(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	if err := ob.PlaceOrder(Order{ID: "sell-1", Price: dec(100.0), Amount: MustParseDecimal("0.3"), Side: Sell}); err != nil {
+		t.Fatalf("Failed to place order: %v", err)
+	}
+
+	// 0.1 + 0.2 must consume 0.3 exactly and leave nothing behind
+	for i, amount := range []string{"0.1", "0.2"} {
+		order := Order{ID: fmt.Sprintf("buy-%d", i), Price: dec(100.0), Amount: MustParseDecimal(amount), Side: Buy}
+		if _, err := ob.ProcessOrder(order); err != nil {
+			t.Fatalf("Failed to process order: %v", err)
+		}
+	}
+	assertEmptyOrderBook(t, ob)
+}
+
+func TestProcessOrder_TradeIdentity(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ob := NewOrderBook("BTC-USD", WithClock(func() time.Time { return now }))
+	ob.PlaceOrder(Order{ID: "ask-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "ask-2", Price: dec(101.0), Amount: dec(1.0), Side: Sell})
+
+	result, err := ob.ProcessOrder(Order{ID: "taker", Price: dec(101.0), Amount: dec(2.0), Side: Buy})
+	if err != nil || len(result.Trades) != 2 {
+		t.Fatalf("Expected two trades, got %+v, %v", result, err)
+	}
+
+	for i, trade := range result.Trades {
+		expected := &Trade{
+			ID:            tradeID(ob.ID, uint64(i+1)),
+			Seq:           uint64(i + 1),
+			Symbol:        "BTC-USD",
+			Timestamp:     now,
+			BuyOrderID:    "taker",
+			SellOrderID:   fmt.Sprintf("ask-%d", i+1),
+			MakerOrderID:  fmt.Sprintf("ask-%d", i+1),
+			TakerOrderID:  "taker",
+			AggressorSide: Buy,
+			Price:         dec(100.0 + float64(i)),
+			Amount:        dec(1.0),
+		}
+		if !reflect.DeepEqual(trade, expected) {
+			t.Errorf("Trade %d: expected %+v, got %+v", i, expected, trade)
+		}
+	}
+	if result.Trades[0].ID == result.Trades[1].ID {
+		t.Error("Expected unique trade IDs")
+	}
+	if tradeID("other-book", 1) == result.Trades[0].ID {
+		t.Error("Expected trade IDs to differ between books")
+	}
+
+	// A sell taking a resting bid is a sell-side aggression
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(99.0), Amount: dec(1.0), Side: Buy})
+	result, _ = ob.ProcessOrder(Order{ID: "seller", Price: dec(99.0), Amount: dec(1.0), Side: Sell})
+	trade := result.Trades[0]
+	if trade.AggressorSide != Sell || trade.MakerOrderID != "bid" || trade.TakerOrderID != "seller" || trade.Seq != 3 {
+		t.Errorf("Unexpected trade %+v", trade)
+	}
+}
+
+func TestPlaceOrder_Precision(t *testing.T) {
+	ob := NewOrderBook("TEST", WithPrecision(2, 3))
+
+	tests := []struct {
+		name   string
+		price  string
+		amount string
+		err    error
+	}{
+		{"Within precision", "100.25", "1.125", nil},
+		{"Price too fine", "100.255", "1", ErrInvalidPrecision},
+		{"Amount too fine", "100", "1.1255", ErrInvalidPrecision},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := Order{
+				ID:     fmt.Sprintf("order-%d", i),
+				Price:  MustParseDecimal(tt.price),
+				Amount: MustParseDecimal(tt.amount),
+				Side:   Buy,
+			}
+			if err := ob.PlaceOrder(order); err != tt.err {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	if err := ob.ModifyOrder("order-0", MustParseDecimal("100.001"), One); err != ErrInvalidPrecision {
+		t.Errorf("Expected ErrInvalidPrecision on modify, got %v", err)
+	}
+}
+
+func TestPlaceOrder_Instrument(t *testing.T) {
+	spec := InstrumentSpec{
+		PricePrecision:  2,
+		AmountPrecision: 3,
+		TickSize:        dec(0.05),
+		LotSize:         dec(0.01),
+		MinQuantity:     dec(0.1),
+		MaxQuantity:     dec(10.0),
+		MinNotional:     dec(20.0),
+	}
+	ob := NewOrderBook("TEST", WithInstrument(spec))
+
+	tests := []struct {
+		name  string
+		order Order
+		err   error
+	}{
+		{"Valid", Order{Price: dec(100.05), Amount: dec(1.25), Side: Buy}, nil},
+		{"Too many decimals", Order{Price: dec(100.051), Amount: dec(1.0), Side: Buy}, ErrInvalidPrecision},
+		{"Off tick", Order{Price: dec(100.02), Amount: dec(1.0), Side: Buy}, ErrInvalidTickSize},
+		{"Stop price off tick", Order{Type: StopLimit, StopPrice: dec(99.99), Price: dec(100.0), Amount: dec(1.0), Side: Sell}, ErrInvalidTickSize},
+		{"Off lot", Order{Price: dec(100.0), Amount: dec(1.005), Side: Buy}, ErrInvalidLotSize},
+		{"Peak off lot", Order{Price: dec(100.0), Amount: dec(1.0), PeakAmount: dec(0.105), Side: Buy}, ErrInvalidLotSize},
+		{"Below min quantity", Order{Price: dec(400.0), Amount: dec(0.05), Side: Buy}, ErrBelowMinQuantity},
+		{"Above max quantity", Order{Price: dec(100.0), Amount: dec(10.01), Side: Buy}, ErrAboveMaxQuantity},
+		{"Below min notional", Order{Price: dec(100.0), Amount: dec(0.19), Side: Buy}, ErrBelowMinNotional},
+		{"Market order has no notional", Order{Type: Market, Amount: dec(0.1), Side: Buy}, nil},
+		{"Market order below min quantity", Order{Type: Market, Amount: dec(0.09), Side: Buy}, ErrBelowMinQuantity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ob.ProcessOrder(tt.order); err != tt.err {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	bid, _ := ob.GetBestBid()
+	modifications := []struct {
+		price  Decimal
+		amount Decimal
+		err    error
+	}{
+		{dec(100.03), dec(1.0), ErrInvalidTickSize},
+		{dec(100.0), dec(1.001), ErrInvalidLotSize},
+		{dec(100.0), dec(11.0), ErrAboveMaxQuantity},
+		{dec(100.0), dec(0.15), ErrBelowMinNotional},
+		{dec(100.0), dec(0.2), nil},
+	}
+	for _, m := range modifications {
+		if err := ob.ModifyOrder(bid.ID, m.price, m.amount); err != m.err {
+			t.Errorf("Modify to %s@%s: expected error %v, got %v", m.amount, m.price, m.err, err)
+		}
+	}
+
+	// Post-only orders slide by a whole tick
+	ob.PlaceOrder(Order{ID: "ask", Price: dec(101.0), Amount: dec(1.0), Side: Sell})
+	result, err := ob.ProcessOrder(Order{Price: dec(101.0), Amount: dec(1.0), Side: Buy, PostOnly: true, PostOnlySlide: true})
+	if err != nil || result.Price != dec(100.95) {
+		t.Errorf("Expected the order to slide to 100.95, got %v (%v)", result, err)
+	}
+}
+
+func TestInstrumentSpec_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		spec InstrumentSpec
+		err  error
+	}{
+		{"Default", DefaultInstrumentSpec(), nil},
+		{"Integer prices", InstrumentSpec{AmountPrecision: 2, TickSize: dec(5.0)}, nil},
+		{"Precision too high", InstrumentSpec{PricePrecision: 9}, ErrInvalidInstrument},
+		{"Negative tick", InstrumentSpec{PricePrecision: 2, TickSize: dec(-0.01)}, ErrInvalidInstrument},
+		{"Tick finer than precision", InstrumentSpec{PricePrecision: 2, TickSize: dec(0.005)}, ErrInvalidInstrument},
+		{"Lot finer than precision", InstrumentSpec{AmountPrecision: 0, LotSize: dec(0.5)}, ErrInvalidInstrument},
+		{"Max below min", InstrumentSpec{MinQuantity: dec(2.0), MaxQuantity: dec(1.0)}, ErrInvalidInstrument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.spec.Validate(); err != tt.err {
+				t.Errorf("Expected error %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestProcessOrder_Market(t *testing.T) {
+	asks := []Order{
+		{ID: "sell-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+		{ID: "sell-2", Price: dec(101.0), Amount: dec(1.0), Side: Sell},
+		{ID: "sell-3", Price: dec(110.0), Amount: dec(1.0), Side: Sell},
+	}
+
+	tests := []struct {
+		name              string
+		order             Order
+		expectedTrades    int
+		expectedFilled    Decimal
+		expectedCancelled Decimal
+		expectedStatus    OrderStatus
+	}{
+		{
+			name:           "Sweeps regardless of price",
+			order:          Order{ID: "buy-1", Type: Market, Amount: dec(3.0), Side: Buy},
+			expectedTrades: 3,
+			expectedFilled: dec(3.0),
+			expectedStatus: StatusFilled,
+		},
+		{
+			name:              "Remainder is cancelled",
+			order:             Order{ID: "buy-1", Type: Market, Amount: dec(5.0), Side: Buy},
+			expectedTrades:    3,
+			expectedFilled:    dec(3.0),
+			expectedCancelled: dec(2.0),
+			expectedStatus:    StatusCancelled,
+		},
+		{
+			name:              "Slippage protection",
+			order:             Order{ID: "buy-1", Type: Market, Amount: dec(3.0), Side: Buy, MaxSlippageBps: 100},
+			expectedTrades:    2,
+			expectedFilled:    dec(2.0),
+			expectedCancelled: dec(1.0),
+			expectedStatus:    StatusCancelled,
+		},
+		{
+			name:              "Notional cap",
+			order:             Order{ID: "buy-1", Type: Market, Amount: dec(3.0), Side: Buy, MaxNotional: dec(150.5)},
+			expectedTrades:    2,
+			expectedFilled:    dec(1.5),
+			expectedCancelled: dec(1.5),
+			expectedStatus:    StatusCancelled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook("TEST")
+			for _, order := range asks {
+				if err := ob.PlaceOrder(order); err != nil {
+					t.Fatalf("Failed to place order: %v", err)
+				}
+			}
+
+			result, err := ob.ProcessOrder(tt.order)
+			if err != nil {
+				t.Fatalf("Failed to process order: %v", err)
+			}
+			assertTradeCount(t, result.Trades, tt.expectedTrades)
+			if result.FilledAmount != tt.expectedFilled || result.CancelledAmount != tt.expectedCancelled {
+				t.Errorf("Expected filled %v cancelled %v, got filled %v cancelled %v",
+					tt.expectedFilled, tt.expectedCancelled, result.FilledAmount, result.CancelledAmount)
+			}
+			if result.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, result.Status)
+			}
+			if len(ob.bids.orders()) != 0 {
+				t.Error("Market order must never rest")
+			}
+		})
+	}
+}
+
+func TestMarketOrder_Validation(t *testing.T) {
+	ob := NewOrderBook("TEST")
+
+	if err := ob.PlaceOrder(Order{ID: "m", Type: Market, Amount: dec(1.0), Side: Buy}); err != ErrInvalidOrderType {
+		t.Errorf("Expected ErrInvalidOrderType placing a market order, got %v", err)
+	}
+	if _, err := ob.ProcessOrder(Order{ID: "s", Type: "TRAILING_STOP", Amount: dec(1.0), Side: Buy}); err != ErrInvalidOrderType {
+		t.Errorf("Expected ErrInvalidOrderType for unknown type, got %v", err)
+	}
+	if _, err := ob.ProcessOrder(Order{ID: "l", Price: dec(1.0), Amount: dec(1.0), Side: Buy, MaxNotional: dec(1.0)}); err != ErrInvalidOrder {
+		t.Errorf("Expected ErrInvalidOrder for protected limit order, got %v", err)
+	}
+
+	// A market order against an empty book is simply cancelled
+	result, err := ob.ProcessOrder(Order{ID: "m", Type: Market, Amount: dec(1.0), Side: Sell})
+	if err != nil {
+		t.Fatalf("Failed to process order: %v", err)
+	}
+	if result.Status != StatusCancelled || result.CancelledAmount != dec(1.0) {
+		t.Errorf("Expected fully cancelled market order, got %+v", result)
+	}
+}
+
+func TestMarketOrder_ProtectionOverflow(t *testing.T) {
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithJournal(j))
+	if err := ob.PlaceOrder(Order{ID: "cheap", Price: dec(0.001), Amount: dec(1.0), Side: Sell}); err != nil {
+		t.Fatalf("Failed to place order: %v", err)
+	}
+
+	// Slippage beyond 100% of the price is rejected before it is journaled
+	if _, err := ob.ProcessOrder(Order{ID: "slip", Type: Market, Amount: dec(1.0), Side: Buy, MaxSlippageBps: 900000000000000}); err != ErrInvalidOrder {
+		t.Errorf("Expected ErrInvalidOrder for slippage over 100%%, got %v", err)
+	}
+
+	// A cap too large to divide by the price caps nothing
+	result, err := ob.ProcessOrder(Order{ID: "capped", Type: Market, Amount: dec(1.0), Side: Buy, MaxNotional: dec(1000000000.0)})
+	if err != nil {
+		t.Fatalf("Failed to process order: %v", err)
+	}
+	if result.Status != StatusFilled || result.FilledAmount != dec(1.0) {
+		t.Errorf("Expected a full fill, got %+v", result)
+	}
+
+	// Nor does it crash the replay of the journal
+	replayed := NewOrderBook("TEST")
+	replayed.ID = ob.ID
+	for _, record := range j.records {
+		if err := replayed.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+	if len(replayed.asks.orders()) != 0 {
+		t.Errorf("Expected the replayed ask to be filled, got %v", replayed.asks.orders())
+	}
+}
+
+func TestProcessOrder_TimeInForce(t *testing.T) {
+	asks := []Order{
+		{ID: "sell-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+		{ID: "sell-2", Price: dec(101.0), Amount: dec(1.0), Side: Sell},
+	}
+
+	tests := []struct {
+		name              string
+		order             Order
+		expectedTrades    int
+		expectedResting   Decimal
+		expectedCancelled Decimal
+		expectedStatus    OrderStatus
+	}{
+		{
+			name:            "GTC rests the remainder",
+			order:           Order{ID: "buy-1", Price: dec(100.0), Amount: dec(2.0), Side: Buy, TimeInForce: GTC},
+			expectedTrades:  1,
+			expectedResting: dec(1.0),
+			expectedStatus:  StatusPartiallyFilled,
+		},
+		{
+			name:              "IOC cancels the remainder",
+			order:             Order{ID: "buy-1", Price: dec(100.0), Amount: dec(2.0), Side: Buy, TimeInForce: IOC},
+			expectedTrades:    1,
+			expectedCancelled: dec(1.0),
+			expectedStatus:    StatusCancelled,
+		},
+		{
+			name:              "FOK without enough liquidity trades nothing",
+			order:             Order{ID: "buy-1", Price: dec(100.0), Amount: dec(2.0), Side: Buy, TimeInForce: FOK},
+			expectedTrades:    0,
+			expectedCancelled: dec(2.0),
+			expectedStatus:    StatusCancelled,
+		},
+		{
+			name:           "FOK with enough liquidity fills",
+			order:          Order{ID: "buy-1", Price: dec(101.0), Amount: dec(2.0), Side: Buy, TimeInForce: FOK},
+			expectedTrades: 2,
+			expectedStatus: StatusFilled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook("TEST")
+			for _, order := range asks {
+				if err := ob.PlaceOrder(order); err != nil {
+					t.Fatalf("Failed to place order: %v", err)
+				}
+			}
+
+			result, err := ob.ProcessOrder(tt.order)
+			if err != nil {
+				t.Fatalf("Failed to process order: %v", err)
+			}
+			assertTradeCount(t, result.Trades, tt.expectedTrades)
+			if result.RestingAmount != tt.expectedResting || result.CancelledAmount != tt.expectedCancelled {
+				t.Errorf("Expected resting %v cancelled %v, got resting %v cancelled %v",
+					tt.expectedResting, tt.expectedCancelled, result.RestingAmount, result.CancelledAmount)
+			}
+			if result.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, result.Status)
+			}
+			if result.TimeInForce != tt.order.TimeInForce {
+				t.Errorf("Expected time in force %s, got %s", tt.order.TimeInForce, result.TimeInForce)
+			}
+
+			// Nothing may have been consumed by a killed FOK order
+			if tt.expectedTrades == 0 && len(ob.asks.orders()) != len(asks) {
+				t.Errorf("Expected asks untouched, got %d orders", len(ob.asks.orders()))
+			}
+		})
+	}
+}
+
+func TestGoodTillDate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ob := NewOrderBook("TEST", WithClock(func() time.Time { return now }))
+	expireAt := now.Add(time.Minute)
+
+	if err := ob.PlaceOrder(Order{ID: "gtd", Price: dec(100.0), Amount: dec(1.0), Side: Sell, TimeInForce: GTD}); err != ErrInvalidTimeInForce {
+		t.Errorf("Expected ErrInvalidTimeInForce without expiry, got %v", err)
+	}
+	past := now.Add(-time.Second)
+	if err := ob.PlaceOrder(Order{ID: "gtd", Price: dec(100.0), Amount: dec(1.0), Side: Sell, TimeInForce: GTD, ExpireAt: &past}); err != ErrInvalidExpiry {
+		t.Errorf("Expected ErrInvalidExpiry for past expiry, got %v", err)
+	}
+	if err := ob.PlaceOrder(Order{ID: "ioc", Price: dec(100.0), Amount: dec(1.0), Side: Sell, TimeInForce: IOC}); err != ErrInvalidTimeInForce {
+		t.Errorf("Expected ErrInvalidTimeInForce placing IOC, got %v", err)
+	}
+
+	for _, order := range []Order{
+		{ID: "gtd-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell, TimeInForce: GTD, ExpireAt: &expireAt},
+		{ID: "gtc-1", Price: dec(101.0), Amount: dec(1.0), Side: Sell},
+	} {
+		if err := ob.PlaceOrder(order); err != nil {
+			t.Fatalf("Failed to place order: %v", err)
+		}
+	}
+
+	if expired := ob.ExpireOrders(now); len(expired) != 0 {
+		t.Errorf("Expected nothing expired yet, got %v", expired)
+	}
+
+	expired := ob.ExpireOrders(expireAt)
+	if len(expired) != 1 || expired[0].ID != "gtd-1" {
+		t.Fatalf("Expected gtd-1 to expire, got %v", expired)
+	}
+	if asks := ob.asks.orders(); len(asks) != 1 || asks[0].ID != "gtc-1" {
+		t.Errorf("Expected only gtc-1 left, got %v", asks)
+	}
+}
+
+func TestGoodTillDate_ExpiredMakerNotMatched(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ob := NewOrderBook("TEST", WithClock(func() time.Time { return now }))
+	expireAt := now.Add(time.Minute)
+
+	ob.PlaceOrder(Order{ID: "gtd-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell, TimeInForce: GTD, ExpireAt: &expireAt})
+	ob.PlaceOrder(Order{ID: "gtc-1", Price: dec(101.0), Amount: dec(1.0), Side: Sell})
+
+	// The sweeper has not run yet, but matching must not hit the expired order
+	now = expireAt.Add(time.Second)
+	result, err := ob.ProcessOrder(Order{ID: "buy-1", Price: dec(101.0), Amount: dec(1.0), Side: Buy})
+	if err != nil {
+		t.Fatalf("Failed to process order: %v", err)
+	}
+	assertTradeCount(t, result.Trades, 1)
+	if result.Trades[0].SellOrderID != "gtc-1" {
+		t.Errorf("Expected match against gtc-1, got %s", result.Trades[0].SellOrderID)
+	}
+}
+
+func TestProcessOrder_PostOnly(t *testing.T) {
+	tests := []struct {
+		name          string
+		order         Order
+		expectedErr   error
+		expectedPrice Decimal
+	}{
+		{
+			name:          "Passive post-only rests",
+			order:         Order{ID: "buy-1", Price: dec(99.0), Amount: dec(1.0), Side: Buy, PostOnly: true},
+			expectedPrice: dec(99.0),
+		},
+		{
+			name:        "Crossing post-only is rejected",
+			order:       Order{ID: "buy-1", Price: dec(100.0), Amount: dec(1.0), Side: Buy, PostOnly: true},
+			expectedErr: ErrPostOnlyWouldCross,
+		},
+		{
+			name:          "Crossing buy slides below the best ask",
+			order:         Order{ID: "buy-1", Price: dec(105.0), Amount: dec(1.0), Side: Buy, PostOnly: true, PostOnlySlide: true},
+			expectedPrice: dec(99.99),
+		},
+		{
+			name:          "Crossing sell slides above the best bid",
+			order:         Order{ID: "sell-1", Price: dec(90.0), Amount: dec(1.0), Side: Sell, PostOnly: true, PostOnlySlide: true},
+			expectedPrice: dec(98.01),
+		},
+		{
+			name:        "Post-only IOC is invalid",
+			order:       Order{ID: "buy-1", Price: dec(99.0), Amount: dec(1.0), Side: Buy, PostOnly: true, TimeInForce: IOC},
+			expectedErr: ErrInvalidTimeInForce,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook("TEST", WithPrecision(2, 8))
+			ob.PlaceOrder(Order{ID: "ask", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+			ob.PlaceOrder(Order{ID: "bid", Price: dec(98.0), Amount: dec(1.0), Side: Buy})
+
+			result, err := ob.ProcessOrder(tt.order)
+			if err != tt.expectedErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			assertTradeCount(t, result.Trades, 0)
+			if result.Status != StatusNew || result.Price != tt.expectedPrice {
+				t.Errorf("Expected new order resting at %v, got %s at %v", tt.expectedPrice, result.Status, result.Price)
+			}
+		})
+	}
+}
+
+func TestStopOrders_Trigger(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	for _, order := range []Order{
+		{ID: "ask-1", Price: dec(100.0), Amount: dec(1.0), Side: Sell},
+		{ID: "ask-2", Price: dec(101.0), Amount: dec(1.0), Side: Sell},
+		{ID: "ask-3", Price: dec(102.0), Amount: dec(1.0), Side: Sell},
+		{ID: "ask-4", Price: dec(103.0), Amount: dec(5.0), Side: Sell},
+	} {
+		if err := ob.PlaceOrder(order); err != nil {
+			t.Fatalf("Failed to place order: %v", err)
+		}
+	}
+
+	// Buy stops at 100 and 101: the first one's fill at 101 cascades into the second
+	stops := []Order{
+		{ID: "stop-1", Type: Stop, StopPrice: dec(100.0), Amount: dec(1.0), Side: Buy},
+		{ID: "stop-2", Type: StopLimit, StopPrice: dec(101.0), Price: dec(102.0), Amount: dec(2.0), Side: Buy},
+	}
+	for _, order := range stops {
+		result, err := ob.ProcessOrder(order)
+		if err != nil {
+			t.Fatalf("Failed to process stop order: %v", err)
+		}
+		if result.Status != StatusUntriggered || len(result.Trades) != 0 {
+			t.Fatalf("Expected untriggered stop, got %+v", result)
+		}
+	}
+
+	result, err := ob.ProcessOrder(Order{ID: "buy-1", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	if err != nil {
+		t.Fatalf("Failed to process order: %v", err)
+	}
+	if len(result.Triggered) != 2 {
+		t.Fatalf("Expected 2 triggered stops, got %d", len(result.Triggered))
+	}
+
+	first, second := result.Triggered[0], result.Triggered[1]
+	if first.OrderID != "stop-1" || first.Status != StatusFilled || first.Trades[0].Price != dec(101.0) {
+		t.Errorf("Expected stop-1 filled at 101, got %+v", first)
+	}
+	if second.OrderID != "stop-2" || second.Status != StatusPartiallyFilled || second.RestingAmount != dec(1.0) {
+		t.Errorf("Expected stop-2 to fill 1 at 102 and rest 1, got %+v", second)
+	}
+	if bid, _ := ob.GetBestBid(); bid.ID != "stop-2" || bid.Price != dec(102.0) {
+		t.Errorf("Expected stop-2 resting at 102, got %+v", bid)
+	}
+}
+
+func TestStopOrders_DeterministicOrder(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "ask", Price: dec(100.0), Amount: dec(10.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(100.0), Amount: dec(10.0), Side: Buy})
+
+	// Both stops trigger on a print at 100, the earlier arrival must go first
+	ob.ProcessOrder(Order{ID: "sell-stop", Type: StopLimit, StopPrice: dec(100.0), Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	ob.ProcessOrder(Order{ID: "buy-stop", Type: StopLimit, StopPrice: dec(100.0), Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+
+	ob.lastPrice = dec(100.0)
+	results := ob.triggerStops()
+	if len(results) != 2 || results[0].OrderID != "sell-stop" || results[1].OrderID != "buy-stop" {
+		t.Fatalf("Expected sell-stop then buy-stop, got %+v", results)
+	}
+}
+
+func TestStopOrders_CancelAndModify(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "ask", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(99.0), Amount: dec(1.0), Side: Buy})
+
+	ob.ProcessOrder(Order{ID: "stop-1", Type: Stop, StopPrice: dec(95.0), Amount: dec(1.0), Side: Sell})
+	ob.ProcessOrder(Order{ID: "stop-2", Type: StopLimit, StopPrice: dec(95.0), Price: dec(94.0), Amount: dec(1.0), Side: Sell})
+
+	if err := ob.CancelOrder("stop-1"); err != nil {
+		t.Errorf("Failed to cancel untriggered stop: %v", err)
+	}
+	if err := ob.ModifyOrder("stop-2", dec(93.0), dec(2.0)); err != nil {
+		t.Errorf("Failed to modify untriggered stop limit: %v", err)
+	}
+	if err := ob.ModifyStopOrder("stop-2", dec(96.0), dec(93.0), dec(2.0)); err != nil {
+		t.Errorf("Failed to move stop price: %v", err)
+	}
+	if err := ob.ModifyStopOrder("bid", dec(96.0), dec(93.0), dec(2.0)); err != ErrOrderNotFound {
+		t.Errorf("Expected ErrOrderNotFound for a resting order, got %v", err)
+	}
+
+	node := ob.stops["stop-2"]
+	if node == nil || node.order.StopPrice != dec(96.0) || node.order.Price != dec(93.0) || node.order.Amount != dec(2.0) {
+		t.Fatalf("Unexpected stop after modification: %+v", node)
+	}
+
+	// A print at 99 does not reach the stop, a print at 96 does
+	ob.ProcessOrder(Order{ID: "sell-1", Price: dec(99.0), Amount: dec(0.5), Side: Sell})
+	if _, waiting := ob.stops["stop-2"]; !waiting {
+		t.Fatal("Stop triggered too early")
+	}
+	ob.PlaceOrder(Order{ID: "bid-2", Price: dec(96.0), Amount: dec(1.0), Side: Buy})
+	result, _ := ob.ProcessOrder(Order{ID: "sell-2", Price: dec(96.0), Amount: dec(1.5), Side: Sell})
+	if len(result.Triggered) != 1 || result.Triggered[0].OrderID != "stop-2" {
+		t.Fatalf("Expected stop-2 to trigger, got %+v", result.Triggered)
+	}
+	if ask, _ := ob.GetBestAsk(); ask.ID != "stop-2" || ask.Price != dec(93.0) {
+		t.Errorf("Expected stop-2 resting at 93 once triggered, got %+v", ask)
+	}
+	if err := ob.PlaceOrder(Order{ID: "x", Type: Stop, StopPrice: dec(1.0), Amount: dec(1.0), Side: Buy}); err != ErrInvalidOrderType {
+		t.Errorf("Expected ErrInvalidOrderType placing a stop directly, got %v", err)
+	}
+}
+
+func TestIcebergOrders(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "iceberg", Price: dec(100.0), Amount: dec(10.0), PeakAmount: dec(2.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "plain", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+
+	// Only the peak is displayed
+	snapshot := ob.GetOrderBookSnapshot()
+	if want := (OrderBookLevel{Price: dec(100.0), TotalAmount: dec(3.0), OrderCount: 2}); !compareOrderBookLevel(snapshot.Asks[0], want) {
+		t.Errorf("Expected level %+v, got %+v", want, snapshot.Asks[0])
+	}
+	if ask, _ := ob.GetBestAsk(); ask.ID != "iceberg" || ask.Amount != dec(2.0) || ask.PeakAmount != 0 {
+		t.Errorf("Expected best ask to show only the peak, got %+v", ask)
+	}
+
+	// Consuming the peak replenishes it behind the plain order
+	result, err := ob.ProcessOrder(Order{ID: "buy-1", Price: dec(100.0), Amount: dec(2.5), Side: Buy})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertTradeCount(t, result.Trades, 2)
+	assertTradeDetails(t, result.Trades[0], &Trade{Price: dec(100.0), Amount: dec(2.0), BuyOrderID: "buy-1", SellOrderID: "iceberg"})
+	assertTradeDetails(t, result.Trades[1], &Trade{Price: dec(100.0), Amount: dec(0.5), BuyOrderID: "buy-1", SellOrderID: "plain"})
+
+	orders := ob.asks.orders()
+	if len(orders) != 2 || orders[0].ID != "plain" || orders[1].ID != "iceberg" || orders[1].Amount != dec(8.0) {
+		t.Fatalf("Expected plain ahead of the replenished iceberg, got %+v", orders)
+	}
+	snapshot = ob.GetOrderBookSnapshot()
+	if want := (OrderBookLevel{Price: dec(100.0), TotalAmount: dec(2.5), OrderCount: 2}); !compareOrderBookLevel(snapshot.Asks[0], want) {
+		t.Errorf("Expected level %+v, got %+v", want, snapshot.Asks[0])
+	}
+
+	// A large taker sweeps through several peaks, hidden size included
+	result, _ = ob.ProcessOrder(Order{ID: "buy-2", Price: dec(100.0), Amount: dec(7.5), Side: Buy})
+	var filled Decimal
+	for _, trade := range result.Trades {
+		filled += trade.Amount
+	}
+	if filled != dec(7.5) || result.Status != StatusFilled {
+		t.Errorf("Expected 7.5 filled against hidden size, got %v (%v)", filled, result.Status)
+	}
+	assertRemainingOrder(t, ob, Order{ID: "iceberg", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	if snapshot := ob.GetOrderBookSnapshot(); snapshot.Asks[0].TotalAmount != dec(1.0) {
+		t.Errorf("Expected the last 1.0 displayed, got %v", snapshot.Asks[0].TotalAmount)
+	}
+}
+
+func TestIcebergOrders_FOKAndModify(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "iceberg", Price: dec(100.0), Amount: dec(5.0), PeakAmount: dec(1.0), Side: Sell})
+
+	// Hidden size counts towards fill-or-kill liquidity
+	result, _ := ob.ProcessOrder(Order{ID: "fok", Price: dec(100.0), Amount: dec(3.0), Side: Buy, TimeInForce: FOK})
+	if result.Status != StatusFilled || result.FilledAmount != dec(3.0) {
+		t.Errorf("Expected FOK to fill against hidden size, got %+v", result)
+	}
+
+	// Shrinking below the displayed peak also shrinks what is displayed
+	if err := ob.ModifyOrder("iceberg", dec(100.0), dec(0.5)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot := ob.GetOrderBookSnapshot(); snapshot.Asks[0].TotalAmount != dec(0.5) {
+		t.Errorf("Expected 0.5 displayed, got %v", snapshot.Asks[0].TotalAmount)
+	}
+	if err := ob.ModifyOrder("iceberg", dec(100.0), dec(4.0)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if snapshot := ob.GetOrderBookSnapshot(); snapshot.Asks[0].TotalAmount != dec(0.5) {
+		t.Errorf("Expected growing the reserve to leave 0.5 displayed, got %v", snapshot.Asks[0].TotalAmount)
+	}
+
+	tests := []Order{
+		{ID: "neg", Price: dec(100.0), Amount: dec(1.0), PeakAmount: dec(-1.0), Side: Buy},
+		{ID: "market", Type: Market, Amount: dec(1.0), PeakAmount: dec(0.5), Side: Buy},
+	}
+	for _, order := range tests {
+		if _, err := ob.ProcessOrder(order); err != ErrInvalidOrder {
+			t.Errorf("%s: expected ErrInvalidOrder, got %v", order.ID, err)
+		}
+	}
+}
+
+func TestProcessOrder_SelfTradePrevention(t *testing.T) {
+	tests := []struct {
+		name            string
+		mode            SelfTradePrevention
+		amount          Decimal
+		expectedTrades  int
+		expectedFilled  Decimal
+		expectedPrevent Decimal
+		expectedStatus  OrderStatus
+		expectedBook    []Order
+		expectedResting []*PreventedMatch
+	}{
+		{
+			name:            "Cancel newest",
+			mode:            CancelNewest,
+			amount:          dec(3.0),
+			expectedTrades:  1,
+			expectedFilled:  dec(1.0),
+			expectedPrevent: dec(2.0),
+			expectedStatus:  StatusCancelled,
+			expectedBook: []Order{
+				{ID: "own", Price: dec(101.0), Amount: dec(1.0), Side: Sell},
+				{ID: "other-2", Price: dec(102.0), Amount: dec(1.0), Side: Sell},
+			},
+		},
+		{
+			name:           "Cancel oldest",
+			mode:           CancelOldest,
+			amount:         dec(3.0),
+			expectedTrades: 2,
+			expectedFilled: dec(2.0),
+			expectedStatus: StatusPartiallyFilled,
+			expectedBook: []Order{
+				{ID: "buy", Price: dec(102.0), Amount: dec(1.0), Side: Buy},
+			},
+			expectedResting: []*PreventedMatch{{RestingOrderID: "own", Amount: dec(1.0)}},
+		},
+		{
+			name:            "Cancel both",
+			mode:            CancelBoth,
+			amount:          dec(3.0),
+			expectedTrades:  1,
+			expectedFilled:  dec(1.0),
+			expectedPrevent: dec(2.0),
+			expectedStatus:  StatusCancelled,
+			expectedBook: []Order{
+				{ID: "other-2", Price: dec(102.0), Amount: dec(1.0), Side: Sell},
+			},
+			expectedResting: []*PreventedMatch{{RestingOrderID: "own", Amount: dec(1.0)}},
+		},
+		{
+			name:            "Decrement and cancel",
+			mode:            DecrementAndCancel,
+			amount:          dec(3.0),
+			expectedTrades:  2,
+			expectedFilled:  dec(2.0),
+			expectedPrevent: dec(1.0),
+			expectedStatus:  StatusCancelled,
+			expectedBook:    nil,
+			expectedResting: []*PreventedMatch{{RestingOrderID: "own", Amount: dec(1.0)}},
+		},
+		{
+			name:            "Decrement smaller incoming",
+			mode:            DecrementAndCancel,
+			amount:          dec(1.5),
+			expectedTrades:  1,
+			expectedFilled:  dec(1.0),
+			expectedPrevent: dec(0.5),
+			expectedStatus:  StatusCancelled,
+			expectedBook: []Order{
+				{ID: "own", Price: dec(101.0), Amount: dec(0.5), Side: Sell},
+				{ID: "other-2", Price: dec(102.0), Amount: dec(1.0), Side: Sell},
+			},
+			expectedResting: []*PreventedMatch{{RestingOrderID: "own", Amount: dec(0.5)}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook("TEST", WithSelfTradePrevention(tt.mode))
+			ob.PlaceOrder(Order{ID: "other-1", Account: "bob", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+			ob.PlaceOrder(Order{ID: "own", Account: "alice", Price: dec(101.0), Amount: dec(1.0), Side: Sell})
+			ob.PlaceOrder(Order{ID: "other-2", Price: dec(102.0), Amount: dec(1.0), Side: Sell})
+
+			result, err := ob.ProcessOrder(Order{ID: "buy", Account: "alice", Price: dec(102.0), Amount: tt.amount, Side: Buy})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			assertTradeCount(t, result.Trades, tt.expectedTrades)
+			for _, trade := range result.Trades {
+				if trade.SellOrderID == "own" {
+					t.Errorf("Own orders traded with each other: %+v", trade)
+				}
+			}
+			if result.FilledAmount != tt.expectedFilled || result.PreventedAmount != tt.expectedPrevent || result.Status != tt.expectedStatus {
+				t.Errorf("Expected filled %v, prevented %v, status %v, got %+v",
+					tt.expectedFilled, tt.expectedPrevent, tt.expectedStatus, result)
+			}
+			if result.FilledAmount+result.RestingAmount+result.CancelledAmount != tt.amount {
+				t.Errorf("Amounts do not add up: %+v", result)
+			}
+			if !reflect.DeepEqual(result.Prevented, tt.expectedResting) {
+				t.Errorf("Expected prevented resting orders %+v, got %+v", tt.expectedResting, result.Prevented)
+			}
+
+			var remaining []Order
+			for _, o := range append(ob.asks.orders(), ob.bids.orders()...) {
+				remaining = append(remaining, Order{ID: o.ID, Price: o.Price, Amount: o.Amount, Side: o.Side})
+			}
+			if !reflect.DeepEqual(remaining, tt.expectedBook) {
+				t.Errorf("Expected book %+v, got %+v", tt.expectedBook, remaining)
+			}
+		})
+	}
+}
+
+func TestSelfTradePrevention_OrderOverrideAndFOK(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "own", Account: "alice", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "other", Account: "bob", Price: dec(100.0), Amount: dec(1.0), Side: Sell})
+
+	// The book cancels the newest order, so FOK cannot be filled
+	result, _ := ob.ProcessOrder(Order{ID: "fok", Account: "alice", Price: dec(100.0), Amount: dec(1.0), Side: Buy, TimeInForce: FOK})
+	if len(result.Trades) != 0 || result.Status != StatusCancelled {
+		t.Errorf("Expected FOK to be killed, got %+v", result)
+	}
+
+	// Cancelling the resting order instead lets it fill against the other account
+	result, _ = ob.ProcessOrder(Order{ID: "fok-2", Account: "alice", Price: dec(100.0), Amount: dec(1.0), Side: Buy,
+		TimeInForce: FOK, SelfTradePrevention: CancelOldest})
+	if result.Status != StatusFilled || len(result.Prevented) != 1 || result.Trades[0].SellOrderID != "other" {
+		t.Errorf("Expected fill against other after cancelling own order, got %+v", result)
+	}
+
+	if _, err := ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy, SelfTradePrevention: "IGNORE"}); err != ErrInvalidOrder {
+		t.Errorf("Expected ErrInvalidOrder for unknown mode, got %v", err)
+	}
+}
+
+func TestHaltAndResume(t *testing.T) {
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithJournal(j))
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	ob.PlaceOrder(Order{ID: "bid-2", Price: dec(99.0), Amount: dec(1.0), Side: Buy})
+
+	if err := ob.Halt(); err != nil || !ob.Halted() {
+		t.Fatalf("Expected the book to halt, got %v", err)
+	}
+	if err := ob.PlaceOrder(Order{Price: dec(101.0), Amount: dec(1.0), Side: Sell}); err != ErrBookHalted {
+		t.Errorf("Expected ErrBookHalted placing, got %v", err)
+	}
+	if _, err := ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Sell}); err != ErrBookHalted {
+		t.Errorf("Expected ErrBookHalted processing, got %v", err)
+	}
+	if err := ob.ModifyOrder("bid", dec(100.0), dec(2.0)); err != ErrBookHalted {
+		t.Errorf("Expected ErrBookHalted modifying, got %v", err)
+	}
+	if err := ob.CancelOrder("bid-2"); err != nil {
+		t.Errorf("Expected cancels to work while halted, got %v", err)
+	}
+
+	// The halt survives a snapshot and a journal replay
+	var buf bytes.Buffer
+	ob.WriteSnapshot(&buf)
+	restored := NewOrderBook("TEST")
+	restored.LoadSnapshot(&buf)
+	replayed := NewOrderBook("TEST")
+	for _, record := range j.records {
+		replayed.Replay(record)
+	}
+	if !restored.Halted() || !replayed.Halted() {
+		t.Error("Expected the halt to be restored")
+	}
+
+	ob.Resume()
+	if _, err := ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Sell}); err != nil {
+		t.Errorf("Expected trading to resume, got %v", err)
+	}
+}
+
 func compareOrderBookLevel(a, b OrderBookLevel) bool {
 	return a.Price == b.Price &&
 		a.TotalAmount == b.TotalAmount &&
@@ -954,7 +1905,7 @@ func assertTradeDetails(t *testing.T, trade *Trade, expected *Trade) {
 }
 
 func assertEmptyOrderBook(t *testing.T, ob *OrderBook) {
-	if len(ob.bids) != 0 || len(ob.asks) != 0 {
+	if len(ob.bids.orders()) != 0 || len(ob.asks.orders()) != 0 {
 		t.Error("Expected empty orderbook after complete match")
 	}
 }
@@ -963,9 +1914,9 @@ func assertRemainingOrder(t *testing.T, ob *OrderBook, expected Order) {
 	var orders []Order
 	switch expected.Side {
 	case Buy:
-		orders = ob.bids
+		orders = ob.bids.orders()
 	case Sell:
-		orders = ob.asks
+		orders = ob.asks.orders()
 	}
 
 	if len(orders) != 1 {
@@ -990,3 +1941,45 @@ func assertRemainingOrder(t *testing.T, ob *OrderBook, expected Order) {
 			expected.ID, remaining.ID)
 	}
 }
+
+// orders flattens a book side into its resting orders in priority order.
+func (s *bookSide) orders() []Order {
+	var orders []Order
+	s.each(func(level *priceLevel) bool {
+		for n := level.head; n != nil; n = n.next {
+			orders = append(orders, n.order)
+		}
+		return true
+	})
+	return orders
+}
+
+// dec converts a float literal to a Decimal for readable test tables.
+func dec(f float64) Decimal {
+	return DecimalFromFloat(f)
+}
+
+func TestCloseAndResume(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+
+	if err := ob.Close(); err != nil || ob.Status() != BookClosed {
+		t.Fatalf("Expected the book to close, got %v", err)
+	}
+	if err := ob.PlaceOrder(Order{Price: dec(101.0), Amount: dec(1.0), Side: Sell}); err != ErrBookClosed {
+		t.Errorf("Expected ErrBookClosed placing, got %v", err)
+	}
+	if err := ob.ModifyOrder("bid", dec(100.0), dec(2.0)); err != ErrBookClosed {
+		t.Errorf("Expected ErrBookClosed modifying, got %v", err)
+	}
+
+	// A manual halt replaces the close
+	ob.Halt()
+	if status := ob.Status(); status != BookHalted {
+		t.Errorf("Expected the book to be halted, got %s", status)
+	}
+	ob.Resume()
+	if _, err := ob.ProcessOrder(Order{Price: dec(100.0), Amount: dec(1.0), Side: Sell}); err != nil || ob.Status() != BookOpen {
+		t.Errorf("Expected trading to resume, got %v", err)
+	}
+}
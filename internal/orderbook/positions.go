@@ -0,0 +1,89 @@
+package orderbook
+
+// Position returns the net amount an account has bought in the book through
+// trades, negative if it has sold more than it bought.
+func (ob *OrderBook) Position(account string) Decimal {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.positions[account]
+}
+
+// OpenOrders returns how many resting and untriggered stop orders an account
+// has in the book.
+func (ob *OrderBook) OpenOrders(account string) int {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return len(ob.openOrders[account])
+}
+
+// OpenExposure returns the amount left of the resting and untriggered stop
+// orders an account has on a side of the book, hidden reserves included.
+func (ob *OrderBook) OpenExposure(account string, side Side) Decimal {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.openExposure(account, side)
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) openExposure(account string, side Side) Decimal {
+	var total Decimal
+	for id := range ob.openOrders[account] {
+		node, ok := ob.orders[id]
+		if !ok {
+			node = ob.stops[id]
+		}
+		if node != nil && node.order.Side == side {
+			total += node.order.Amount
+		}
+	}
+	return total
+}
+
+// LastPrice returns the price of the most recent trade, or zero if the book
+// has not traded yet.
+func (ob *OrderBook) LastPrice() Decimal {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.lastPrice
+}
+
+// move adds the amount of a trade to the position of the buyer and takes it
+// from the seller's. Orders without an account have no position.
+// Must be called with the lock held.
+func (ob *OrderBook) move(trade *Trade, taker, maker *Order) {
+	for _, order := range []*Order{taker, maker} {
+		if order.Account == "" {
+			continue
+		}
+		if order.Side == Buy {
+			ob.positions[order.Account] += trade.Amount
+		} else {
+			ob.positions[order.Account] -= trade.Amount
+		}
+	}
+}
+
+// trackOpen adds an order to the open orders of its account, or takes it
+// out of them.
+// Must be called with the lock held.
+func (ob *OrderBook) trackOpen(order *Order, open bool) {
+	if order.Account == "" {
+		return
+	}
+	ids := ob.openOrders[order.Account]
+	if !open {
+		if delete(ids, order.ID); len(ids) == 0 {
+			delete(ob.openOrders, order.Account)
+		}
+		return
+	}
+	if ids == nil {
+		ids = make(map[string]struct{})
+		ob.openOrders[order.Account] = ids
+	}
+	ids[order.ID] = struct{}{}
+}
@@ -0,0 +1,56 @@
+package orderbook
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPositions(t *testing.T) {
+	ob := NewOrderBook("TEST", WithJournal(&memJournal{}))
+	ob.PlaceOrder(Order{ID: "ask", Price: dec(100.0), Amount: dec(2.0), Side: Sell, Account: "bob"})
+	ob.ProcessOrder(Order{ID: "stop", Type: Stop, StopPrice: dec(90.0), Amount: dec(1.0), Side: Sell, Account: "bob"})
+	ob.ProcessOrder(Order{Price: dec(101.0), Amount: dec(0.5), Side: Buy, Account: "alice"})
+	ob.ProcessOrder(Order{Price: dec(101.0), Amount: dec(0.25), Side: Buy})
+
+	check := func(step string, book *OrderBook) {
+		t.Helper()
+		if p := book.Position("alice"); p != dec(0.5) {
+			t.Errorf("%s: expected alice long 0.5, got %v", step, p)
+		}
+		if p := book.Position("bob"); p != dec(-0.75) {
+			t.Errorf("%s: expected bob short 0.75, got %v", step, p)
+		}
+		if n := book.OpenOrders("bob"); n != 2 {
+			t.Errorf("%s: expected bob to have 2 open orders, got %d", step, n)
+		}
+		if n := book.OpenOrders("alice"); n != 0 {
+			t.Errorf("%s: expected alice to have no open orders, got %d", step, n)
+		}
+		if price := book.LastPrice(); price != dec(100.0) {
+			t.Errorf("%s: expected last price 100, got %v", step, price)
+		}
+	}
+	check("Live", ob)
+
+	var buf bytes.Buffer
+	if _, err := ob.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to write snapshot: %v", err)
+	}
+	restored := NewOrderBook("TEST")
+	if _, err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	check("Restored", restored)
+
+	if order, err := ob.GetOrder("stop"); err != nil || order.StopPrice != dec(90.0) {
+		t.Errorf("Expected the stop order, got %+v, %v", order, err)
+	}
+	ob.CancelOrder("stop")
+	ob.CancelOrder("ask")
+	if n := ob.OpenOrders("bob"); n != 0 {
+		t.Errorf("Expected bob to have no open orders after cancelling, got %d", n)
+	}
+	if _, err := ob.GetOrder("ask"); err != ErrOrderNotFound {
+		t.Errorf("Expected ErrOrderNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package orderbook
+
+// PreTradeCheck vets an order before the book takes it: a new order, with
+// old nil, or an order as it would be after a modification, with old as it
+// is now. It runs with the book locked, once the order passed the book's own
+// checks, so that no other order gets in between the check and the order
+// reaching the book. It must read the book through view only. An error
+// rejects the order and is returned as is, without an EventRejected.
+type PreTradeCheck func(order *Order, old *Order, view PreTradeView) error
+
+// PreTradeView is the book as a PreTradeCheck sees it. It must not be kept
+// after the check returns.
+type PreTradeView struct {
+	ob *OrderBook
+}
+
+// GetBestBid returns the highest bid, or ErrNoOrders.
+func (v PreTradeView) GetBestBid() (Order, error) {
+	return v.ob.bestOrder(v.ob.bids)
+}
+
+// GetBestAsk returns the lowest ask, or ErrNoOrders.
+func (v PreTradeView) GetBestAsk() (Order, error) {
+	return v.ob.bestOrder(v.ob.asks)
+}
+
+// LastPrice returns the price of the most recent trade, zero if none.
+func (v PreTradeView) LastPrice() Decimal {
+	return v.ob.lastPrice
+}
+
+// OpenOrders returns how many resting and stop orders an account has.
+func (v PreTradeView) OpenOrders(account string) int {
+	return len(v.ob.openOrders[account])
+}
+
+// OpenExposure returns the amount left of the resting and stop orders an
+// account has on a side.
+func (v PreTradeView) OpenExposure(account string, side Side) Decimal {
+	return v.ob.openExposure(account, side)
+}
+
+// Position returns the net amount an account has bought.
+func (v PreTradeView) Position(account string) Decimal {
+	return v.ob.positions[account]
+}
+
+// preTrade runs the pre-trade check on an order, except for commands
+// replayed from the journal, which were checked when first applied.
+// Must be called with the lock held.
+func (ob *OrderBook) preTrade(order *Order, old *Order) error {
+	if ob.preTradeCheck == nil || ob.replaying {
+		return nil
+	}
+	checked := *order // The check cannot change the order it vets
+	return ob.preTradeCheck(&checked, old, PreTradeView{ob: ob})
+}
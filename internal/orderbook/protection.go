@@ -0,0 +1,226 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrInvalidPriceProtection = errors.New("Invalid price protection")
+
+// PriceProtection limits how far and how fast trade prices can move. A zero
+// field turns its check off.
+//
+// Price bands stop an incoming order from trading more than BandBps away from
+// the last trade price; what is left of it is cancelled. The circuit breaker
+// halts the book when trades would move the price by more than BreakerBps
+// within BreakerWindow. The halt lasts Cooldown, then the book collects
+// orders in a reopening auction for AuctionDuration before it uncrosses and
+// opens again.
+type PriceProtection struct {
+	BandBps         int64
+	BreakerBps      int64
+	BreakerWindow   time.Duration
+	Cooldown        time.Duration
+	AuctionDuration time.Duration
+}
+
+// priceProtectionJSON is how PriceProtection is written in JSON, with
+// durations such as "90s".
+type priceProtectionJSON struct {
+	BandBps         int64  `json:"band_bps,omitempty"`
+	BreakerBps      int64  `json:"breaker_bps,omitempty"`
+	BreakerWindow   string `json:"breaker_window,omitempty"`
+	Cooldown        string `json:"cooldown,omitempty"`
+	AuctionDuration string `json:"auction_duration,omitempty"`
+}
+
+func (p PriceProtection) MarshalJSON() ([]byte, error) {
+	encoded := priceProtectionJSON{BandBps: p.BandBps, BreakerBps: p.BreakerBps}
+	for _, d := range []struct {
+		dst *string
+		src time.Duration
+	}{{&encoded.BreakerWindow, p.BreakerWindow}, {&encoded.Cooldown, p.Cooldown}, {&encoded.AuctionDuration, p.AuctionDuration}} {
+		if d.src != 0 {
+			*d.dst = d.src.String()
+		}
+	}
+	return json.Marshal(encoded)
+}
+
+func (p *PriceProtection) UnmarshalJSON(data []byte) error {
+	var decoded priceProtectionJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	protection := PriceProtection{BandBps: decoded.BandBps, BreakerBps: decoded.BreakerBps}
+	for _, d := range []struct {
+		dst *time.Duration
+		src string
+	}{{&protection.BreakerWindow, decoded.BreakerWindow}, {&protection.Cooldown, decoded.Cooldown}, {&protection.AuctionDuration, decoded.AuctionDuration}} {
+		if d.src == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.src)
+		if err != nil {
+			return ErrInvalidPriceProtection
+		}
+		*d.dst = duration
+	}
+	*p = protection
+	return nil
+}
+
+// Validate checks that no setting is negative, that bands stay within 100%
+// and that a circuit breaker has a window.
+func (p PriceProtection) Validate() error {
+	if p.BandBps < 0 || p.BandBps > maxFeeBps || p.BreakerBps < 0 || p.BreakerBps > maxFeeBps {
+		return ErrInvalidPriceProtection
+	}
+	if p.BreakerWindow < 0 || p.Cooldown < 0 || p.AuctionDuration < 0 {
+		return ErrInvalidPriceProtection
+	}
+	if p.BreakerBps > 0 && p.BreakerWindow == 0 {
+		return ErrInvalidPriceProtection
+	}
+	return nil
+}
+
+// PriceProtection returns the price bands and circuit breaker of the book.
+func (ob *OrderBook) PriceProtection() PriceProtection {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.protection
+}
+
+// SetPriceProtection replaces the price bands and circuit breaker of the
+// book. Returns ErrInvalidPriceProtection if the settings fail Validate.
+func (ob *OrderBook) SetPriceProtection(protection PriceProtection) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	ob.at = ob.now()
+	return ob.setPriceProtection(protection)
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) setPriceProtection(protection PriceProtection) error {
+	if err := protection.Validate(); err != nil {
+		return err
+	}
+	if err := ob.record(Command{Type: CommandSetProtection, Protection: &protection}); err != nil {
+		return err
+	}
+	ob.protection = protection
+	return nil
+}
+
+// priceBounds returns how far an order of a side may trade right now: the
+// price band around the last trade price, and the price at which the circuit
+// breaker trips given the trades of its window and the best opposite price,
+// where a sweep would start. The flags are false when there is no bound.
+// Must be called with the lock held.
+func (ob *OrderBook) priceBounds(side Side, matchingSide *bookSide) (band Decimal, banded bool, breaker Decimal, breakable bool) {
+	if ob.protection.BandBps > 0 && ob.lastPrice > 0 {
+		band, banded = moveFrom(ob.lastPrice, side, ob.protection.BandBps)
+	}
+
+	if ob.protection.BreakerBps > 0 {
+		ob.window.evict(ob.at.Add(-ob.protection.BreakerWindow))
+		if best := matchingSide.best(); best != nil {
+			// Buys push the price up from the lowest price of the window,
+			// sells down from the highest
+			from := best.price
+			if side == Buy {
+				if low, ok := ob.window.low(); ok {
+					from = from.Min(low)
+				}
+			} else if high, ok := ob.window.high(); ok && high > from {
+				from = high
+			}
+			breaker, breakable = moveFrom(from, side, ob.protection.BreakerBps)
+		}
+	}
+	return band, banded, breaker, breakable
+}
+
+// moveFrom returns the price bps basis points above price for buys, or below
+// it for sells, and false if it cannot be computed.
+func moveFrom(price Decimal, side Side, bps int64) (Decimal, bool) {
+	move, ok := price.MulChecked(NewDecimal(bps, 4))
+	if !ok {
+		return 0, false
+	}
+	if side == Buy {
+		if move > math.MaxInt64-price {
+			return 0, false
+		}
+		return price + move, true
+	}
+	return price - move, true
+}
+
+// tripBreaker halts the book for the cooldown of the circuit breaker and
+// starts a new window for when it reopens.
+// Must be called with the lock held.
+func (ob *OrderBook) tripBreaker() {
+	ob.window.reset()
+	ob.setStatus(BookHalted, ob.at.Add(ob.protection.Cooldown))
+}
+
+// pricePoint is a trade price and when it traded.
+type pricePoint struct {
+	at    time.Time
+	price Decimal
+}
+
+// priceWindow tracks the lowest and highest trade prices of a sliding time
+// window, each in a queue that only keeps the prices that can still become
+// the extreme once older ones leave the window.
+type priceWindow struct {
+	lows  []pricePoint // Increasing prices, oldest first
+	highs []pricePoint // Decreasing prices, oldest first
+}
+
+// add records a trade price.
+func (w *priceWindow) add(at time.Time, price Decimal) {
+	for len(w.lows) > 0 && w.lows[len(w.lows)-1].price >= price {
+		w.lows = w.lows[:len(w.lows)-1]
+	}
+	w.lows = append(w.lows, pricePoint{at: at, price: price})
+
+	for len(w.highs) > 0 && w.highs[len(w.highs)-1].price <= price {
+		w.highs = w.highs[:len(w.highs)-1]
+	}
+	w.highs = append(w.highs, pricePoint{at: at, price: price})
+}
+
+// evict drops the prices that traded before a time.
+func (w *priceWindow) evict(before time.Time) {
+	for len(w.lows) > 0 && w.lows[0].at.Before(before) {
+		w.lows = w.lows[1:]
+	}
+	for len(w.highs) > 0 && w.highs[0].at.Before(before) {
+		w.highs = w.highs[1:]
+	}
+}
+
+func (w *priceWindow) low() (Decimal, bool) {
+	if len(w.lows) == 0 {
+		return 0, false
+	}
+	return w.lows[0].price, true
+}
+
+func (w *priceWindow) high() (Decimal, bool) {
+	if len(w.highs) == 0 {
+		return 0, false
+	}
+	return w.highs[0].price, true
+}
+
+func (w *priceWindow) reset() {
+	w.lows, w.highs = nil, nil
+}
@@ -0,0 +1,203 @@
+package orderbook
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPriceBands(t *testing.T) {
+	ob := NewOrderBook("TEST", WithPriceProtection(PriceProtection{BandBps: 500}))
+	for _, price := range []float64{100.0, 104.0, 106.0, 110.0} {
+		ob.PlaceOrder(Order{Price: dec(price), Amount: dec(1.0), Side: Sell})
+	}
+
+	tests := []struct {
+		name              string
+		order             Order
+		expectedFilled    Decimal
+		expectedCancelled Decimal
+		expectedResting   Decimal
+	}{
+		{"No reference yet", Order{Price: dec(100.0), Amount: dec(1.0), Side: Buy}, dec(1.0), 0, 0},
+		{"Market sweep stops at the band", Order{Type: Market, Amount: dec(3.0), Side: Buy}, dec(1.0), dec(2.0), 0},
+		{"Limit beyond the band is cut", Order{Price: dec(120.0), Amount: dec(2.0), Side: Buy}, dec(1.0), dec(1.0), 0},
+		{"Limit inside the band rests", Order{Price: dec(108.0), Amount: dec(1.0), Side: Buy}, 0, 0, dec(1.0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ob.ProcessOrder(tt.order)
+			if err != nil {
+				t.Fatalf("Failed to process: %v", err)
+			}
+			if result.FilledAmount != tt.expectedFilled || result.CancelledAmount != tt.expectedCancelled || result.RestingAmount != tt.expectedResting {
+				t.Errorf("Expected %v filled, %v cancelled, %v resting, got %+v",
+					tt.expectedFilled, tt.expectedCancelled, tt.expectedResting, result)
+			}
+		})
+	}
+
+	// Fill or kill counts only what the band lets it reach
+	ob.PlaceOrder(Order{Price: dec(95.0), Amount: dec(1.0), Side: Buy})
+	result, _ := ob.ProcessOrder(Order{Price: dec(90.0), Amount: dec(2.0), Side: Sell, TimeInForce: FOK})
+	if result.FilledAmount != 0 || result.CancelledAmount != dec(2.0) {
+		t.Errorf("Expected the FOK to be killed, got %+v", result)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	j := &memJournal{}
+	protection := PriceProtection{BreakerBps: 1000, BreakerWindow: time.Minute, Cooldown: 5 * time.Minute, AuctionDuration: 2 * time.Minute}
+	ob := NewOrderBook("TEST", WithClock(clock), WithJournal(j))
+	if err := ob.SetPriceProtection(protection); err != nil {
+		t.Fatalf("Failed to set price protection: %v", err)
+	}
+	for _, price := range []float64{100.0, 105.0, 112.0, 120.0} {
+		ob.PlaceOrder(Order{Price: dec(price), Amount: dec(1.0), Side: Sell})
+	}
+	ob.ProcessOrder(Order{Type: Market, Amount: dec(1.0), Side: Buy})
+
+	// 112 is more than 10% above the 100 traded within the window
+	now = now.Add(10 * time.Second)
+	result, err := ob.ProcessOrder(Order{Type: Market, Amount: dec(3.0), Side: Buy})
+	if err != nil || result.FilledAmount != dec(1.0) || result.CancelledAmount != dec(2.0) {
+		t.Fatalf("Expected the sweep to stop at 105, got %+v, %v", result, err)
+	}
+	halt := ob.StatusInfo()
+	if halt.Status != BookHalted || halt.Until == nil || !halt.Until.Equal(now.Add(5*time.Minute)) {
+		t.Fatalf("Expected a five minute halt, got %+v", halt)
+	}
+	if err := ob.PlaceOrder(Order{Price: dec(115.0), Amount: dec(1.0), Side: Buy}); err != ErrBookHalted {
+		t.Errorf("Expected ErrBookHalted, got %v", err)
+	}
+
+	// The halt survives a snapshot
+	var buf bytes.Buffer
+	ob.WriteSnapshot(&buf)
+	restored := NewOrderBook("TEST", WithClock(clock))
+	if _, err := restored.LoadSnapshot(&buf); err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if info := restored.StatusInfo(); !reflect.DeepEqual(info, halt) || restored.PriceProtection() != protection {
+		t.Errorf("Expected the halt to be restored, got %+v", info)
+	}
+
+	// After the cooldown orders collect in the reopening auction
+	now = now.Add(5 * time.Minute)
+	if err := ob.PlaceOrder(Order{ID: "bid", Price: dec(115.0), Amount: dec(1.0), Side: Buy}); err != nil {
+		t.Fatalf("Expected the auction to take orders, got %v", err)
+	}
+	if status := ob.Status(); status != BookAuction {
+		t.Fatalf("Expected an auction, got %s", status)
+	}
+	if _, err := ob.ProcessOrder(Order{Type: Market, Amount: dec(1.0), Side: Buy}); err != ErrAuctionOrder {
+		t.Errorf("Expected ErrAuctionOrder, got %v", err)
+	}
+	if best, _ := ob.GetBestBid(); best.ID != "bid" {
+		t.Errorf("Expected the bid to rest crossed, got %+v", best)
+	}
+
+	// The auction uncrosses at 112, the crossing price nearest the last trade
+	sub := ob.Subscribe()
+	defer sub.Close()
+	now = now.Add(2 * time.Minute)
+	ob.Advance(now)
+	if status := ob.Status(); status != BookOpen {
+		t.Fatalf("Expected the book to open, got %s", status)
+	}
+	events := receive(t, sub, 4)
+	if trade := events[0].Trade; trade == nil || trade.Price != dec(112.0) || trade.TakerOrderID != "bid" {
+		t.Errorf("Expected a trade at 112, got %+v", events[0])
+	}
+	if events[3].Type != EventStatusChanged || events[3].Status != BookOpen {
+		t.Errorf("Expected the book to announce it opened, got %+v", events[3])
+	}
+
+	// Replaying the journal ends in the same place
+	replayed := NewOrderBook("TEST")
+	for _, record := range j.records {
+		if err := replayed.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+	if replayed.Status() != BookOpen || replayed.LastPrice() != dec(112.0) || !reflect.DeepEqual(replayed.GetOrderBookSnapshot().Asks, ob.GetOrderBookSnapshot().Asks) {
+		t.Errorf("Replayed book differs: %s at %v", replayed.Status(), replayed.LastPrice())
+	}
+}
+
+func TestAuction_Equilibrium(t *testing.T) {
+	type quote struct {
+		side   Side
+		price  float64
+		amount float64
+	}
+	tests := []struct {
+		name              string
+		lastPrice         float64
+		quotes            []quote
+		expectedPrice     Decimal
+		expectedVolume    Decimal
+		expectedImbalance Decimal
+		expectedSide      Side
+	}{
+		{"Not crossed", 0, []quote{{Buy, 99, 1}, {Sell, 100, 1}}, 0, 0, 0, ""},
+		{"Most volume", 0, []quote{{Buy, 102, 1}, {Buy, 101, 2}, {Sell, 100, 1}, {Sell, 101, 1}, {Sell, 103, 5}}, dec(101.0), dec(2.0), dec(1.0), Buy},
+		{"Least imbalance", 0, []quote{{Buy, 102, 3}, {Buy, 100, 1}, {Sell, 100, 2}, {Sell, 102, 1}}, dec(102.0), dec(3.0), 0, ""},
+		{"Sell imbalance", 0, []quote{{Buy, 101, 1}, {Sell, 100, 3}}, dec(100.0), dec(1.0), dec(2.0), Sell},
+		{"Nearest the last trade", 101.5, []quote{{Buy, 102, 1}, {Sell, 100, 1}}, dec(102.0), dec(1.0), 0, ""},
+		{"Lowest without a last trade", 0, []quote{{Buy, 102, 1}, {Sell, 100, 1}}, dec(100.0), dec(1.0), 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ob := NewOrderBook("TEST")
+			ob.status = BookAuction
+			ob.lastPrice = dec(tt.lastPrice)
+			for _, q := range tt.quotes {
+				if err := ob.PlaceOrder(Order{Price: dec(q.price), Amount: dec(q.amount), Side: q.side}); err != nil {
+					t.Fatalf("Failed to place: %v", err)
+				}
+			}
+			got := ob.equilibrium(ob.at)
+			if got.Price != tt.expectedPrice || got.Volume != tt.expectedVolume || got.Imbalance != tt.expectedImbalance || got.ImbalanceSide != tt.expectedSide {
+				t.Errorf("Expected %v x %v with imbalance %v %s, got %+v",
+					tt.expectedPrice, tt.expectedVolume, tt.expectedImbalance, tt.expectedSide, got)
+			}
+		})
+	}
+}
+
+func TestPriceProtection_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		protection PriceProtection
+		err        error
+	}{
+		{"Off", PriceProtection{}, nil},
+		{"Band", PriceProtection{BandBps: 500}, nil},
+		{"Breaker", PriceProtection{BreakerBps: 1000, BreakerWindow: time.Minute}, nil},
+		{"Breaker without window", PriceProtection{BreakerBps: 1000}, ErrInvalidPriceProtection},
+		{"Negative band", PriceProtection{BandBps: -1}, ErrInvalidPriceProtection},
+		{"Band over 100%", PriceProtection{BandBps: 10001}, ErrInvalidPriceProtection},
+		{"Negative cooldown", PriceProtection{Cooldown: -time.Second}, ErrInvalidPriceProtection},
+	}
+
+	for _, tt := range tests {
+		if err := tt.protection.Validate(); err != tt.err {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+
+	var decoded PriceProtection
+	if err := json.Unmarshal([]byte(`{"breaker_bps": 1000, "breaker_window": "1m", "cooldown": "30s"}`), &decoded); err != nil {
+		t.Fatalf("Failed to decode: %v", err)
+	}
+	if decoded != (PriceProtection{BreakerBps: 1000, BreakerWindow: time.Minute, Cooldown: 30 * time.Second}) {
+		t.Errorf("Unexpected decoded protection %+v", decoded)
+	}
+}
@@ -0,0 +1,476 @@
+package orderbook
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"maps"
+	"slices"
+	"time"
+)
+
+var (
+	ErrInvalidSnapshot = errors.New("Invalid snapshot")
+	ErrSnapshotVersion = errors.New("Unsupported snapshot version")
+	ErrBookNotEmpty    = errors.New("Snapshots can only be loaded into an empty book")
+)
+
+const (
+	snapshotMagic       = "OBSN"
+	snapshotVersion     = uint16(1)
+	snapshotHeaderSize  = len(snapshotMagic) + 2
+	snapshotTrailerSize = 4
+)
+
+var snapshotCRC = crc32.MakeTable(crc32.Castagnoli)
+
+// WriteSnapshot writes the full state of the book to w: every resting and
+// untriggered stop order with its queue position, the sequence counters and
+// the number of journal records the state includes, which it returns.
+//
+// The format is the magic "OBSN", a 2 byte little endian version, the body,
+// and a CRC-32C of everything before it. Integers in the body are varints.
+func (ob *OrderBook) WriteSnapshot(w io.Writer) (uint64, error) {
+	ob.mu.RLock()
+	e := &snapshotEncoder{buf: []byte(snapshotMagic)}
+	e.buf = binary.LittleEndian.AppendUint16(e.buf, snapshotVersion)
+
+	e.string(ob.Tag)
+	e.uvarint(ob.lsn)
+	e.uvarint(ob.arrivals)
+	e.uvarint(ob.eventSeq)
+	e.varint(int64(ob.lastPrice))
+	e.string(string(ob.status))
+	e.uvarint(ob.trades)
+	e.fees(&ob.fees, ob.feeTotals)
+	e.balances(ob.balances)
+	e.positions(ob.positions)
+	e.time(ob.until)
+	e.protection(&ob.protection)
+	e.window(&ob.window)
+	e.string(string(ob.auction))
+	for _, side := range ob.snapshotSides() {
+		e.side(side)
+	}
+	lsn := ob.lsn
+	ob.mu.RUnlock()
+
+	e.buf = binary.LittleEndian.AppendUint32(e.buf, crc32.Checksum(e.buf, snapshotCRC))
+	if _, err := w.Write(e.buf); err != nil {
+		return 0, err
+	}
+	return lsn, nil
+}
+
+// LoadSnapshot restores a book from a snapshot written by WriteSnapshot and
+// returns the number of journal records it includes; replaying the journal
+// after that record brings the book up to date. The book must be empty.
+func (ob *OrderBook) LoadSnapshot(r io.Reader) (uint64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) < snapshotHeaderSize+snapshotTrailerSize || string(data[:len(snapshotMagic)]) != snapshotMagic {
+		return 0, ErrInvalidSnapshot
+	}
+	body, trailer := data[:len(data)-snapshotTrailerSize], data[len(data)-snapshotTrailerSize:]
+	if crc32.Checksum(body, snapshotCRC) != binary.LittleEndian.Uint32(trailer) {
+		return 0, ErrInvalidSnapshot
+	}
+	version := binary.LittleEndian.Uint16(body[len(snapshotMagic):])
+	if version != snapshotVersion {
+		return 0, ErrSnapshotVersion
+	}
+
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	if len(ob.orders) > 0 || len(ob.stops) > 0 || ob.arrivals > 0 {
+		return 0, ErrBookNotEmpty
+	}
+
+	d := &snapshotDecoder{buf: body[snapshotHeaderSize:]}
+	d.string() // Tag, kept for inspection
+	lsn := d.uvarint()
+	arrivals := d.uvarint()
+	eventSeq := d.uvarint()
+	lastPrice := Decimal(d.varint())
+	status := BookStatus(d.string())
+	trades := d.uvarint()
+	fees, feeTotals := d.fees()
+	balances := d.balances()
+	positions := d.positions()
+	until := d.time()
+	protection := d.protection()
+	window := d.window()
+	auction := AuctionType(d.string())
+	sides := ob.snapshotSides()
+	nodes := make([][]*orderNode, len(sides))
+	for i := range sides {
+		nodes[i] = d.side()
+	}
+	if d.err != nil || len(d.buf) > 0 {
+		return 0, ErrInvalidSnapshot
+	}
+
+	for i, side := range sides {
+		index := ob.orders
+		if side.byStop {
+			index = ob.stops
+		}
+		for _, node := range nodes[i] {
+			side.add(node)
+			index[node.order.ID] = node
+			ob.trackOpen(&node.order, true)
+			ob.scheduleExpiry(&node.order)
+		}
+	}
+	ob.lsn = lsn
+	ob.arrivals = arrivals
+	ob.eventSeq = eventSeq
+	ob.lastPrice = lastPrice
+	ob.status = status
+	ob.until = until
+	ob.protection = protection
+	ob.window = window
+	ob.auction = auction
+	ob.trades = trades
+	ob.fees = fees
+	ob.feeTotals = feeTotals
+	if ob.balances != nil && balances != nil {
+		ob.balances = balances
+	}
+	ob.positions = positions
+	return lsn, nil
+}
+
+// snapshotSides lists the book sides in snapshot order.
+func (ob *OrderBook) snapshotSides() []*bookSide {
+	return []*bookSide{ob.bids, ob.asks, ob.buyStops, ob.sellStops}
+}
+
+type snapshotEncoder struct {
+	buf []byte
+}
+
+func (e *snapshotEncoder) uvarint(v uint64) { e.buf = binary.AppendUvarint(e.buf, v) }
+func (e *snapshotEncoder) varint(v int64)   { e.buf = binary.AppendVarint(e.buf, v) }
+
+func (e *snapshotEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *snapshotEncoder) bool(b bool) {
+	if b {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// side writes the orders of a side from best to worst price, each level in
+// queue order.
+func (e *snapshotEncoder) side(side *bookSide) {
+	var count int
+	side.each(func(level *priceLevel) bool {
+		count += level.count
+		return true
+	})
+	e.uvarint(uint64(count))
+
+	side.each(func(level *priceLevel) bool {
+		for n := level.head; n != nil; n = n.next {
+			e.uvarint(n.seq)
+			e.varint(int64(n.visible))
+			e.order(&n.order)
+		}
+		return true
+	})
+}
+
+// fees writes the fee schedule and the fee totals of every account, in key
+// order so that the same state always gives the same bytes.
+func (e *snapshotEncoder) fees(schedule *FeeSchedule, totals map[string]*AccountFees) {
+	e.string(schedule.Currency)
+	e.feeRate(schedule.Default)
+	e.uvarint(uint64(len(schedule.Tiers)))
+	for _, name := range slices.Sorted(maps.Keys(schedule.Tiers)) {
+		e.string(name)
+		e.feeRate(schedule.Tiers[name])
+	}
+	e.uvarint(uint64(len(schedule.Accounts)))
+	for _, account := range slices.Sorted(maps.Keys(schedule.Accounts)) {
+		e.string(account)
+		e.string(schedule.Accounts[account])
+	}
+
+	e.uvarint(uint64(len(totals)))
+	for _, account := range slices.Sorted(maps.Keys(totals)) {
+		e.string(account)
+		e.varint(int64(totals[account].MakerFees))
+		e.varint(int64(totals[account].TakerFees))
+		e.uvarint(totals[account].Trades)
+	}
+}
+
+// balances writes the funds of every account in account order, after a flag
+// telling whether the book enforces balances at all.
+func (e *snapshotEncoder) balances(balances map[string]*AccountBalances) {
+	e.bool(balances != nil)
+	if balances == nil {
+		return
+	}
+	e.uvarint(uint64(len(balances)))
+	for _, account := range slices.Sorted(maps.Keys(balances)) {
+		b := balances[account]
+		e.string(account)
+		e.varint(int64(b.Base.Available))
+		e.varint(int64(b.Base.Reserved))
+		e.varint(int64(b.Quote.Available))
+		e.varint(int64(b.Quote.Reserved))
+	}
+}
+
+// positions writes the position of every account in account order.
+func (e *snapshotEncoder) positions(positions map[string]Decimal) {
+	e.uvarint(uint64(len(positions)))
+	for _, account := range slices.Sorted(maps.Keys(positions)) {
+		e.string(account)
+		e.varint(int64(positions[account]))
+	}
+}
+
+// time writes a time as Unix nanoseconds, with zero for the zero time.
+func (e *snapshotEncoder) time(t time.Time) {
+	if t.IsZero() {
+		e.varint(0)
+		return
+	}
+	e.varint(t.UnixNano())
+}
+
+func (e *snapshotEncoder) protection(p *PriceProtection) {
+	e.varint(p.BandBps)
+	e.varint(p.BreakerBps)
+	e.varint(int64(p.BreakerWindow))
+	e.varint(int64(p.Cooldown))
+	e.varint(int64(p.AuctionDuration))
+}
+
+// window writes both queues of the circuit breaker window.
+func (e *snapshotEncoder) window(w *priceWindow) {
+	for _, points := range [][]pricePoint{w.lows, w.highs} {
+		e.uvarint(uint64(len(points)))
+		for _, point := range points {
+			e.time(point.at)
+			e.varint(int64(point.price))
+		}
+	}
+}
+
+func (e *snapshotEncoder) feeRate(r FeeRate) {
+	e.varint(r.MakerBps)
+	e.varint(r.TakerBps)
+	e.varint(int64(r.MinFee))
+}
+
+func (e *snapshotEncoder) order(o *Order) {
+	e.string(o.ID)
+	e.string(string(o.Type))
+	e.varint(int64(o.Price))
+	e.varint(int64(o.Amount))
+	e.string(string(o.Side))
+	e.string(o.Account)
+	e.string(string(o.SelfTradePrevention))
+	e.varint(int64(o.StopPrice))
+	e.string(string(o.TimeInForce))
+	e.bool(o.ExpireAt != nil)
+	if o.ExpireAt != nil {
+		e.varint(o.ExpireAt.UnixNano())
+	}
+	e.bool(o.PostOnly)
+	e.bool(o.PostOnlySlide)
+	e.varint(int64(o.PeakAmount))
+	e.varint(o.MaxSlippageBps)
+	e.varint(int64(o.MaxNotional))
+}
+
+// snapshotDecoder reads what snapshotEncoder wrote. The first error sticks
+// and turns every later read into a zero value.
+type snapshotDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *snapshotDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.err = ErrInvalidSnapshot
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *snapshotDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.err = ErrInvalidSnapshot
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+func (d *snapshotDecoder) string() string {
+	n := d.uvarint()
+	if d.err != nil || n > uint64(len(d.buf)) {
+		d.err = ErrInvalidSnapshot
+		return ""
+	}
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+func (d *snapshotDecoder) bool() bool {
+	if d.err != nil || len(d.buf) == 0 {
+		d.err = ErrInvalidSnapshot
+		return false
+	}
+	b := d.buf[0]
+	d.buf = d.buf[1:]
+	return b == 1
+}
+
+func (d *snapshotDecoder) side() []*orderNode {
+	count := d.uvarint()
+	var nodes []*orderNode
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		node := &orderNode{seq: d.uvarint(), visible: Decimal(d.varint())}
+		node.order = d.order()
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (d *snapshotDecoder) fees() (FeeSchedule, map[string]*AccountFees) {
+	schedule := FeeSchedule{Currency: d.string(), Default: d.feeRate()}
+	if n := d.uvarint(); n > 0 {
+		schedule.Tiers = make(map[string]FeeRate)
+		for i := uint64(0); i < n && d.err == nil; i++ {
+			name := d.string()
+			schedule.Tiers[name] = d.feeRate()
+		}
+	}
+	if n := d.uvarint(); n > 0 {
+		schedule.Accounts = make(map[string]string)
+		for i := uint64(0); i < n && d.err == nil; i++ {
+			account := d.string()
+			schedule.Accounts[account] = d.string()
+		}
+	}
+
+	totals := make(map[string]*AccountFees)
+	n := d.uvarint()
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		account := d.string()
+		totals[account] = &AccountFees{
+			MakerFees: Decimal(d.varint()),
+			TakerFees: Decimal(d.varint()),
+			Trades:    d.uvarint(),
+		}
+	}
+	return schedule, totals
+}
+
+func (d *snapshotDecoder) balances() map[string]*AccountBalances {
+	if !d.bool() {
+		return nil
+	}
+	balances := make(map[string]*AccountBalances)
+	n := d.uvarint()
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		account := d.string()
+		balances[account] = &AccountBalances{
+			Base:  Balance{Available: Decimal(d.varint()), Reserved: Decimal(d.varint())},
+			Quote: Balance{Available: Decimal(d.varint()), Reserved: Decimal(d.varint())},
+		}
+	}
+	return balances
+}
+
+func (d *snapshotDecoder) positions() map[string]Decimal {
+	positions := make(map[string]Decimal)
+	n := d.uvarint()
+	for i := uint64(0); i < n && d.err == nil; i++ {
+		account := d.string()
+		positions[account] = Decimal(d.varint())
+	}
+	return positions
+}
+
+func (d *snapshotDecoder) time() time.Time {
+	nanos := d.varint()
+	if nanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanos).UTC()
+}
+
+func (d *snapshotDecoder) protection() PriceProtection {
+	return PriceProtection{
+		BandBps:         d.varint(),
+		BreakerBps:      d.varint(),
+		BreakerWindow:   time.Duration(d.varint()),
+		Cooldown:        time.Duration(d.varint()),
+		AuctionDuration: time.Duration(d.varint()),
+	}
+}
+
+func (d *snapshotDecoder) window() priceWindow {
+	var queues [2][]pricePoint
+	for i := range queues {
+		n := d.uvarint()
+		for j := uint64(0); j < n && d.err == nil; j++ {
+			queues[i] = append(queues[i], pricePoint{at: d.time(), price: Decimal(d.varint())})
+		}
+	}
+	return priceWindow{lows: queues[0], highs: queues[1]}
+}
+
+func (d *snapshotDecoder) feeRate() FeeRate {
+	return FeeRate{MakerBps: d.varint(), TakerBps: d.varint(), MinFee: Decimal(d.varint())}
+}
+
+func (d *snapshotDecoder) order() Order {
+	o := Order{
+		ID:                  d.string(),
+		Type:                OrderType(d.string()),
+		Price:               Decimal(d.varint()),
+		Amount:              Decimal(d.varint()),
+		Side:                Side(d.string()),
+		Account:             d.string(),
+		SelfTradePrevention: SelfTradePrevention(d.string()),
+		StopPrice:           Decimal(d.varint()),
+		TimeInForce:         TimeInForce(d.string()),
+	}
+	if d.bool() {
+		expireAt := time.Unix(0, d.varint()).UTC()
+		o.ExpireAt = &expireAt
+	}
+	o.PostOnly = d.bool()
+	o.PostOnlySlide = d.bool()
+	o.PeakAmount = Decimal(d.varint())
+	o.MaxSlippageBps = d.varint()
+	o.MaxNotional = Decimal(d.varint())
+	return o
+}
@@ -0,0 +1,96 @@
+package orderbook
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSnapshot_LoadAndReplayTail(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	j := &memJournal{}
+	ob := NewOrderBook("TEST", WithClock(func() time.Time { return now }), WithJournal(j))
+
+	expireAt := now.Add(time.Hour)
+	ob.PlaceOrder(Order{ID: "ask-1", Price: dec(101.0), Amount: dec(2.0), Side: Sell, Account: "alice"})
+	ob.PlaceOrder(Order{ID: "ask-2", Price: dec(101.0), Amount: dec(5.0), PeakAmount: dec(1.0), Side: Sell})
+	ob.PlaceOrder(Order{ID: "gtd", Price: dec(99.0), Amount: dec(1.0), Side: Buy, TimeInForce: GTD, ExpireAt: &expireAt})
+	ob.ProcessOrder(Order{ID: "stop", Type: Stop, StopPrice: dec(90.0), Amount: dec(1.0), Side: Sell, MaxSlippageBps: 100})
+	ob.ProcessOrder(Order{ID: "take", Price: dec(101.0), Amount: dec(2.5), Side: Buy})
+
+	var buf bytes.Buffer
+	lsn, err := ob.WriteSnapshot(&buf)
+	if err != nil || lsn != 5 {
+		t.Fatalf("Expected a snapshot at LSN 5, got %d, %v", lsn, err)
+	}
+
+	// The book keeps going after the snapshot
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	ob.CancelOrder("ask-1")
+	now = now.Add(2 * time.Hour)
+	ob.ExpireOrders(now)
+
+	restored := NewOrderBook("TEST")
+	loaded, err := restored.LoadSnapshot(&buf)
+	if err != nil || loaded != lsn {
+		t.Fatalf("Failed to load snapshot: %d, %v", loaded, err)
+	}
+	for _, record := range j.records[loaded:] {
+		if err := restored.Replay(record); err != nil {
+			t.Fatalf("Failed to replay: %v", err)
+		}
+	}
+
+	if !reflect.DeepEqual(bookState(restored), bookState(ob)) {
+		t.Errorf("Restored book differs:\n got %+v\nwant %+v", bookState(restored), bookState(ob))
+	}
+	if restored.lsn != ob.lsn || restored.arrivals != ob.arrivals || restored.eventSeq != ob.eventSeq ||
+		restored.lastPrice != ob.lastPrice || restored.trades != ob.trades {
+		t.Errorf("Counters differ: got %d/%d/%d/%v/%d, want %d/%d/%d/%v/%d",
+			restored.lsn, restored.arrivals, restored.eventSeq, restored.lastPrice, restored.trades,
+			ob.lsn, ob.arrivals, ob.eventSeq, ob.lastPrice, ob.trades)
+	}
+	if !reflect.DeepEqual(restored.GetOrderBookSnapshot().Asks, ob.GetOrderBookSnapshot().Asks) {
+		t.Error("Expected the same displayed levels")
+	}
+	if _, ok := restored.orders["gtd"]; ok {
+		t.Error("Expected the restored GTD order to be scheduled for expiry")
+	}
+}
+
+func TestSnapshot_Invalid(t *testing.T) {
+	ob := NewOrderBook("TEST")
+	ob.PlaceOrder(Order{ID: "bid", Price: dec(100.0), Amount: dec(1.0), Side: Buy})
+	var buf bytes.Buffer
+	ob.WriteSnapshot(&buf)
+	data := buf.Bytes()
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)/2] ^= 0xff
+
+	future := append([]byte(nil), data[:len(data)-4]...)
+	binary.LittleEndian.PutUint16(future[4:], 99)
+	future = binary.LittleEndian.AppendUint32(future, crc32.Checksum(future, snapshotCRC))
+
+	tests := []struct {
+		name     string
+		data     []byte
+		book     *OrderBook
+		expected error
+	}{
+		{"Empty", nil, NewOrderBook("TEST"), ErrInvalidSnapshot},
+		{"Corrupt", corrupt, NewOrderBook("TEST"), ErrInvalidSnapshot},
+		{"Version", future, NewOrderBook("TEST"), ErrSnapshotVersion},
+		{"Not empty", data, ob, ErrBookNotEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.book.LoadSnapshot(bytes.NewReader(tt.data)); err != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package orderbook
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrBookClosed   = errors.New("Book is closed")
+	ErrAuctionOrder = errors.New("Market, IOC and FOK orders cannot join an auction")
+)
+
+// BookStatus is the trading phase of a book.
+type BookStatus string
+
+const (
+	BookOpen    BookStatus = "OPEN"    // Orders match as they arrive
+	BookHalted  BookStatus = "HALTED"  // Orders can only be cancelled
+	BookAuction BookStatus = "AUCTION" // Orders rest without matching until the book uncrosses
+	BookClosed  BookStatus = "CLOSED"  // Orders can only be cancelled until the book is resumed
+)
+
+// StatusInfo is the status of a book and, for a circuit breaker halt or a
+// timed auction, when it ends.
+type StatusInfo struct {
+	Status  BookStatus  `json:"status"`
+	Auction AuctionType `json:"auction,omitempty"` // Kind of auction the book is in
+	Until   *time.Time  `json:"until,omitempty"`
+}
+
+// Status returns the trading phase of the book.
+func (ob *OrderBook) Status() BookStatus {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	return ob.status
+}
+
+// StatusInfo returns the trading phase of the book and when it is due to
+// change.
+func (ob *OrderBook) StatusInfo() StatusInfo {
+	ob.mu.RLock()
+	defer ob.mu.RUnlock()
+
+	info := StatusInfo{Status: ob.status, Auction: ob.auction}
+	if !ob.until.IsZero() {
+		until := ob.until
+		info.Until = &until
+	}
+	return info
+}
+
+// Halted reports whether trading is halted.
+func (ob *OrderBook) Halted() bool {
+	return ob.Status() == BookHalted
+}
+
+// Halt stops trading: new orders and modifications are rejected with
+// ErrBookHalted until Resume is called. Cancels and expiry carry on. Halting
+// a book stopped by its circuit breaker keeps it halted past the cooldown.
+func (ob *OrderBook) Halt() error {
+	return ob.changeStatus(CommandHalt)
+}
+
+// Close closes the market: like a halt, but new orders and modifications are
+// rejected with ErrBookClosed. Resume opens it again.
+func (ob *OrderBook) Close() error {
+	return ob.changeStatus(CommandClose)
+}
+
+// Resume opens the book for trading. A book left crossed by an auction is
+// uncrossed first.
+func (ob *OrderBook) Resume() error {
+	return ob.changeStatus(CommandResume)
+}
+
+// Advance applies the status changes due at now: a circuit breaker halt
+// whose cooldown is over becomes a reopening auction, and an auction whose
+// time is up uncrosses. Order operations and
+// ExpireOrders advance the book themselves.
+func (ob *OrderBook) Advance(now time.Time) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = now
+	return ob.advance()
+}
+
+func (ob *OrderBook) changeStatus(typ CommandType) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = ob.now()
+	return ob.applyStatus(typ)
+}
+
+// advance applies the status changes due at the time of the operation.
+// Replayed operations find them already in the journal.
+// Must be called with the lock held.
+func (ob *OrderBook) advance() error {
+	for !ob.replaying && !ob.until.IsZero() && !ob.at.Before(ob.until) {
+		var err error
+		if ob.status == BookAuction {
+			_, err = ob.endAuction()
+		} else {
+			err = ob.applyStatus(CommandAuction)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyStatus moves the book to the status a command stands for.
+// Must be called with the lock held.
+func (ob *OrderBook) applyStatus(typ CommandType) error {
+	switch {
+	case typ == CommandHalt && ob.status == BookHalted && ob.until.IsZero(),
+		typ == CommandClose && ob.status == BookClosed,
+		typ == CommandResume && ob.status == BookOpen:
+		return nil
+	}
+	if err := ob.record(Command{Type: typ}); err != nil {
+		return err
+	}
+
+	switch typ {
+	case CommandHalt:
+		ob.setStatus(BookHalted, time.Time{})
+	case CommandClose:
+		ob.setStatus(BookClosed, time.Time{})
+	case CommandAuction:
+		// The auction runs from the end of the cooldown, however late the
+		// book notices
+		ob.setStatus(BookAuction, ob.until.Add(ob.protection.AuctionDuration))
+		ob.auction = AuctionReopening
+		ob.indicative = Indicative{}
+	case CommandResume:
+		ob.uncross()
+		ob.setStatus(BookOpen, time.Time{})
+		ob.triggerStops()
+	}
+	return nil
+}
+
+// setStatus changes the status of the book and when it is due to change
+// next, zero if only a command changes it. Entering an auction leaves its
+// kind to the caller.
+// Must be called with the lock held.
+func (ob *OrderBook) setStatus(status BookStatus, until time.Time) {
+	changed := ob.status != status
+	ob.status = status
+	ob.until = until
+	ob.auction = ""
+	if changed {
+		ob.emit(Event{Type: EventStatusChanged, Status: status})
+	}
+}
+
+// stopped returns the error for operations the book does not take while
+// trading is stopped, or nil if it is trading.
+func (ob *OrderBook) stopped() error {
+	switch ob.status {
+	case BookHalted:
+		return ErrBookHalted
+	case BookClosed:
+		return ErrBookClosed
+	}
+	return nil
+}
+
+// checkStatus rejects a new order the book does not take in its status.
+func (ob *OrderBook) checkStatus(order *Order) error {
+	if err := ob.stopped(); err != nil {
+		return err
+	}
+	if ob.status == BookAuction && !order.Rests() {
+		return ErrAuctionOrder
+	}
+	return nil
+}
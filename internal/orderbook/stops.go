@@ -0,0 +1,197 @@
+package orderbook
+
+import (
+	"time"
+)
+
+// ModifyStopOrder changes the stop price, limit price and amount of an
+// untriggered stop order. Stop market orders take a zero price. If the stop
+// price changes the order loses its priority among stops at that price, and
+// it triggers immediately if the last trade price has already crossed it.
+// Returns ErrOrderNotFound if no such stop order is waiting, or
+// ErrInvalidModification if the new values are invalid.
+func (ob *OrderBook) ModifyStopOrder(orderID string, newStopPrice Decimal, newPrice Decimal, newAmount Decimal) error {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+	defer ob.publishLevels()
+
+	ob.at = ob.now()
+	if err := ob.advance(); err != nil {
+		return err
+	}
+	return ob.modifyStopOrder(orderID, newStopPrice, newPrice, newAmount)
+}
+
+// Must be called with the lock held.
+func (ob *OrderBook) modifyStopOrder(orderID string, newStopPrice Decimal, newPrice Decimal, newAmount Decimal) error {
+	if err := ob.stopped(); err != nil {
+		return err
+	}
+	if newStopPrice <= 0 || newAmount <= 0 || newPrice < 0 {
+		return ErrInvalidModification
+	}
+	if _, ok := newPrice.MulChecked(newAmount); !ok {
+		return ErrInvalidModification
+	}
+	if err := ob.spec.checkIncrements(newPrice, newAmount); err != nil {
+		return err
+	}
+	if err := ob.spec.checkIncrements(newStopPrice, newAmount); err != nil {
+		return err
+	}
+	if err := ob.spec.checkSize(newPrice, newAmount); err != nil {
+		return err
+	}
+
+	node, ok := ob.stops[orderID]
+	if !ok {
+		return ErrOrderNotFound
+	}
+	if (node.order.Type == StopLimit) != (newPrice > 0) {
+		return ErrInvalidModification
+	}
+	old := node.order
+	modified := old
+	modified.StopPrice = newStopPrice
+	modified.Price = newPrice
+	modified.Amount = newAmount
+	if err := ob.checkFunds(&modified, &old); err != nil {
+		return err
+	}
+	if err := ob.preTrade(&modified, &old); err != nil {
+		return err
+	}
+	if err := ob.record(Command{Type: CommandModifyStop, OrderID: orderID, StopPrice: newStopPrice, Price: newPrice, Amount: newAmount}); err != nil {
+		return err
+	}
+
+	// If the stop price is unchanged, update in place
+	if newStopPrice == node.order.StopPrice {
+		node.order.Price = newPrice
+		node.resize(newAmount)
+		ob.rehold(&old, &node.order)
+		ob.emitOrder(EventModified, node.order, newAmount, 0)
+		return nil
+	}
+
+	ob.removeStop(node)
+	ob.insertStop(modified)
+	ob.emitOrder(EventModified, modified, newAmount, 0)
+
+	// Any resulting executions are not reported back to the caller
+	ob.triggerStops()
+	return nil
+}
+
+// stopTriggered reports whether the last trade price has reached the stop
+// price of an order.
+func (ob *OrderBook) stopTriggered(order *Order) bool {
+	if ob.lastPrice == 0 {
+		return false
+	}
+	if order.Side == Buy {
+		return ob.lastPrice >= order.StopPrice
+	}
+	return ob.lastPrice <= order.StopPrice
+}
+
+// triggerStops activates every stop order whose stop price has been reached
+// and feeds it back through matching, where it is accepted a second time as
+// the order it activates into. Trades from an activated order move the
+// last trade price, so the loop continues until no stop is left to trigger.
+// When buy and sell stops trigger together the earliest arrival goes first.
+// Must be called with the lock held.
+func (ob *OrderBook) triggerStops() []*ProcessResult {
+	var results []*ProcessResult
+	now := ob.at
+
+	for ob.status == BookOpen {
+		node := ob.nextTriggered()
+		if node == nil {
+			return results
+		}
+		ob.removeStop(node)
+		if node.order.expired(now) {
+			ob.emitOrder(EventExpired, node.order, 0, node.order.Amount)
+			continue
+		}
+
+		result, err := ob.process(activate(node.order))
+		if err != nil {
+			ob.emitRejected(node.order, err)
+			result = &ProcessResult{
+				OrderID:         node.order.ID,
+				Status:          StatusRejected,
+				TimeInForce:     node.order.TimeInForce,
+				Price:           node.order.Price,
+				CancelledAmount: node.order.Amount,
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// nextTriggered returns the next stop order to activate, or nil.
+func (ob *OrderBook) nextTriggered() *orderNode {
+	var next *orderNode
+	if level := ob.buyStops.best(); level != nil && ob.stopTriggered(&level.head.order) {
+		next = level.head
+	}
+	if level := ob.sellStops.best(); level != nil && ob.stopTriggered(&level.head.order) {
+		if next == nil || level.head.seq < next.seq {
+			next = level.head
+		}
+	}
+	return next
+}
+
+// insertStop parks an order in the trigger book, reserving its funds.
+// Must be called with the lock held.
+func (ob *OrderBook) insertStop(order Order) {
+	ob.hold(&order)
+	ob.trackOpen(&order, true)
+	node := newOrderNode(order, ob.nextArrival())
+	ob.stopSide(order.Side).add(node)
+	ob.stops[order.ID] = node
+	ob.scheduleExpiry(&node.order)
+}
+
+// removeStop takes an order out of the trigger book, freeing its funds.
+// Must be called with the lock held.
+func (ob *OrderBook) removeStop(node *orderNode) {
+	ob.release(&node.order)
+	ob.trackOpen(&node.order, false)
+	ob.stopSide(node.order.Side).unlink(node)
+	delete(ob.stops, node.order.ID)
+}
+
+// stopSide returns the half of the trigger book holding stops of a side.
+func (ob *OrderBook) stopSide(side Side) *bookSide {
+	if side == Sell {
+		return ob.sellStops
+	}
+	return ob.buyStops
+}
+
+// expireStop removes an expired GTD stop order, reporting whether it did.
+// Must be called with the lock held.
+func (ob *OrderBook) expireStop(orderID string, now time.Time) (Order, bool) {
+	node, ok := ob.stops[orderID]
+	if !ok || !node.order.expired(now) {
+		return Order{}, false
+	}
+	ob.removeStop(node)
+	ob.emitOrder(EventExpired, node.order, 0, node.order.Amount)
+	return node.order, true
+}
+
+// activate turns a triggered stop order into the order it stands for.
+func activate(order Order) Order {
+	if order.Type == Stop {
+		order.Type = Market
+	} else {
+		order.Type = Limit
+	}
+	return order
+}
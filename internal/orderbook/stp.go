@@ -0,0 +1,50 @@
+package orderbook
+
+// selfTrade reports whether two orders belong to the same account.
+func selfTrade(order, resting *Order) bool {
+	return order.Account != "" && order.Account == resting.Account
+}
+
+// stpMode returns the self-trade prevention mode that applies to an order.
+func (ob *OrderBook) stpMode(order *Order) SelfTradePrevention {
+	if order.SelfTradePrevention != "" {
+		return order.SelfTradePrevention
+	}
+	return ob.stp
+}
+
+// preventSelfTrade applies self-trade prevention to an incoming order about
+// to match a resting order of the same account. Cancelled or reduced resting
+// orders are recorded in the result, and the returned amount is how much of
+// the incoming remainder is cancelled.
+// Must be called with the lock held.
+func (ob *OrderBook) preventSelfTrade(order *Order, node *orderNode, remaining Decimal, result *ProcessResult) Decimal {
+	var incoming, resting Decimal
+	switch ob.stpMode(order) {
+	case CancelNewest:
+		incoming = remaining
+	case CancelOldest:
+		resting = node.order.Amount
+	case CancelBoth:
+		incoming, resting = remaining, node.order.Amount
+	case DecrementAndCancel:
+		incoming = remaining.Min(node.order.Amount)
+		resting = incoming
+	}
+
+	if resting > 0 {
+		result.Prevented = append(result.Prevented, &PreventedMatch{RestingOrderID: node.order.ID, Amount: resting})
+		open := node.order.Amount - resting
+		if open == 0 {
+			ob.remove(node)
+		} else {
+			old := node.order
+			node.resize(open)
+			ob.rehold(&old, &node.order)
+			ob.touch(node)
+		}
+		ob.emitOrder(EventCancelled, node.order, open, resting)
+	}
+	result.PreventedAmount += incoming
+	return incoming
+}
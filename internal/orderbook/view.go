@@ -0,0 +1,59 @@
+package orderbook
+
+import "time"
+
+// BookOrder is a resting order as the book displays it: only the displayed
+// part of icebergs and nothing about who placed it. The orders of a price
+// level trade in increasing Priority.
+type BookOrder struct {
+	ID       string  `json:"id"`
+	Side     Side    `json:"side"`
+	Price    Decimal `json:"price"`
+	Amount   Decimal `json:"amount"`
+	Priority uint64  `json:"priority"`
+}
+
+func (n *orderNode) bookOrder() BookOrder {
+	return BookOrder{ID: n.order.ID, Side: n.order.Side, Price: n.order.Price, Amount: n.visible, Priority: n.seq}
+}
+
+// BookView is everything the book displays as of the event Seq: the resting
+// orders of each side, best price first and then in priority order, the
+// last trade price and the trading status.
+type BookView struct {
+	Seq       uint64
+	Time      time.Time
+	Bids      []BookOrder
+	Asks      []BookOrder
+	LastPrice Decimal
+	Status    BookStatus
+}
+
+// SubscribeView registers a subscriber for every event emitted from now on
+// and returns the view of the book those events follow, so that a market
+// data feed can start from the view and apply every event after its Seq.
+func (ob *OrderBook) SubscribeView() (*Subscription, BookView) {
+	ob.mu.Lock()
+	defer ob.mu.Unlock()
+
+	view := BookView{
+		Seq:       ob.eventSeq,
+		Time:      ob.now(),
+		Bids:      bookOrders(ob.bids),
+		Asks:      bookOrders(ob.asks),
+		LastPrice: ob.lastPrice,
+		Status:    ob.status,
+	}
+	return ob.subscribe(), view
+}
+
+func bookOrders(side *bookSide) []BookOrder {
+	var orders []BookOrder
+	side.each(func(level *priceLevel) bool {
+		for n := level.head; n != nil; n = n.next {
+			orders = append(orders, n.bookOrder())
+		}
+		return true
+	})
+	return orders
+}
@@ -0,0 +1,184 @@
+// Package risk checks orders against pre-trade limits before they reach an
+// order book.
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"orderbook/internal/orderbook"
+)
+
+var ErrInvalidLimits = errors.New("Invalid risk limits")
+
+// Book is what rules need to know about the market an order is for.
+// *orderbook.OrderBook implements it.
+type Book interface {
+	GetBestBid() (orderbook.Order, error)
+	GetBestAsk() (orderbook.Order, error)
+	LastPrice() orderbook.Decimal
+	OpenOrders(account string) int
+	OpenExposure(account string, side orderbook.Side) orderbook.Decimal
+	Position(account string) orderbook.Decimal
+}
+
+// Rule is a single pre-trade check. It is given the order about to reach the
+// book and, when an order resting in the book is being modified, the order
+// as it is now in old; nil for new orders. A nil Rejection lets it through.
+type Rule interface {
+	Check(order *orderbook.Order, old *orderbook.Order, book Book) *Rejection
+}
+
+// RuleFunc adapts a function to a Rule.
+type RuleFunc func(order *orderbook.Order, old *orderbook.Order, book Book) *Rejection
+
+func (f RuleFunc) Check(order *orderbook.Order, old *orderbook.Order, book Book) *Rejection {
+	return f(order, old, book)
+}
+
+// Rejection is the reason a rule stopped an order: the rule, the limit it
+// enforces and the value the order would have reached.
+type Rejection struct {
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+	Limit  string `json:"limit"`
+	Value  string `json:"value"`
+}
+
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("%s: %s (limit %s, got %s)", r.Rule, r.Reason, r.Limit, r.Value)
+}
+
+// Limit configures the built-in rules. A zero field disables its rule.
+type Limit struct {
+	MaxQuantity    orderbook.Decimal `json:"max_quantity,omitempty"`
+	MaxNotional    orderbook.Decimal `json:"max_notional,omitempty"`
+	PriceCollarBps int64             `json:"price_collar_bps,omitempty"`
+	MaxOpenOrders  int               `json:"max_open_orders,omitempty"`
+	MaxPosition    orderbook.Decimal `json:"max_position,omitempty"`
+}
+
+// Validate reports whether every field is zero or positive.
+func (l Limit) Validate() error {
+	if l.MaxQuantity < 0 || l.MaxNotional < 0 || l.PriceCollarBps < 0 || l.MaxOpenOrders < 0 || l.MaxPosition < 0 {
+		return ErrInvalidLimits
+	}
+	return nil
+}
+
+// Rules returns the rules the limit enables.
+func (l Limit) Rules() []Rule {
+	var rules []Rule
+	if l.MaxQuantity > 0 {
+		rules = append(rules, MaxQuantity(l.MaxQuantity))
+	}
+	if l.MaxNotional > 0 {
+		rules = append(rules, MaxNotional(l.MaxNotional))
+	}
+	if l.PriceCollarBps > 0 {
+		rules = append(rules, PriceCollar(l.PriceCollarBps))
+	}
+	if l.MaxOpenOrders > 0 {
+		rules = append(rules, MaxOpenOrders(l.MaxOpenOrders))
+	}
+	if l.MaxPosition > 0 {
+		rules = append(rules, MaxPosition(l.MaxPosition))
+	}
+	return rules
+}
+
+// Limits are the limits of a market. An account listed in Accounts is
+// checked against its own limit instead of the default.
+type Limits struct {
+	Default  Limit            `json:"default"`
+	Accounts map[string]Limit `json:"accounts,omitempty"`
+}
+
+// Validate checks the default and every account limit.
+func (l Limits) Validate() error {
+	if err := l.Default.Validate(); err != nil {
+		return err
+	}
+	for _, limit := range l.Accounts {
+		if err := limit.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limit returns the limit that applies to an account.
+func (l Limits) limit(account string) Limit {
+	if limit, ok := l.Accounts[account]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// Engine checks orders against the limits of their market and any custom
+// rules it was created with. It is safe for concurrent use.
+type Engine struct {
+	mu     sync.RWMutex
+	rules  []Rule            // Applied to every order of every market
+	limits map[string]Limits // Indexed by market
+}
+
+// NewEngine creates an engine that applies rules to every order on top of the
+// limits set per market.
+func NewEngine(rules ...Rule) *Engine {
+	return &Engine{
+		rules:  rules,
+		limits: make(map[string]Limits),
+	}
+}
+
+// SetLimits replaces the limits of a market.
+// Returns ErrInvalidLimits if any limit is negative.
+func (e *Engine) SetLimits(market string, limits Limits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	limits.Accounts = maps.Clone(limits.Accounts)
+	e.limits[market] = limits
+	return nil
+}
+
+// Limits returns the limits of a market, zero if none were set.
+func (e *Engine) Limits(market string) Limits {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	limits := e.limits[market]
+	limits.Accounts = maps.Clone(limits.Accounts)
+	return limits
+}
+
+// RemoveLimits forgets the limits of a market.
+func (e *Engine) RemoveLimits(market string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.limits, market)
+}
+
+// Check runs every rule that applies to an order in a market and returns the
+// first *Rejection, or nil if the order may go to the book. old is the order
+// as it rests in the book when it is being modified, nil otherwise.
+func (e *Engine) Check(market string, book Book, order *orderbook.Order, old *orderbook.Order) error {
+	e.mu.RLock()
+	rules := append(e.limits[market].limit(order.Account).Rules(), e.rules...)
+	e.mu.RUnlock()
+
+	for _, rule := range rules {
+		if rejection := rule.Check(order, old, book); rejection != nil {
+			return rejection
+		}
+	}
+	return nil
+}
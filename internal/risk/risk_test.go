@@ -0,0 +1,134 @@
+package risk
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"orderbook/internal/orderbook"
+)
+
+func TestEngine_Check(t *testing.T) {
+	book := orderbook.NewOrderBook("TEST")
+	book.PlaceOrder(orderbook.Order{Price: dec(100.0), Amount: dec(5.0), Side: orderbook.Sell, Account: "bob"})
+	book.ProcessOrder(orderbook.Order{Price: dec(100.0), Amount: dec(2.0), Side: orderbook.Buy, Account: "alice"})
+	book.PlaceOrder(orderbook.Order{Price: dec(99.0), Amount: dec(1.0), Side: orderbook.Buy, Account: "alice"})
+
+	engine := NewEngine()
+	err := engine.SetLimits("TEST", Limits{
+		Default: Limit{
+			MaxQuantity:    dec(10.0),
+			MaxNotional:    dec(500.0),
+			PriceCollarBps: 500,
+			MaxOpenOrders:  1,
+			MaxPosition:    dec(3.0),
+		},
+		Accounts: map[string]Limit{"mm": {MaxOpenOrders: 100}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to set limits: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		order    orderbook.Order
+		old      *orderbook.Order
+		expected string // Rule expected to reject, empty if none
+	}{
+		{"Within limits", orderbook.Order{Price: dec(100.0), Amount: dec(1.0), Side: orderbook.Sell, Account: "carol"}, nil, ""},
+		{"Quantity", orderbook.Order{Price: dec(1.0), Amount: dec(11.0), Side: orderbook.Sell, Account: "carol"}, nil, RuleMaxQuantity},
+		{"Notional", orderbook.Order{Price: dec(100.0), Amount: dec(6.0), Side: orderbook.Sell, Account: "carol"}, nil, RuleMaxNotional},
+		{"Market notional at last price", orderbook.Order{Type: orderbook.Market, Amount: dec(6.0), Side: orderbook.Sell, Account: "carol"}, nil, RuleMaxNotional},
+		{"Market notional at cap", orderbook.Order{Type: orderbook.Market, Amount: dec(1.0), MaxNotional: dec(600.0), Side: orderbook.Buy, Account: "carol"}, nil, RuleMaxNotional},
+		{"Collar above", orderbook.Order{Price: dec(105.01), Amount: dec(1.0), Side: orderbook.Sell, Account: "carol"}, nil, RulePriceCollar},
+		{"Collar below", orderbook.Order{Price: dec(94.99), Amount: dec(1.0), Side: orderbook.Buy, Account: "carol"}, nil, RulePriceCollar},
+		{"Collar edge", orderbook.Order{Price: dec(95.0), Amount: dec(1.0), Side: orderbook.Buy, Account: "carol"}, nil, ""},
+		{"Open orders", orderbook.Order{Price: dec(98.0), Amount: dec(0.5), Side: orderbook.Sell, Account: "alice"}, nil, RuleMaxOpenOrders},
+		{"Open orders IOC", orderbook.Order{Price: dec(98.0), Amount: dec(0.5), Side: orderbook.Sell, Account: "alice", TimeInForce: orderbook.IOC}, nil, ""},
+		{"Open orders modify", orderbook.Order{Price: dec(98.0), Amount: dec(0.5), Side: orderbook.Buy, Account: "alice"},
+			&orderbook.Order{Price: dec(99.0), Amount: dec(1.0), Side: orderbook.Buy, Account: "alice"}, ""},
+		{"Position", orderbook.Order{Type: orderbook.Market, Amount: dec(1.5), Side: orderbook.Buy, Account: "alice"}, nil, RuleMaxPosition},
+		{"Position with open orders", orderbook.Order{Price: dec(99.0), Amount: dec(0.5), Side: orderbook.Buy, Account: "alice", TimeInForce: orderbook.IOC}, nil, RuleMaxPosition},
+		{"Position reduced", orderbook.Order{Type: orderbook.Market, Amount: dec(3.0), Side: orderbook.Sell, Account: "alice"}, nil, ""},
+		{"Short position", orderbook.Order{Price: dec(100.0), Amount: dec(3.5), Side: orderbook.Sell, Account: "carol"}, nil, RuleMaxPosition},
+		{"Account limit", orderbook.Order{Price: dec(200.0), Amount: dec(50.0), Side: orderbook.Sell, Account: "mm"}, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := engine.Check("TEST", book, &tt.order, tt.old)
+			var rejection *Rejection
+			switch {
+			case tt.expected == "" && err != nil:
+				t.Errorf("Expected no rejection, got %v", err)
+			case tt.expected != "" && (!errors.As(err, &rejection) || rejection.Rule != tt.expected):
+				t.Errorf("Expected %s rejection, got %v", tt.expected, err)
+			}
+		})
+	}
+
+	// Other markets have no limits
+	order := orderbook.Order{Price: dec(1.0), Amount: dec(1000.0), Side: orderbook.Sell}
+	if err := engine.Check("OTHER", book, &order, nil); err != nil {
+		t.Errorf("Expected no limits in another market, got %v", err)
+	}
+}
+
+func TestMaxPosition_OpenOrders(t *testing.T) {
+	book := orderbook.NewOrderBook("TEST")
+	rule := MaxPosition(dec(10.0))
+
+	// Each bid is within the limit alone, but not once the others fill
+	for i, expected := range []bool{true, false, false} {
+		order := orderbook.Order{ID: fmt.Sprint("bid-", i), Price: dec(100.0), Amount: dec(8.0), Side: orderbook.Buy, Account: "alice"}
+		if rejection := rule.Check(&order, nil, book); (rejection == nil) != expected {
+			t.Fatalf("Bid %d: expected accepted %v, got %v", i, expected, rejection)
+		}
+		if expected {
+			book.PlaceOrder(order)
+		}
+	}
+
+	// Stops count too, and asks offset nothing until they fill
+	book.ProcessOrder(orderbook.Order{ID: "stop", Type: orderbook.Stop, StopPrice: dec(90.0), Amount: dec(2.0), Side: orderbook.Buy, Account: "alice"})
+	order := orderbook.Order{Price: dec(100.0), Amount: dec(1.0), Side: orderbook.Buy, Account: "alice"}
+	if rejection := rule.Check(&order, nil, book); rejection == nil || rejection.Value != "11" {
+		t.Errorf("Expected a projected position of 11, got %v", rejection)
+	}
+
+	// A modification replaces the order it modifies
+	old, _ := book.GetOrder("bid-0")
+	modified := old
+	modified.Amount = dec(7.0)
+	if rejection := rule.Check(&modified, &old, book); rejection != nil {
+		t.Errorf("Expected a smaller bid to pass, got %v", rejection)
+	}
+}
+
+func TestEngine_CustomRules(t *testing.T) {
+	blocked := RuleFunc(func(order *orderbook.Order, _ *orderbook.Order, _ Book) *Rejection {
+		if order.Account != "mallory" {
+			return nil
+		}
+		return &Rejection{Rule: "BLOCKED", Reason: "Account is blocked"}
+	})
+	engine := NewEngine(blocked)
+	book := orderbook.NewOrderBook("TEST")
+
+	order := orderbook.Order{Price: dec(1.0), Amount: dec(1.0), Side: orderbook.Buy, Account: "mallory"}
+	if err := engine.Check("TEST", book, &order, nil); err == nil || err.(*Rejection).Rule != "BLOCKED" {
+		t.Errorf("Expected BLOCKED rejection, got %v", err)
+	}
+	order.Account = "alice"
+	if err := engine.Check("TEST", book, &order, nil); err != nil {
+		t.Errorf("Expected no rejection, got %v", err)
+	}
+
+	if err := engine.SetLimits("TEST", Limits{Default: Limit{MaxQuantity: dec(-1.0)}}); err != ErrInvalidLimits {
+		t.Errorf("Expected ErrInvalidLimits, got %v", err)
+	}
+}
+
+func dec(f float64) orderbook.Decimal {
+	return orderbook.DecimalFromFloat(f)
+}
@@ -0,0 +1,164 @@
+package risk
+
+import (
+	"strconv"
+
+	"orderbook/internal/orderbook"
+)
+
+// Names of the built-in rules, as reported in rejections.
+const (
+	RuleMaxQuantity   = "MAX_QUANTITY"
+	RuleMaxNotional   = "MAX_NOTIONAL"
+	RulePriceCollar   = "PRICE_COLLAR"
+	RuleMaxOpenOrders = "MAX_OPEN_ORDERS"
+	RuleMaxPosition   = "MAX_POSITION"
+)
+
+// MaxQuantity rejects orders for more than limit.
+func MaxQuantity(limit orderbook.Decimal) Rule {
+	return RuleFunc(func(order *orderbook.Order, _ *orderbook.Order, _ Book) *Rejection {
+		if order.Amount <= limit {
+			return nil
+		}
+		return &Rejection{
+			Rule:   RuleMaxQuantity,
+			Reason: "Order quantity is above the limit",
+			Limit:  limit.String(),
+			Value:  order.Amount.String(),
+		}
+	})
+}
+
+// MaxNotional rejects orders worth more than limit in the quote asset. Orders
+// without a price are valued at their max notional if they have one, or at
+// the reference price of the book otherwise.
+func MaxNotional(limit orderbook.Decimal) Rule {
+	return RuleFunc(func(order *orderbook.Order, _ *orderbook.Order, book Book) *Rejection {
+		price := order.Price
+		if price == 0 {
+			if order.MaxNotional > 0 {
+				return checkNotional(limit, order.MaxNotional, true)
+			}
+			if price = reference(book); price == 0 {
+				return nil
+			}
+		}
+		notional, ok := price.MulChecked(order.Amount)
+		return checkNotional(limit, notional, ok)
+	})
+}
+
+// checkNotional rejects a notional above limit, or one too large to compute.
+func checkNotional(limit orderbook.Decimal, notional orderbook.Decimal, ok bool) *Rejection {
+	if ok && notional <= limit {
+		return nil
+	}
+	value := notional.String()
+	if !ok {
+		value = "overflow"
+	}
+	return &Rejection{
+		Rule:   RuleMaxNotional,
+		Reason: "Order notional is above the limit",
+		Limit:  limit.String(),
+		Value:  value,
+	}
+}
+
+// PriceCollar rejects limit prices more than bps basis points away from the
+// reference price of the book. It lets everything through while the book has
+// no reference price.
+func PriceCollar(bps int64) Rule {
+	return RuleFunc(func(order *orderbook.Order, _ *orderbook.Order, book Book) *Rejection {
+		ref := reference(book)
+		if order.Price == 0 || ref == 0 {
+			return nil
+		}
+		band, ok := ref.MulChecked(orderbook.NewDecimal(bps, 4))
+		if !ok || (order.Price >= ref-band && order.Price <= ref+band) {
+			return nil
+		}
+		return &Rejection{
+			Rule:   RulePriceCollar,
+			Reason: "Order price is outside the collar around " + ref.String(),
+			Limit:  strconv.FormatInt(bps, 10) + " bps",
+			Value:  order.Price.String(),
+		}
+	})
+}
+
+// MaxOpenOrders rejects orders that would give their account more than limit
+// resting and stop orders. Orders that cannot rest, and modifications, are
+// never rejected.
+func MaxOpenOrders(limit int) Rule {
+	return RuleFunc(func(order *orderbook.Order, old *orderbook.Order, book Book) *Rejection {
+		if old != nil || !order.Rests() {
+			return nil
+		}
+		open := book.OpenOrders(order.Account)
+		if open < limit {
+			return nil
+		}
+		return &Rejection{
+			Rule:   RuleMaxOpenOrders,
+			Reason: "Account has too many open orders",
+			Limit:  strconv.Itoa(limit),
+			Value:  strconv.Itoa(open + 1),
+		}
+	})
+}
+
+// MaxPosition rejects orders that, filled completely along with the open
+// orders of their account on the same side, would take its position beyond
+// limit either long or short. Orders that reduce the position are always let
+// through.
+func MaxPosition(limit orderbook.Decimal) Rule {
+	return RuleFunc(func(order *orderbook.Order, old *orderbook.Order, book Book) *Rejection {
+		position := book.Position(order.Account)
+		exposure := book.OpenExposure(order.Account, order.Side) + order.Amount
+		if old != nil {
+			exposure -= old.Amount // Replaced by the modified order
+		}
+		projected := position + exposure
+		if order.Side == orderbook.Sell {
+			projected = position - exposure
+		}
+		if abs(projected) <= limit || abs(projected) <= abs(position) {
+			return nil
+		}
+		return &Rejection{
+			Rule:   RuleMaxPosition,
+			Reason: "Order would take the position beyond the limit",
+			Limit:  limit.String(),
+			Value:  projected.String(),
+		}
+	})
+}
+
+// reference returns the price rules measure orders against: the last trade,
+// the mid price without trades, or the only side quoted. Zero if the book is
+// empty and has never traded.
+func reference(book Book) orderbook.Decimal {
+	if price := book.LastPrice(); price > 0 {
+		return price
+	}
+	bid, bidErr := book.GetBestBid()
+	ask, askErr := book.GetBestAsk()
+	switch {
+	case bidErr == nil && askErr == nil:
+		return bid.Price + (ask.Price-bid.Price)/2
+	case bidErr == nil:
+		return bid.Price
+	case askErr == nil:
+		return ask.Price
+	}
+	return 0
+}
+
+func abs(d orderbook.Decimal) orderbook.Decimal {
+	if d < 0 {
+		return -d
+	}
+	return d
+}